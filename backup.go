@@ -0,0 +1,639 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version of the export document format, bumped when its shape changes
+const snapshotSchemaVersion = 1
+
+// Export rows written between flushes
+const exportFlushEvery = 100
+
+// Tables whose contents POST /api/admin/import?mode=replace replaces
+var importTables = []string{"heroes", "hero_tags", "tags", "hero_counters", "hero_tiers"}
+
+// Import modes
+const (
+	importModeReplace = "replace"
+	importModeMerge   = "merge"
+)
+
+// Queries of the export and import
+var (
+	queryExportHeroes   = registerBuiltQuery("admin.export_heroes")
+	queryExportCounters = registerQuery("admin.export_counters", "SELECT hero_id, countered_by_id FROM hero_counters ORDER BY hero_id, countered_by_id")
+	queryExportTiers    = registerQuery("admin.export_tiers", "SELECT patch, hero_id, tier FROM hero_tiers ORDER BY patch, hero_id")
+	queryImportTruncate = registerBuiltQuery("admin.import_truncate")
+	queryImportLockHero = registerBuiltQuery("admin.import_lock_hero")
+	queryImportHero     = registerBuiltQuery("admin.import_hero")
+	queryImportUntag    = registerQuery("admin.import_untag", "DELETE FROM hero_tags WHERE hero_id = $1", paramHeroID)
+	queryImportCounter  = registerQuery("admin.import_counter", `INSERT INTO hero_counters (hero_id, countered_by_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, paramHeroID, paramHeroID)
+	queryImportTier = registerQuery("admin.import_tier", `INSERT INTO hero_tiers (patch, hero_id, tier) VALUES ($1, $2, $3)
+		ON CONFLICT (patch, hero_id) DO UPDATE SET tier = EXCLUDED.tier, updated_at = CURRENT_TIMESTAMP
+		WHERE hero_tiers.tier <> EXCLUDED.tier
+		RETURNING (xmax = 0)`, paramText, paramHeroID, paramText)
+	queryImportSequence = registerBuiltQuery("admin.import_sequence")
+)
+
+// Columns written by the import, in importHeroArgs order
+const importHeroColumns = "id, name, role, difficulty, difficulty_score, created_at, updated_at, archived_at, deleted_at, lore, specialty, lane, release_date"
+
+// GET /api/admin/export - Export the hero dataset
+// @Summary Export heroes
+// @Description Download every hero (archived and deleted ones included) with its tags, counters and tier placements as one JSON document,
+// @Description read from a single consistent snapshot. The document is streamed, so a failure midway leaves it truncated; check that it parses before relying on it.
+// @Description Favorites and view counts are not included.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} Snapshot
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/export [get]
+func exportHeroes(w http.ResponseWriter, r *http.Request) {
+	// Every table is read from the same snapshot
+	tx, err := beginTx(r.Context(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to export heroes")
+		return
+	}
+	defer tx.Rollback()
+
+	heroes, err := queryExportHeroes.WithContext(r.Context()).Build("SELECT " + heroColumns + " FROM heroes ORDER BY id").In(tx).Query()
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to export heroes")
+		return
+	}
+	defer heroes.Close()
+
+	// Large exports may take longer than the server's write timeout
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="heroes-%s.json"`, dbClock.Now().UTC().Format("20060102-150405")))
+	w.WriteHeader(http.StatusOK)
+
+	stream := &exportStream{w: w, controller: controller}
+	exportedAt, _ := json.Marshal(dbClock.Now().UTC())
+	stream.raw(fmt.Sprintf(`{"schema_version":%d,"exported_at":%s,"heroes":[`, snapshotSchemaVersion, exportedAt))
+	for heroes.Next() {
+		var hero Hero
+		if err := heroes.Scan(heroScanDest(&hero)...); err != nil {
+			stream.fail(r, err)
+			return
+		}
+		stream.item(hero)
+	}
+	if err := heroes.Err(); err != nil {
+		stream.fail(r, err)
+		return
+	}
+
+	stream.section("counters")
+	counters, err := queryExportCounters.WithContext(r.Context()).In(tx).Query()
+	if err != nil {
+		stream.fail(r, err)
+		return
+	}
+	defer counters.Close()
+	for counters.Next() {
+		var counter HeroCounter
+		if err := counters.Scan(&counter.HeroID, &counter.CounteredByID); err != nil {
+			stream.fail(r, err)
+			return
+		}
+		stream.item(counter)
+	}
+	if err := counters.Err(); err != nil {
+		stream.fail(r, err)
+		return
+	}
+
+	stream.section("tiers")
+	tiers, err := queryExportTiers.WithContext(r.Context()).In(tx).Query()
+	if err != nil {
+		stream.fail(r, err)
+		return
+	}
+	defer tiers.Close()
+	for tiers.Next() {
+		var tier TierPlacement
+		if err := tiers.Scan(&tier.Patch, &tier.HeroID, &tier.Tier); err != nil {
+			stream.fail(r, err)
+			return
+		}
+		stream.item(tier)
+	}
+	if err := tiers.Err(); err != nil {
+		stream.fail(r, err)
+		return
+	}
+
+	stream.raw("]}\n")
+	if stream.err != nil {
+		requestLogger(r).Warn("Export aborted", "error", stream.err)
+		return
+	}
+	stream.controller.Flush()
+
+	session, _ := sessionFromRequest(r)
+	requestLogger(r).Info("AUDIT heroes exported", "rows", stream.rows, "by", session.Username)
+}
+
+// exportStream writes the export document piece by piece, flushing every
+// exportFlushEvery rows. After the first write error every write is a no-op.
+type exportStream struct {
+	w          io.Writer
+	controller *http.ResponseController
+	rows       int
+	first      bool
+	err        error
+}
+
+// raw writes text as is
+func (s *exportStream) raw(text string) {
+	if s.err == nil {
+		_, s.err = io.WriteString(s.w, text)
+	}
+	s.first = true
+}
+
+// section closes the current array and opens the one named key
+func (s *exportStream) section(key string) {
+	s.raw(`],"` + key + `":[`)
+}
+
+// item writes one array element
+func (s *exportStream) item(value interface{}) {
+	if s.err != nil {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		s.err = err
+		return
+	}
+	if !s.first {
+		data = append([]byte(","), data...)
+	}
+	s.first = false
+	if _, s.err = s.w.Write(data); s.err != nil {
+		return
+	}
+	if s.rows++; s.rows%exportFlushEvery == 0 {
+		s.controller.Flush()
+	}
+}
+
+// fail gives up on the export. The status is already sent, so the client only
+// sees a truncated document.
+func (s *exportStream) fail(r *http.Request, err error) {
+	requestLogger(r).Error("Export failed midway", "error", err, "rows", s.rows)
+}
+
+// POST /api/admin/import - Import a hero dataset
+// @Summary Import heroes
+// @Description Restore heroes, tags, counters and tier placements from a document produced by GET /api/admin/export, in one transaction.
+// @Description The schema_version and every record are validated before anything is written; any problem fails the whole import with 422.
+// @Description mode=replace (the default) empties heroes, tags, counters and tier placements first and, with DESTRUCTIVE_CONFIRMATION on, asks for confirmation when they aren't empty.
+// @Description mode=merge keeps existing rows: heroes are matched by ID and overwritten when they differ, counters are added and tier placements overwritten.
+// @Description The body may be up to SERVER_MAX_IMPORT_BYTES. Favorites and view counts are left untouched.
+// @Tags admin
+// @Accept json
+// @Produce json,application/xml
+// @Param mode query string false "replace or merge (default replace)"
+// @Param snapshot body Snapshot true "Exported dataset"
+// @Success 200 {object} ImportSummary
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse
+// @Failure 428 {object} ConfirmationRequiredResponse
+// @Security BearerAuth
+// @Router /api/admin/import [post]
+func importHeroes(w http.ResponseWriter, r *http.Request) {
+	mode, ok := importMode(w, r)
+	if !ok {
+		return
+	}
+
+	// Large documents may take longer to upload than the server's read timeout
+	http.NewResponseController(w).SetReadDeadline(time.Time{})
+
+	snapshot, violations, err := decodeSnapshot(r.Body)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			respondWithError(w, r, http.StatusBadRequest, ErrCodeBodyRequired, "Request body is required")
+		} else if !respondWithBodyTooLarge(w, r, err) {
+			respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidPayload, "Invalid request payload: "+err.Error())
+		}
+		return
+	}
+	if violations == nil {
+		violations = validateSnapshot(&snapshot)
+	}
+	if len(violations) > 0 {
+		respondWithViolations(w, r, violations)
+		return
+	}
+
+	tx, err := beginTx(r.Context(), nil)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to import heroes")
+		return
+	}
+	defer tx.Rollback()
+
+	summary, err := applySnapshot(tx, mode, snapshot)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to import heroes")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithInternalError(w, r, err, "Failed to import heroes")
+		return
+	}
+	invalidateHeroCache()
+
+	session, _ := sessionFromRequest(r)
+	requestLogger(r).Info("AUDIT heroes imported", "mode", mode,
+		"heroes_inserted", summary.Heroes.Inserted, "heroes_updated", summary.Heroes.Updated, "heroes_skipped", summary.Heroes.Skipped,
+		"by", session.Username)
+
+	respondWith(w, r, http.StatusOK, summary)
+}
+
+// importMode reads ?mode=, responding with a 400 and returning false when it is unknown
+func importMode(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", importModeReplace:
+		return importModeReplace, true
+	case importModeMerge:
+		return importModeMerge, true
+	default:
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, "mode must be replace or merge")
+		return "", false
+	}
+}
+
+// Describe the rows removed by POST /api/admin/import?mode=replace; merges remove nothing
+func describeImport(r *http.Request) (AffectedRows, error) {
+	if mode := r.URL.Query().Get("mode"); mode != "" && mode != importModeReplace {
+		return AffectedRows{}, nil
+	}
+	return countTableRows(r.Context(), importTables)
+}
+
+// decodeSnapshot reads an export document one record at a time so the raw
+// body is never held in memory. A schema_version that comes before the
+// records and doesn't match is reported without reading further.
+func decodeSnapshot(body io.Reader) (Snapshot, []Violation, error) {
+	var snapshot Snapshot
+	decoder := json.NewDecoder(body)
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return snapshot, nil, err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return snapshot, nil, err
+		}
+		key, _ := token.(string)
+
+		switch key {
+		case "schema_version":
+			if err := decoder.Decode(&snapshot.SchemaVersion); err != nil {
+				return snapshot, nil, fmt.Errorf("schema_version: %v", err)
+			}
+			if violation := checkSchemaVersionField(snapshot.SchemaVersion); violation != nil {
+				return snapshot, []Violation{*violation}, nil
+			}
+		case "exported_at":
+			if err := decoder.Decode(&snapshot.ExportedAt); err != nil {
+				return snapshot, nil, fmt.Errorf("exported_at: %v", err)
+			}
+		case "heroes":
+			err = decodeArray(decoder, func() error {
+				var hero Hero
+				err := decoder.Decode(&hero)
+				snapshot.Heroes = append(snapshot.Heroes, hero)
+				return err
+			})
+		case "counters":
+			err = decodeArray(decoder, func() error {
+				var counter HeroCounter
+				err := decoder.Decode(&counter)
+				snapshot.Counters = append(snapshot.Counters, counter)
+				return err
+			})
+		case "tiers":
+			err = decodeArray(decoder, func() error {
+				var tier TierPlacement
+				err := decoder.Decode(&tier)
+				snapshot.Tiers = append(snapshot.Tiers, tier)
+				return err
+			})
+		default:
+			return snapshot, nil, fmt.Errorf("unknown field %q", key)
+		}
+		if err != nil {
+			return snapshot, nil, fmt.Errorf("%s: %v", key, err)
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return snapshot, nil, err
+	}
+
+	var extra json.RawMessage
+	if err := decoder.Decode(&extra); !errors.Is(err, io.EOF) {
+		if err == nil {
+			err = errors.New("trailing data after JSON value")
+		}
+		return snapshot, nil, err
+	}
+	return snapshot, nil, nil
+}
+
+// expectDelim reads the next token and checks that it is delim
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %q", delim)
+	}
+	return nil
+}
+
+// decodeArray calls element once per element of the array at the decoder
+func decodeArray(decoder *json.Decoder, element func() error) error {
+	if err := expectDelim(decoder, '['); err != nil {
+		return err
+	}
+	for decoder.More() {
+		if err := element(); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, ']')
+}
+
+// checkSchemaVersionField reports a violation unless version is one this server reads
+func checkSchemaVersionField(version int) *Violation {
+	if version == snapshotSchemaVersion {
+		return nil
+	}
+	return &Violation{
+		Path:    jsonPointer("schema_version"),
+		Message: fmt.Sprintf("unsupported schema_version %d, expected %d", version, snapshotSchemaVersion),
+	}
+}
+
+// validateSnapshot checks every record, normalizing IDs, difficulties and tags
+// in place so applySnapshot can write them as they are
+func validateSnapshot(snapshot *Snapshot) []Violation {
+	var violations []Violation
+	fail := func(message string, segments ...string) {
+		violations = append(violations, Violation{Path: jsonPointer(segments...), Message: message})
+	}
+
+	if violation := checkSchemaVersionField(snapshot.SchemaVersion); violation != nil {
+		violations = append(violations, *violation)
+	}
+
+	now := dbClock.Now().UTC()
+	ids := make(map[HeroID]bool, len(snapshot.Heroes))
+	names := make(map[string]bool, len(snapshot.Heroes))
+	for i := range snapshot.Heroes {
+		hero := &snapshot.Heroes[i]
+		index := strconv.Itoa(i)
+
+		id, err := heroIDs.Parse(string(hero.ID))
+		if err != nil {
+			fail("invalid hero id: "+err.Error(), "heroes", index, "id")
+		} else if ids[id] {
+			fail(fmt.Sprintf("hero id %s appears more than once", id), "heroes", index, "id")
+		}
+		hero.ID = id
+		ids[id] = true
+
+		// Imports follow the same input rules as the write endpoints
+		label := DifficultyField(hero.Difficulty)
+		normalizeHeroInput(&hero.Name, &hero.Role, &label, &hero.HeroDetails)
+		hero.Difficulty = string(label)
+
+		if hero.Name == "" {
+			fail("name is required", "heroes", index, "name")
+		} else if names[hero.Name] {
+			fail(fmt.Sprintf("hero name %q appears more than once", hero.Name), "heroes", index, "name")
+		}
+		names[hero.Name] = true
+
+		if hero.Role == "" {
+			fail("role is required", "heroes", index, "role")
+		}
+		if hero.Difficulty == "" {
+			fail("difficulty is required", "heroes", index, "difficulty")
+		} else if difficulty, err := resolveDifficulty(DifficultyField(hero.Difficulty), hero.DifficultyScore); err != nil {
+			fail(err.Error(), "heroes", index, "difficulty")
+		} else {
+			hero.Difficulty, hero.DifficultyScore = difficulty.Label, difficulty.Score
+		}
+
+		if hero.Tags, err = normalizeTags(hero.Tags); err != nil {
+			fail(err.Error(), "heroes", index, "tags")
+		}
+
+		// Documents written by hand may leave out the timestamps
+		if hero.CreatedAt.IsZero() {
+			hero.CreatedAt = now
+		}
+		if hero.UpdatedAt.IsZero() {
+			hero.UpdatedAt = hero.CreatedAt
+		}
+		hero.CreatedAt, hero.UpdatedAt = hero.CreatedAt.UTC(), hero.UpdatedAt.UTC()
+		for _, marker := range []*time.Time{hero.ArchivedAt, hero.DeletedAt} {
+			if marker != nil {
+				*marker = marker.UTC()
+			}
+		}
+	}
+
+	// Counters and tier placements may only refer to heroes in the document
+	known := func(raw HeroID, segments ...string) HeroID {
+		id, err := heroIDs.Parse(string(raw))
+		if err != nil {
+			fail("invalid hero id: "+err.Error(), segments...)
+		} else if !ids[id] {
+			fail(fmt.Sprintf("hero %s is not in the document", id), segments...)
+		}
+		return id
+	}
+
+	counters := make(map[HeroCounter]bool, len(snapshot.Counters))
+	for i := range snapshot.Counters {
+		counter := &snapshot.Counters[i]
+		index := strconv.Itoa(i)
+
+		counter.HeroID = known(counter.HeroID, "counters", index, "hero_id")
+		counter.CounteredByID = known(counter.CounteredByID, "counters", index, "countered_by_id")
+		if counter.HeroID == counter.CounteredByID {
+			fail("a hero cannot counter itself", "counters", index)
+		} else if counters[*counter] {
+			fail("counter appears more than once", "counters", index)
+		}
+		counters[*counter] = true
+	}
+
+	type placement struct {
+		patch string
+		hero  HeroID
+	}
+	allowedTiers := make(map[string]bool, len(config.Validation.TierLists.Tiers))
+	for _, tier := range config.Validation.TierLists.Tiers {
+		allowedTiers[tier] = true
+	}
+	placements := make(map[placement]bool, len(snapshot.Tiers))
+	for i := range snapshot.Tiers {
+		tier := &snapshot.Tiers[i]
+		index := strconv.Itoa(i)
+
+		tier.HeroID = known(tier.HeroID, "tiers", index, "hero_id")
+		if strings.TrimSpace(tier.Patch) == "" {
+			fail("patch is required", "tiers", index, "patch")
+		}
+		if !allowedTiers[tier.Tier] {
+			fail(fmt.Sprintf("unknown tier %q, allowed tiers are %s", tier.Tier, strings.Join(config.Validation.TierLists.Tiers, ", ")),
+				"tiers", index, "tier")
+		}
+		key := placement{tier.Patch, tier.HeroID}
+		if placements[key] {
+			fail(fmt.Sprintf("hero %s is placed more than once in patch %s", tier.HeroID, tier.Patch), "tiers", index)
+		}
+		placements[key] = true
+	}
+
+	return violations
+}
+
+// applySnapshot writes a validated snapshot inside tx
+func applySnapshot(tx *sql.Tx, mode string, snapshot Snapshot) (ImportSummary, error) {
+	summary := ImportSummary{Mode: mode}
+
+	if mode == importModeReplace {
+		if _, err := queryImportTruncate.Build("TRUNCATE " + strings.Join(importTables, ", ")).In(tx).Exec(); err != nil {
+			return summary, err
+		}
+	}
+
+	insert := queryImportHero.Build("INSERT INTO heroes (" + importHeroColumns + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)")
+	// The update trigger replaces updated_at with the time of the import
+	update := queryImportHero.Build(`UPDATE heroes SET name = $2, role = $3, difficulty = $4, difficulty_score = $5,
+		created_at = $6, updated_at = $7, archived_at = $8, deleted_at = $9, lore = $10, specialty = $11, lane = $12, release_date = $13
+		WHERE id = $1`)
+	lock := queryImportLockHero.Build("SELECT " + heroColumns + " FROM heroes WHERE id = $1 FOR UPDATE")
+
+	for _, hero := range snapshot.Heroes {
+		if mode == importModeMerge {
+			var existing Hero
+			err := lock.In(tx).QueryRow(hero.ID).Scan(heroScanDest(&existing)...)
+			switch {
+			case err == nil && sameHero(existing, hero):
+				summary.Heroes.Skipped++
+				continue
+			case err == nil:
+				if _, err := update.In(tx).Exec(importHeroArgs(hero)...); err != nil {
+					return summary, err
+				}
+				if _, err := queryImportUntag.In(tx).Exec(hero.ID); err != nil {
+					return summary, err
+				}
+				if err := tagHero(tx, hero.ID, hero.Tags); err != nil {
+					return summary, err
+				}
+				summary.Heroes.Updated++
+				continue
+			case err != sql.ErrNoRows:
+				return summary, err
+			}
+		}
+
+		if _, err := insert.In(tx).Exec(importHeroArgs(hero)...); err != nil {
+			return summary, err
+		}
+		if err := tagHero(tx, hero.ID, hero.Tags); err != nil {
+			return summary, err
+		}
+		summary.Heroes.Inserted++
+	}
+
+	for _, counter := range snapshot.Counters {
+		result, err := queryImportCounter.In(tx).Exec(counter.HeroID, counter.CounteredByID)
+		if err != nil {
+			return summary, err
+		}
+		if added, _ := result.RowsAffected(); added > 0 {
+			summary.Counters.Inserted++
+		} else {
+			summary.Counters.Skipped++
+		}
+	}
+
+	for _, tier := range snapshot.Tiers {
+		var inserted bool
+		err := queryImportTier.In(tx).QueryRow(tier.Patch, tier.HeroID, tier.Tier).Scan(&inserted)
+		switch {
+		case err == sql.ErrNoRows:
+			summary.Tiers.Skipped++
+		case err != nil:
+			return summary, err
+		case inserted:
+			summary.Tiers.Inserted++
+		default:
+			summary.Tiers.Updated++
+		}
+	}
+
+	// Heroes created after the import must not reuse an imported serial ID
+	if heroIDs.Name() == idStrategySerial && summary.Heroes.Inserted > 0 {
+		if _, err := queryImportSequence.Build(`SELECT setval(pg_get_serial_sequence('heroes', 'id'),
+			COALESCE((SELECT MAX(id) FROM heroes), 0) + 1, false)`).In(tx).Exec(); err != nil {
+			return summary, err
+		}
+	}
+
+	return summary, nil
+}
+
+// importHeroArgs returns the values of importHeroColumns for hero
+func importHeroArgs(hero Hero) []interface{} {
+	return []interface{}{hero.ID, hero.Name, hero.Role, hero.Difficulty, hero.DifficultyScore,
+		hero.CreatedAt, hero.UpdatedAt, hero.ArchivedAt, hero.DeletedAt,
+		hero.Lore, hero.Specialty, hero.Lane, hero.ReleaseDate}
+}
+
+// sameHero reports whether an import would leave existing unchanged. updated_at
+// is ignored since the update trigger sets it anyway.
+func sameHero(existing, imported Hero) bool {
+	existing.UpdatedAt, imported.UpdatedAt = time.Time{}, time.Time{}
+	if len(existing.Tags) == 0 && len(imported.Tags) == 0 {
+		existing.Tags, imported.Tags = nil, nil
+	}
+	a, errA := json.Marshal(existing)
+	b, errB := json.Marshal(imported)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
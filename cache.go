@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// cachedResponse is a serialized response stored by responseCache
+type cachedResponse struct {
+	status    int
+	header    http.Header
+	body      []byte
+	expiresAt time.Time
+}
+
+// responseCache is a small TTL cache of serialized GET responses
+type responseCache struct {
+	mu      sync.RWMutex
+	enabled bool
+	ttl     time.Duration
+	entries map[string]cachedResponse
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// Cache for the heroes list and single heroes, configured in initHeroCache
+var heroCache = &responseCache{entries: make(map[string]cachedResponse)}
+
+// initHeroCache applies the cache settings
+func initHeroCache(cfg CacheConfig) {
+	heroCache.mu.Lock()
+	defer heroCache.mu.Unlock()
+
+	heroCache.ttl = cfg.TTL
+	heroCache.enabled = cfg.Enabled && heroCache.ttl > 0
+}
+
+// get returns a live entry for key
+func (c *responseCache) get(key string) (cachedResponse, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.entries[key]
+	if !exists || time.Now().After(entry.expiresAt) {
+		return cachedResponse{}, false
+	}
+	return entry, true
+}
+
+// set stores a response under key for the configured TTL
+func (c *responseCache) set(key string, entry cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.expiresAt = time.Now().Add(c.ttl)
+	c.entries[key] = entry
+}
+
+// invalidate drops every cached entry
+func (c *responseCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]cachedResponse)
+}
+
+// isEnabled reports whether responses should be cached
+func (c *responseCache) isEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.enabled
+}
+
+// invalidateHeroCache must be called after any successful hero mutation
+func invalidateHeroCache() {
+	heroCache.invalidate()
+}
+
+// Headers describing the cached representation, the only ones replayed on a
+// hit. Everything else on the writer belongs to the request that filled the
+// entry (X-Request-ID, X-RateLimit-*) or is set per request by the middleware,
+// and Cache-Control is recomputed since it depends on the caller.
+var cachedHeaders = []string{"Content-Type", "Content-Length", "ETag", "Last-Modified", "Link", "X-Total-Count"}
+
+// Cache middleware serving successful GET responses from c, keyed by
+// query parameters and negotiated format, to GET and HEAD requests. Sets X-Cache to HIT or MISS.
+func cacheMiddleware(c *responseCache, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Streams are never buffered, so they can't be cached either
+		if !c.isEnabled() || negotiateFormat(r) == formatNDJSON {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Encode sorts parameters, so equivalent queries share an entry. Visibility and
+		// audience are part of the key so callers never see rows or fields hidden from them.
+		key := negotiateFormat(r) + " " + string(visibilityFor(r)) + " " + audienceFor(r).String() + " " + r.URL.Path + "?" + r.URL.Query().Encode()
+
+		if entry, hit := c.get(key); hit {
+			c.hits.Add(1)
+			for name, values := range entry.header {
+				w.Header()[name] = values
+			}
+			setReadCacheControl(w, r)
+			w.Header().Set("X-Cache", "HIT")
+			w.WriteHeader(entry.status)
+			if r.Method != http.MethodHead {
+				w.Write(entry.body)
+			}
+			return
+		}
+
+		c.misses.Add(1)
+		w.Header().Set("X-Cache", "MISS")
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// HEAD responses have no body to store; they are served from GET entries
+		if recorder.status == http.StatusOK && r.Method == http.MethodGet {
+			header := http.Header{}
+			for _, name := range cachedHeaders {
+				if values := w.Header().Values(name); len(values) > 0 {
+					header[name] = append([]string(nil), values...)
+				}
+			}
+			c.set(key, cachedResponse{status: recorder.status, header: header, body: recorder.body.Bytes()})
+		}
+	})
+}
+
+// responseRecorder passes a response through while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader records the status code
+func (rec *responseRecorder) WriteHeader(code int) {
+	rec.status = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the body
+func (rec *responseRecorder) Write(data []byte) (int, error) {
+	rec.body.Write(data)
+	return rec.ResponseWriter.Write(data)
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// stats reports the cache size and how often it was hit
+func (c *responseCache) stats() CacheStats {
+	c.mu.RLock()
+	stats := CacheStats{Enabled: c.enabled, TTLSeconds: c.ttl.Seconds(), Entries: len(c.entries)}
+	c.mu.RUnlock()
+
+	stats.Hits = c.hits.Load()
+	stats.Misses = c.misses.Load()
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+	return stats
+}
+
+// GET /api/admin/cache - Hero cache statistics
+// @Summary Hero cache statistics
+// @Description Size of the in-memory hero response cache and its hits and misses since startup
+// @Tags admin
+// @Produce json,application/xml
+// @Success 200 {object} CacheStats
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/cache [get]
+func getCacheStats(w http.ResponseWriter, r *http.Request) {
+	respondWith(w, r, http.StatusOK, heroCache.stats())
+}
+
+// statusRecorder passes a response through, keeping only its status code
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code
+func (rec *statusRecorder) WriteHeader(code int) {
+	rec.status = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Flush passes through to the wrapped writer so streamed responses still flush
+func (rec *statusRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// setReadCacheControl marks a successful hero read as cacheable for the
+// configured max-age. Responses to authenticated callers may include archived
+// heroes, so only the caller's own cache may keep them.
+func setReadCacheControl(w http.ResponseWriter, r *http.Request) {
+	maxAge := int(config.Cache.MaxAge / time.Second)
+	if maxAge <= 0 {
+		w.Header().Set("Cache-Control", "no-cache")
+		return
+	}
+
+	scope := "public"
+	if r.Header.Get("Authorization") != "" {
+		scope = "private"
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", scope, maxAge))
+}
+
+// noStoreMiddleware forbids caching of mutation responses
+func noStoreMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCacheMiddlewareReplaysOnlyRepresentationHeaders(t *testing.T) {
+	useTestConfig(t)
+	cache := &responseCache{enabled: true, ttl: time.Minute, entries: make(map[string]cachedResponse)}
+
+	calls := 0
+	handler := cacheMiddleware(cache, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-Total-Count", "42")
+		w.Header().Set("Link", `</api/heroes?limit=10&page=2>; rel="next"`)
+		setReadCacheControl(w, r)
+		respondWith(w, r, http.StatusOK, []Hero{{ID: "1", Name: "Alucard"}})
+	}))
+
+	// Per-request headers, as requestLogMiddleware and rateLimitMiddleware set them
+	get := func(method, requestID string, remaining int, authorization string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/api/heroes?limit=10", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		rec.Header().Set(requestIDHeader, requestID)
+		rec.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	miss := get(http.MethodGet, "first", 9, "")
+	if got := miss.Header().Get("X-Cache"); got != "MISS" {
+		t.Fatalf("first request X-Cache = %q, want MISS", got)
+	}
+
+	hit := get(http.MethodGet, "second", 8, "Bearer someone")
+	if got := hit.Header().Get("X-Cache"); got != "HIT" {
+		t.Fatalf("second request X-Cache = %q, want HIT", got)
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+	if hit.Body.String() != miss.Body.String() {
+		t.Errorf("hit body %q, want %q", hit.Body.String(), miss.Body.String())
+	}
+
+	for header, want := range map[string]string{
+		requestIDHeader:         "second",
+		"X-RateLimit-Remaining": "8",
+		"X-Total-Count":         "42",
+		"Link":                  miss.Header().Get("Link"),
+		"Content-Type":          "application/json",
+		"Content-Length":        strconv.Itoa(miss.Body.Len()),
+		"Cache-Control":         "private, max-age=30",
+	} {
+		if got := hit.Header().Get(header); got != want {
+			t.Errorf("hit %s = %q, want %q", header, got, want)
+		}
+	}
+	if got := len(hit.Header().Values(requestIDHeader)); got != 1 {
+		t.Errorf("hit has %d %s values, want 1", got, requestIDHeader)
+	}
+
+	head := get(http.MethodHead, "third", 7, "")
+	if got := head.Header().Get("X-Cache"); got != "HIT" {
+		t.Fatalf("HEAD X-Cache = %q, want HIT", got)
+	}
+	if head.Body.Len() != 0 {
+		t.Errorf("HEAD body = %q, want empty", head.Body.String())
+	}
+	if got := head.Header().Get("Content-Length"); got != strconv.Itoa(miss.Body.Len()) {
+		t.Errorf("HEAD Content-Length = %q, want the GET length", got)
+	}
+	if got := head.Header().Get("Cache-Control"); got != "public, max-age=30" {
+		t.Errorf("HEAD Cache-Control = %q, want public for an anonymous caller", got)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells the time; tests substitute a fake to simulate skew
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the application host's clock
+type systemClock struct{}
+
+// Now returns the host time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// dbAlignedClock is used wherever application time is compared with timestamps
+// written by Postgres. When preferDB is set it applies the last measured skew so
+// comparisons follow the database clock.
+type dbAlignedClock struct {
+	base     Clock
+	preferDB bool
+	skew     atomic.Int64
+}
+
+// Now returns the host time, shifted to database time when preferred
+func (c *dbAlignedClock) Now() time.Time {
+	if !c.preferDB {
+		return c.base.Now()
+	}
+	return c.base.Now().Add(c.Skew())
+}
+
+// Skew returns the last measured offset of the database clock from the host clock
+func (c *dbAlignedClock) Skew() time.Duration {
+	return time.Duration(c.skew.Load())
+}
+
+// Clock for comparisons against DB-written timestamps, configured in initClockSkew
+var dbClock = &dbAlignedClock{base: systemClock{}}
+
+// Reads the database clock
+var queryDatabaseTime = registerQuery("clock.now", "SELECT now()")
+
+// measureClockSkew compares the host clock with SELECT now(), using the midpoint
+// of the round trip so query latency isn't counted as skew
+func measureClockSkew(clock Clock) (time.Duration, error) {
+	before := clock.Now()
+	var dbTime time.Time
+	if err := queryDatabaseTime.QueryRow().Scan(&dbTime); err != nil {
+		return 0, err
+	}
+	after := clock.Now()
+
+	midpoint := before.Add(after.Sub(before) / 2)
+	return dbTime.Sub(midpoint), nil
+}
+
+// checkClockSkew measures and records the skew, warning above threshold
+func checkClockSkew(threshold time.Duration) {
+	skew, err := measureClockSkew(dbClock.base)
+	if err != nil {
+		slog.Warn("Failed to measure clock skew against database", "error", err)
+		return
+	}
+	dbClock.skew.Store(int64(skew))
+
+	if skew.Abs() > threshold {
+		slog.Warn("Database clock differs from host clock", "skew", skew, "threshold", threshold)
+	}
+}
+
+// initClockSkew measures the skew at startup and keeps re-measuring in the background
+func initClockSkew(cfg ClockConfig) {
+	dbClock.preferDB = cfg.PreferDatabaseTime
+	threshold := cfg.SkewWarnThreshold
+	interval := cfg.SkewCheckInterval
+
+	checkClockSkew(threshold)
+	slog.Info("Database clock skew", "skew", dbClock.Skew())
+
+	go func() {
+		for {
+			time.Sleep(interval)
+			checkClockSkew(threshold)
+		}
+	}()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Names tried for a clone before giving up
+const maxCloneNameAttempts = 100
+
+// cloneName is the nth name tried for a copy of name: "X (copy)", then "X (copy 2)", ...
+func cloneName(name string, n int) string {
+	if n == 1 {
+		return name + " (copy)"
+	}
+	return fmt.Sprintf("%s (copy %d)", name, n)
+}
+
+// POST /api/heroes/{id}/clone - Duplicate a hero
+// @Summary Clone hero
+// @Description Create a copy of a hero named "<name> (copy)", or "(copy 2)", "(copy 3)", ... when taken
+// @Tags heroes
+// @Produce json,application/xml
+// @Param id path string true "ID of the hero to copy"
+// @Success 201 {object} Hero
+// @Header 201 {string} Location "URL of the created hero"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/{id}/clone [post]
+func cloneHero(w http.ResponseWriter, r *http.Request) {
+	id, err := heroIDs.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
+		return
+	}
+
+	filter := &heroFilter{}
+	filter.add("id = $%d", id)
+	filter.restrictVisibility(r)
+
+	var source Hero
+	err = queryGetHero.WithContext(r.Context()).Build("SELECT " + heroColumns + " FROM heroes" + filter.where()).QueryRow(filter.args...).
+		Scan(heroScanDest(&source)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, r, http.StatusNotFound, ErrCodeHeroNotFound, "Hero not found")
+		} else {
+			respondWithInternalError(w, r, err, "Failed to fetch hero")
+		}
+		return
+	}
+
+	difficulty := heroDifficulty{Label: source.Difficulty, Score: source.DifficultyScore}
+
+	// Insert and retry on the unique name constraint, so concurrent clones
+	// can't both claim the same suffix
+	var hero Hero
+	for n := 1; ; n++ {
+		if n > maxCloneNameAttempts {
+			respondWithError(w, r, http.StatusConflict, ErrCodeHeroNameTaken, "No free name for a copy of this hero")
+			return
+		}
+
+		insert, args := heroInsert(cloneName(source.Name, n), source.Role, difficulty, source.HeroDetails)
+		err = queryCreateHero.WithContext(r.Context()).Build(insert + " RETURNING " + heroColumns).QueryRow(args...).
+			Scan(heroScanDest(&hero)...)
+		if err == nil {
+			break
+		}
+		if !isUniqueViolation(err) {
+			respondWithInternalError(w, r, err, "Failed to clone hero")
+			return
+		}
+	}
+
+	invalidateHeroCache()
+	publishHeroEvent(r, eventCreated, hero)
+
+	w.Header().Set("Location", apiURL(r, "/heroes/"+hero.ID.String()))
+	respondWith(w, r, http.StatusCreated, hero)
+}
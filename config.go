@@ -0,0 +1,512 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Placeholder written instead of secrets by -print-config
+const redacted = "REDACTED"
+
+// BASE_PATH is one or more URL path segments, e.g. /mlbb or /games/mlbb
+var basePathPattern = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// AppConfig is the complete application configuration, merged from defaults,
+// config.yaml and environment variables (in increasing order of precedence)
+type AppConfig struct {
+	Server       ServerConfig      `yaml:"server"`
+	Database     DatabaseConfig    `yaml:"database"`
+	TLS          TLSConfig         `yaml:"tls"`
+	Cache        CacheConfig       `yaml:"cache"`
+	Clock        ClockConfig       `yaml:"clock"`
+	Destructive  DestructiveConfig `yaml:"destructive"`
+	IDs          IDConfig          `yaml:"ids"`
+	Lockout      LockoutConfig     `yaml:"lockout"`
+	Users        []User            `yaml:"users"`
+	Validation   ValidationRules   `yaml:"validation"`
+	RateLimits   RateLimitConfig   `yaml:"rate_limits"`
+	DisplayOrder DisplayOrder      `yaml:"display_order"`
+	Logging      LoggingConfig     `yaml:"logging"`
+	Pagination   PaginationConfig  `yaml:"pagination"`
+	Events       EventsConfig      `yaml:"events"`
+	Debug        DebugConfig       `yaml:"debug"`
+
+	// Revoke the tokens of users dropped from config.yaml on reload
+	RevokeRemovedUsers bool `yaml:"revoke_removed_users"`
+
+	// AdminUser is the ADMIN_USER/ADMIN_PASSWORD account, also listed in Users
+	AdminUser *User `yaml:"-"`
+}
+
+// ServerConfig holds HTTP server settings. The timeouts bound how long a
+// client may hold a connection, so slow clients can't exhaust them.
+type ServerConfig struct {
+	Port string `yaml:"port"`
+	// BasePath prefixes every route, e.g. /mlbb behind a proxy; empty serves from the root
+	BasePath          string        `yaml:"base_path"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes"`
+	// MaxBodyBytes caps API request bodies; 0 means no limit
+	MaxBodyBytes int `yaml:"max_body_bytes"`
+	// MaxImportBytes caps POST /api/admin/import bodies instead; 0 means no limit
+	MaxImportBytes int `yaml:"max_import_bytes"`
+	// HandlerTimeouts bound how long API handlers run, per route group
+	HandlerTimeouts HandlerTimeouts `yaml:"handler_timeouts"`
+}
+
+// DatabaseConfig holds database configuration
+type DatabaseConfig struct {
+	Host                  string        `yaml:"host"`
+	Port                  string        `yaml:"port"`
+	User                  string        `yaml:"user"`
+	Password              string        `yaml:"password"`
+	DBName                string        `yaml:"name"`
+	SSLMode               string        `yaml:"sslmode"`
+	ConnectMaxAttempts    int           `yaml:"connect_max_attempts"`
+	ConnectRetryInterval  time.Duration `yaml:"connect_retry_interval"`
+	StatementTimeout      time.Duration `yaml:"statement_timeout"`
+	SlowQueryThreshold    time.Duration `yaml:"slow_query_threshold"`
+	AutoMigrate           bool          `yaml:"auto_migrate"`
+	SeedInitialData       bool          `yaml:"seed_initial_data"`
+	SeedFile              string        `yaml:"seed_file"`
+	SchemaVersionOverride bool          `yaml:"schema_version_override"`
+	// ReplicaDSN is a read replica for list, detail and count queries
+	ReplicaDSN string `yaml:"replica_dsn"`
+	// Consecutive connection failures that open the circuit, and how long it
+	// stays open before the database is probed
+	BreakerThreshold int           `yaml:"breaker_threshold"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown"`
+}
+
+// CacheConfig holds the heroes list cache settings
+type CacheConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"`
+	// MaxAge is the Cache-Control max-age sent on hero reads
+	MaxAge time.Duration `yaml:"max_age"`
+}
+
+// ClockConfig holds clock skew detection settings
+type ClockConfig struct {
+	SkewWarnThreshold  time.Duration `yaml:"skew_warn_threshold"`
+	SkewCheckInterval  time.Duration `yaml:"skew_check_interval"`
+	PreferDatabaseTime bool          `yaml:"prefer_db_time"`
+}
+
+// DestructiveConfig holds the destructive operation interlock settings
+type DestructiveConfig struct {
+	Confirmation    bool          `yaml:"confirmation"`
+	ConfirmationTTL time.Duration `yaml:"confirmation_ttl"`
+}
+
+// defaultAppConfig returns the configuration used when nothing overrides it
+func defaultAppConfig() AppConfig {
+	return AppConfig{
+		Server: ServerConfig{
+			Port:              "8080",
+			ReadTimeout:       10 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			MaxBodyBytes:      1 << 20,
+			MaxImportBytes:    64 << 20,
+			HandlerTimeouts: HandlerTimeouts{
+				Read:  5 * time.Second,
+				Write: 10 * time.Second,
+				Bulk:  60 * time.Second,
+			},
+		},
+		Database: DatabaseConfig{
+			Host:                 "localhost",
+			Port:                 "5432",
+			User:                 "postgres",
+			Password:             "password",
+			DBName:               "heroes_db",
+			SSLMode:              "disable",
+			ConnectMaxAttempts:   10,
+			ConnectRetryInterval: time.Second,
+			SlowQueryThreshold:   200 * time.Millisecond,
+			AutoMigrate:          true,
+			SeedInitialData:      true,
+			BreakerThreshold:     3,
+			BreakerCooldown:      5 * time.Second,
+		},
+		Cache: CacheConfig{Enabled: true, TTL: 30 * time.Second, MaxAge: 30 * time.Second},
+		Clock: ClockConfig{
+			SkewWarnThreshold: 5 * time.Second,
+			SkewCheckInterval: 10 * time.Minute,
+		},
+		Destructive: DestructiveConfig{Confirmation: false, ConfirmationTTL: time.Minute},
+		IDs:         IDConfig{Strategy: idStrategySerial},
+		Lockout:     LockoutConfig{MaxAttempts: 5, Duration: 15 * time.Minute},
+		Logging:     LoggingConfig{Level: "info", Format: logFormatText},
+		Pagination:  PaginationConfig{MaxLimit: defaultMaxPageLimit, ExactCount: true},
+		Events:      EventsConfig{Audience: "admin"},
+	}
+}
+
+// Configuration file used when neither -config nor CONFIG_FILE names one
+const defaultConfigFile = "config.yaml"
+
+// configFile picks the configuration file: the -config flag, then CONFIG_FILE,
+// then config.yaml. explicit is false for the fallback, which may be missing.
+func configFile(flagValue string) (path string, explicit bool) {
+	if flagValue != "" {
+		return flagValue, true
+	}
+	if value := os.Getenv("CONFIG_FILE"); value != "" {
+		return value, true
+	}
+	return defaultConfigFile, false
+}
+
+// Active configuration, set once by LoadConfig at startup
+var config AppConfig
+
+// LoadConfig builds the configuration from defaults, the YAML file at path (if it
+// exists) and environment overrides, then validates it. Every problem found is
+// reported in the returned error rather than only the first.
+func LoadConfig(path string) (AppConfig, error) {
+	cfg := defaultAppConfig()
+	var problems []string
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		slog.Info("Config file not found, using defaults and environment variables", "path", path)
+	case err != nil:
+		problems = append(problems, fmt.Sprintf("reading %s: %v", path, err))
+	default:
+		// Unmarshal over the defaults so omitted keys keep their default values
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			problems = append(problems, fmt.Sprintf("parsing %s: %v", path, err))
+		}
+	}
+
+	env := envOverrides{}
+	env.str(&cfg.Server.Port, "SERVER_PORT")
+	env.str(&cfg.Server.BasePath, "BASE_PATH")
+	env.duration(&cfg.Server.ReadTimeout, "SERVER_READ_TIMEOUT")
+	env.duration(&cfg.Server.ReadHeaderTimeout, "SERVER_READ_HEADER_TIMEOUT")
+	env.duration(&cfg.Server.WriteTimeout, "SERVER_WRITE_TIMEOUT")
+	env.duration(&cfg.Server.IdleTimeout, "SERVER_IDLE_TIMEOUT")
+	env.integer(&cfg.Server.MaxHeaderBytes, "SERVER_MAX_HEADER_BYTES")
+	env.integer(&cfg.Server.MaxBodyBytes, "SERVER_MAX_BODY_BYTES")
+	env.integer(&cfg.Server.MaxImportBytes, "SERVER_MAX_IMPORT_BYTES")
+	env.duration(&cfg.Server.HandlerTimeouts.Read, "SERVER_READ_HANDLER_TIMEOUT")
+	env.duration(&cfg.Server.HandlerTimeouts.Write, "SERVER_WRITE_HANDLER_TIMEOUT")
+	env.duration(&cfg.Server.HandlerTimeouts.Bulk, "SERVER_BULK_HANDLER_TIMEOUT")
+	env.str(&cfg.Database.Host, "DB_HOST")
+	env.str(&cfg.Database.Port, "DB_PORT")
+	env.str(&cfg.Database.User, "DB_USER")
+	env.str(&cfg.Database.Password, "DB_PASSWORD")
+	env.str(&cfg.Database.DBName, "DB_NAME")
+	env.str(&cfg.Database.SSLMode, "DB_SSLMODE")
+	env.integer(&cfg.Database.ConnectMaxAttempts, "DB_CONNECT_MAX_ATTEMPTS")
+	env.duration(&cfg.Database.ConnectRetryInterval, "DB_CONNECT_RETRY_INTERVAL")
+	env.duration(&cfg.Database.StatementTimeout, "DB_STATEMENT_TIMEOUT")
+	env.duration(&cfg.Database.SlowQueryThreshold, "DB_SLOW_QUERY_THRESHOLD")
+	env.boolean(&cfg.Database.AutoMigrate, "DB_AUTO_MIGRATE")
+	env.boolean(&cfg.Database.SeedInitialData, "SEED_INITIAL_DATA")
+	env.str(&cfg.Database.SeedFile, "SEED_FILE")
+	env.boolean(&cfg.Database.SchemaVersionOverride, "SCHEMA_VERSION_OVERRIDE")
+	env.str(&cfg.Database.ReplicaDSN, "DB_REPLICA_DSN")
+	env.integer(&cfg.Database.BreakerThreshold, "DB_BREAKER_THRESHOLD")
+	env.duration(&cfg.Database.BreakerCooldown, "DB_BREAKER_COOLDOWN")
+	env.str(&cfg.TLS.CertFile, "TLS_CERT_FILE")
+	env.str(&cfg.TLS.KeyFile, "TLS_KEY_FILE")
+	env.str(&cfg.TLS.RedirectPort, "TLS_REDIRECT_PORT")
+	env.boolean(&cfg.Cache.Enabled, "HEROES_CACHE_ENABLED")
+	env.duration(&cfg.Cache.TTL, "HEROES_CACHE_TTL")
+	env.duration(&cfg.Cache.MaxAge, "HTTP_CACHE_MAX_AGE")
+	env.duration(&cfg.Clock.SkewWarnThreshold, "CLOCK_SKEW_WARN_THRESHOLD")
+	env.duration(&cfg.Clock.SkewCheckInterval, "CLOCK_SKEW_CHECK_INTERVAL")
+	env.boolean(&cfg.Clock.PreferDatabaseTime, "CLOCK_PREFER_DB_TIME")
+	env.boolean(&cfg.Destructive.Confirmation, "DESTRUCTIVE_CONFIRMATION")
+	env.duration(&cfg.Destructive.ConfirmationTTL, "DESTRUCTIVE_CONFIRMATION_TTL")
+	env.boolean(&cfg.RevokeRemovedUsers, "REVOKE_REMOVED_USERS")
+	env.str(&cfg.IDs.Strategy, "HERO_ID_STRATEGY")
+	env.int64(&cfg.IDs.NodeID, "HERO_ID_NODE")
+	env.integer(&cfg.Lockout.MaxAttempts, "LOCKOUT_MAX_ATTEMPTS")
+	env.duration(&cfg.Lockout.Duration, "LOCKOUT_DURATION")
+	env.adminUser(&cfg, "ADMIN_USER", "ADMIN_PASSWORD")
+	env.str(&cfg.Logging.Level, "LOG_LEVEL")
+	env.str(&cfg.Logging.Format, "LOG_FORMAT")
+	env.integer(&cfg.Pagination.MaxLimit, "PAGINATION_MAX_LIMIT")
+	env.boolean(&cfg.Pagination.ExactCount, "PAGINATION_EXACT_COUNT")
+	env.str(&cfg.Events.Audience, "EVENTS_AUDIENCE")
+	env.boolean(&cfg.Debug.Pprof, "DEBUG_PPROF")
+	env.boolean(&cfg.Debug.DevEndpoints, "ENABLE_DEV_ENDPOINTS")
+	problems = append(problems, env.problems...)
+
+	cfg.Server.BasePath = strings.TrimRight(cfg.Server.BasePath, "/")
+	cfg.Validation = cfg.Validation.withDefaults()
+	cfg.RateLimits = cfg.RateLimits.withDefaults()
+	cfg.DisplayOrder = cfg.DisplayOrder.withDefaults()
+
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return cfg, fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+
+	return cfg, nil
+}
+
+// validate checks the merged configuration and returns every problem found
+func (c AppConfig) validate() []string {
+	var problems []string
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("server port %q must be a number between 1 and 65535", c.Server.Port))
+	}
+
+	if c.Server.BasePath != "" && !basePathPattern.MatchString(c.Server.BasePath) {
+		problems = append(problems, fmt.Sprintf("BASE_PATH %q must look like /prefix or /nested/prefix", c.Server.BasePath))
+	}
+	if c.Server.MaxHeaderBytes < 0 {
+		problems = append(problems, "SERVER_MAX_HEADER_BYTES must not be negative")
+	}
+	if c.Server.MaxBodyBytes < 0 {
+		problems = append(problems, "SERVER_MAX_BODY_BYTES must not be negative")
+	}
+	if c.Server.HandlerTimeouts.Read < 0 {
+		problems = append(problems, "SERVER_READ_HANDLER_TIMEOUT must not be negative")
+	}
+	if c.Server.HandlerTimeouts.Write < 0 {
+		problems = append(problems, "SERVER_WRITE_HANDLER_TIMEOUT must not be negative")
+	}
+	if c.Server.HandlerTimeouts.Bulk < 0 {
+		problems = append(problems, "SERVER_BULK_HANDLER_TIMEOUT must not be negative")
+	}
+	if c.Server.MaxImportBytes < 0 {
+		problems = append(problems, "SERVER_MAX_IMPORT_BYTES must not be negative")
+	}
+
+	required := map[string]string{
+		"DB_HOST": c.Database.Host,
+		"DB_PORT": c.Database.Port,
+		"DB_USER": c.Database.User,
+		"DB_NAME": c.Database.DBName,
+	}
+	for _, key := range []string{"DB_HOST", "DB_PORT", "DB_USER", "DB_NAME"} {
+		if required[key] == "" {
+			problems = append(problems, key+" is required")
+		}
+	}
+	if c.Database.ConnectMaxAttempts < 1 {
+		problems = append(problems, "DB_CONNECT_MAX_ATTEMPTS must be at least 1")
+	}
+	if c.Database.BreakerThreshold < 1 {
+		problems = append(problems, "DB_BREAKER_THRESHOLD must be at least 1")
+	}
+	if c.Pagination.MaxLimit < 1 {
+		problems = append(problems, "PAGINATION_MAX_LIMIT must be at least 1")
+	}
+	if c.Database.SeedInitialData && c.Database.SeedFile != "" {
+		if _, err := os.Stat(c.Database.SeedFile); err != nil {
+			problems = append(problems, fmt.Sprintf("SEED_FILE: %v", err))
+		}
+	}
+
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"SERVER_READ_TIMEOUT", c.Server.ReadTimeout},
+		{"SERVER_READ_HEADER_TIMEOUT", c.Server.ReadHeaderTimeout},
+		{"SERVER_WRITE_TIMEOUT", c.Server.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", c.Server.IdleTimeout},
+		{"DB_CONNECT_RETRY_INTERVAL", c.Database.ConnectRetryInterval},
+		{"DB_STATEMENT_TIMEOUT", c.Database.StatementTimeout},
+		{"DB_SLOW_QUERY_THRESHOLD", c.Database.SlowQueryThreshold},
+		{"DB_BREAKER_COOLDOWN", c.Database.BreakerCooldown},
+		{"HEROES_CACHE_TTL", c.Cache.TTL},
+		{"HTTP_CACHE_MAX_AGE", c.Cache.MaxAge},
+		{"CLOCK_SKEW_WARN_THRESHOLD", c.Clock.SkewWarnThreshold},
+		{"DESTRUCTIVE_CONFIRMATION_TTL", c.Destructive.ConfirmationTTL},
+		{"LOCKOUT_DURATION", c.Lockout.Duration},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			problems = append(problems, d.name+" must not be negative")
+		}
+	}
+	if c.Clock.SkewCheckInterval <= 0 {
+		problems = append(problems, "CLOCK_SKEW_CHECK_INTERVAL must be positive")
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		problems = append(problems, "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if c.TLS.RedirectPort != "" && !c.TLS.Enabled() {
+		problems = append(problems, "TLS_REDIRECT_PORT requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	// Load the pair now so a bad path fails at startup rather than on first handshake
+	if c.TLS.Enabled() {
+		if _, err := tls.LoadX509KeyPair(c.TLS.CertFile, c.TLS.KeyFile); err != nil {
+			problems = append(problems, fmt.Sprintf("failed to load TLS certificate %s / key %s: %v", c.TLS.CertFile, c.TLS.KeyFile, err))
+		}
+	}
+
+	if _, err := newIDStrategy(c.IDs); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	seenUsers := make(map[string]bool, len(c.Users))
+	for i, user := range c.Users {
+		switch {
+		case user.Username == "":
+			problems = append(problems, fmt.Sprintf("users[%d] has an empty username", i))
+		case seenUsers[user.Username]:
+			problems = append(problems, fmt.Sprintf("users[%d]: username %q is listed more than once", i, user.Username))
+		}
+		seenUsers[user.Username] = true
+
+		if user.Password == "" {
+			problems = append(problems, fmt.Sprintf("users[%d] (%s) has an empty password", i, user.Username))
+		}
+		if user.Role != "" && user.Role != roleUser && user.Role != roleAdmin {
+			problems = append(problems, fmt.Sprintf("users[%d] (%s) has role %q, must be %s or %s", i, user.Username, user.Role, roleUser, roleAdmin))
+		}
+	}
+
+	if _, err := parseAudience(c.Events.Audience); err != nil {
+		problems = append(problems, "EVENTS_AUDIENCE: "+err.Error())
+	}
+
+	if _, err := newLogHandler(c.Logging, io.Discard); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	for _, entry := range c.RateLimits.TrustedProxies {
+		if _, err := parseTrustedProxy(entry); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	return problems
+}
+
+// Redacted returns a copy of the configuration with secrets masked
+func (c AppConfig) Redacted() AppConfig {
+	if c.Database.Password != "" {
+		c.Database.Password = redacted
+	}
+	// The DSN may embed a password
+	if c.Database.ReplicaDSN != "" {
+		c.Database.ReplicaDSN = redacted
+	}
+
+	users := make([]User, len(c.Users))
+	for i, user := range c.Users {
+		if user.Password != "" {
+			user.Password = redacted
+		}
+		users[i] = user
+	}
+	c.Users = users
+	if c.AdminUser != nil {
+		admin := *c.AdminUser
+		admin.Password = redacted
+		c.AdminUser = &admin
+	}
+
+	return c
+}
+
+// envOverrides applies environment variables onto config fields, collecting parse errors
+type envOverrides struct {
+	problems []string
+}
+
+// str overrides target with the variable key when it is set
+func (e *envOverrides) str(target *string, key string) {
+	if value := os.Getenv(key); value != "" {
+		*target = value
+	}
+}
+
+// integer overrides target with the integer variable key when it is set
+func (e *envOverrides) integer(target *int, key string) {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			e.problems = append(e.problems, fmt.Sprintf("%s=%q is not an integer", key, value))
+			return
+		}
+		*target = parsed
+	}
+}
+
+// adminUser adds an admin from the variables userKey and passwordKey when they
+// are set, replacing a user of the same name from config.yaml
+func (e *envOverrides) adminUser(cfg *AppConfig, userKey, passwordKey string) {
+	username, password := os.Getenv(userKey), os.Getenv(passwordKey)
+	if username == "" && password == "" {
+		return
+	}
+	if username == "" || password == "" {
+		e.problems = append(e.problems, fmt.Sprintf("%s and %s must be set together", userKey, passwordKey))
+		return
+	}
+
+	admin := User{Username: username, Password: password, Role: roleAdmin}
+	cfg.AdminUser = &admin
+	for i, user := range cfg.Users {
+		if user.Username == username {
+			cfg.Users[i] = admin
+			return
+		}
+	}
+	cfg.Users = append(cfg.Users, admin)
+}
+
+// int64 overrides target with the integer variable key when it is set
+func (e *envOverrides) int64(target *int64, key string) {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			e.problems = append(e.problems, fmt.Sprintf("%s=%q is not an integer", key, value))
+			return
+		}
+		*target = parsed
+	}
+}
+
+// duration overrides target with the duration variable key (e.g. "2s") when it is set
+func (e *envOverrides) duration(target *time.Duration, key string) {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			e.problems = append(e.problems, fmt.Sprintf("%s=%q is not a duration", key, value))
+			return
+		}
+		*target = parsed
+	}
+}
+
+// boolean overrides target with the boolean variable key when it is set
+func (e *envOverrides) boolean(target *bool, key string) {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			e.problems = append(e.problems, fmt.Sprintf("%s=%q is not a boolean", key, value))
+			return
+		}
+		*target = parsed
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigWithoutConfigFile(t *testing.T) {
+	captureLogs(t)
+	t.Setenv("ADMIN_USER", "")
+
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig() without config.yaml: %v", err)
+	}
+	if len(cfg.Users) != 0 {
+		t.Errorf("Users = %v, want none", cfg.Users)
+	}
+}
+
+func TestLoadConfigRejectsInvalidUsers(t *testing.T) {
+	captureLogs(t)
+	t.Setenv("ADMIN_USER", "")
+
+	tests := []struct {
+		name  string
+		users string
+		want  string
+	}{
+		{"empty username", "- password: secret", "users[0] has an empty username"},
+		{"duplicate username", "- {username: alice, password: a}\n- {username: alice, password: b}", `users[1]: username "alice" is listed more than once`},
+		{"empty password", "- username: alice", "users[0] (alice) has an empty password"},
+		{"unknown role", "- {username: alice, password: a, role: root}", `users[0] (alice) has role "root"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte("users:\n"+tt.users+"\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadConfig() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigAdminUser(t *testing.T) {
+	captureLogs(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("users:\n- {username: alice, password: old}\n- {username: bob, password: b}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ADMIN_USER", "alice")
+	t.Setenv("ADMIN_PASSWORD", "new-secret")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig(): %v", err)
+	}
+	want := User{Username: "alice", Password: "new-secret", Role: roleAdmin}
+	if cfg.AdminUser == nil || *cfg.AdminUser != want {
+		t.Errorf("AdminUser = %v, want %v", cfg.AdminUser, want)
+	}
+	if len(cfg.Users) != 2 || cfg.Users[0] != want {
+		t.Errorf("Users = %v, want alice replaced by the admin", cfg.Users)
+	}
+	if masked := cfg.Redacted(); masked.AdminUser.Password != redacted || cfg.AdminUser.Password != "new-secret" {
+		t.Errorf("Redacted() AdminUser = %v, original %v", masked.AdminUser, cfg.AdminUser)
+	}
+}
+
+func TestLoadConfigEventsAudience(t *testing.T) {
+	captureLogs(t)
+	t.Setenv("ADMIN_USER", "")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Events.Audience != "admin" {
+		t.Errorf("default Events.Audience = %q, want admin so subscribers keep their own audience", cfg.Events.Audience)
+	}
+
+	t.Setenv("EVENTS_AUDIENCE", "public")
+	if cfg, err = LoadConfig(path); err != nil || cfg.Events.Audience != "public" {
+		t.Errorf("EVENTS_AUDIENCE=public: audience %q, error %v", cfg.Events.Audience, err)
+	}
+
+	t.Setenv("EVENTS_AUDIENCE", "partners")
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), `EVENTS_AUDIENCE: unknown audience "partners"`) {
+		t.Errorf("EVENTS_AUDIENCE=partners: error = %v, want it rejected", err)
+	}
+}
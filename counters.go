@@ -0,0 +1,199 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Queries on hero_counters; counter lists are filtered by visibility per request
+var (
+	queryAddCounter    = registerQuery("hero_counters.add", "INSERT INTO hero_counters (hero_id, countered_by_id) VALUES ($1, $2)", paramHeroID, paramHeroID)
+	queryRemoveCounter = registerQuery("hero_counters.remove", "DELETE FROM hero_counters WHERE hero_id = $1 AND countered_by_id = $2", paramHeroID, paramHeroID)
+	queryListCounters  = registerBuiltQuery("hero_counters.list")
+)
+
+// visibleHero fetches the hero with id if the caller may see it
+func visibleHero(r *http.Request, id HeroID) (Hero, error) {
+	filter := &heroFilter{}
+	filter.add("id = $%d", id)
+	filter.restrictVisibility(r)
+
+	var hero Hero
+	err := queryGetHero.WithContext(r.Context()).Build("SELECT " + heroColumns + " FROM heroes" + filter.where()).QueryRow(filter.args...).
+		Scan(heroScanDest(&hero)...)
+	return hero, err
+}
+
+// GET /api/heroes/{id}/counters - Heroes that counter a hero
+// @Summary List hero counters
+// @Description The heroes that are strong against this hero, by name. Counters the caller can't see are left out.
+// @Tags heroes
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Success 200 {array} Hero
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/heroes/{id}/counters [get]
+func getHeroCounters(w http.ResponseWriter, r *http.Request) {
+	id, err := heroIDs.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
+		return
+	}
+
+	hero, err := visibleHero(r, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, r, http.StatusNotFound, ErrCodeHeroNotFound, "Hero not found")
+		} else {
+			respondWithInternalError(w, r, err, "Failed to fetch hero")
+		}
+		return
+	}
+
+	filter := &heroFilter{}
+	filter.add("c.hero_id = $%d", hero.ID)
+	filter.restrictVisibility(r)
+
+	query := "SELECT " + heroColumns + " FROM heroes JOIN hero_counters c ON c.countered_by_id = heroes.id::text" +
+		filter.where() + " ORDER BY heroes.name"
+
+	rows, err := queryListCounters.WithContext(r.Context()).Build(query).Query(filter.args...)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to fetch counters")
+		return
+	}
+	defer rows.Close()
+
+	counters := []Hero{}
+	for rows.Next() {
+		var counter Hero
+		if err := rows.Scan(heroScanDest(&counter)...); err != nil {
+			respondWithInternalError(w, r, err, "Failed to scan hero data")
+			return
+		}
+		counters = append(counters, counter)
+	}
+
+	if err := rows.Err(); err != nil {
+		respondWithInternalError(w, r, err, "Error iterating heroes")
+		return
+	}
+
+	respondWith(w, r, http.StatusOK, counters)
+}
+
+// POST /api/heroes/{id}/counters - Record a counter
+// @Summary Add hero counter
+// @Description Record that another hero counters this one. A hero can't counter itself, and each pair is stored once.
+// @Tags heroes
+// @Accept json
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Param counter body HeroCounterRequest true "The countering hero"
+// @Success 201 {object} Hero
+// @Header 201 {string} Location "URL of the hero's counters"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/{id}/counters [post]
+func addHeroCounter(w http.ResponseWriter, r *http.Request) {
+	id, err := heroIDs.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
+		return
+	}
+
+	var req HeroCounterRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	counterID, err := heroIDs.Parse(req.CounteredByID.String())
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, "countered_by_id must be a valid hero ID")
+		return
+	}
+
+	hero, err := visibleHero(r, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, r, http.StatusNotFound, ErrCodeHeroNotFound, "Hero not found")
+		} else {
+			respondWithInternalError(w, r, err, "Failed to fetch hero")
+		}
+		return
+	}
+	if counterID == hero.ID {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, "A hero can't counter itself")
+		return
+	}
+
+	counter, err := visibleHero(r, counterID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, "countered_by_id does not match a hero")
+		} else {
+			respondWithInternalError(w, r, err, "Failed to fetch hero")
+		}
+		return
+	}
+
+	if _, err := queryAddCounter.WithContext(r.Context()).Exec(hero.ID, counter.ID); err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, r, http.StatusConflict, ErrCodeConflict, "This counter is already recorded")
+		} else {
+			respondWithInternalError(w, r, err, "Failed to add counter")
+		}
+		return
+	}
+
+	session, _ := sessionFromRequest(r)
+	requestLogger(r).Info("AUDIT hero counter added", "name", hero.Name, "countered_by", counter.Name, "by", session.Username)
+
+	w.Header().Set("Location", apiURL(r, "/heroes/"+hero.ID.String()+"/counters"))
+	respondWith(w, r, http.StatusCreated, counter)
+}
+
+// DELETE /api/heroes/{id}/counters/{counterId} - Remove a counter
+// @Summary Remove hero counter
+// @Description Remove a recorded counter of a hero
+// @Tags heroes
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Param counterId path string true "ID of the countering hero"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/{id}/counters/{counterId} [delete]
+func removeHeroCounter(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := heroIDs.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
+		return
+	}
+	counterID, err := heroIDs.Parse(vars["counterId"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
+		return
+	}
+
+	result, err := queryRemoveCounter.WithContext(r.Context()).Exec(id, counterID)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to remove counter")
+		return
+	}
+	if removed, _ := result.RowsAffected(); removed == 0 {
+		respondWithError(w, r, http.StatusNotFound, ErrCodeNotFound, "Counter not found")
+		return
+	}
+
+	session, _ := sessionFromRequest(r)
+	requestLogger(r).Info("AUDIT hero counter removed", "hero_id", id, "countered_by_id", counterID, "by", session.Username)
+
+	w.WriteHeader(http.StatusNoContent)
+}
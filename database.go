@@ -1,139 +1,385 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"gopkg.in/yaml.v3"
 )
 
 // Database connection pool
 var DB *sql.DB
 
-// DatabaseConfig holds database configuration
-type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
-}
+// ReadDB is the pool used by read-heavy handlers. It is a separate pool on the
+// replica when DB_REPLICA_DSN is set, and DB otherwise.
+var ReadDB *sql.DB
+
+// trigramEnabled reports whether the pg_trgm extension is available for hero search
+var trigramEnabled bool
 
 // InitDB initializes database connection with connection pooling
-func InitDB() error {
-	config := DatabaseConfig{
-		Host:     getEnv("DB_HOST", "localhost"),
-		Port:     getEnv("DB_PORT", "5432"),
-		User:     getEnv("DB_USER", "postgres"),
-		Password: getEnv("DB_PASSWORD", "password"),
-		DBName:   getEnv("DB_NAME", "heroes_db"),
-		SSLMode:  getEnv("DB_SSLMODE", "disable"),
-	}
+func InitDB(cfg DatabaseConfig) error {
+	dsn := dataSourceName(cfg)
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode)
+	// Let Postgres cancel runaway statements server-side as well
+	if cfg.StatementTimeout > 0 {
+		dsn += fmt.Sprintf(" options='-c statement_timeout=%d'", cfg.StatementTimeout.Milliseconds())
+	}
 
 	var err error
-	DB, err = sql.Open("postgres", dsn)
-	if err != nil {
+	if DB, err = openPool(dsn); err != nil {
 		return fmt.Errorf("failed to open database: %v", err)
 	}
 
-	// Configure connection pool
-	DB.SetMaxOpenConns(25)
-	DB.SetMaxIdleConns(5)
-	DB.SetConnMaxLifetime(5 * time.Minute)
-
-	// Test connection
-	if err = DB.Ping(); err != nil {
+	// Wait for the database to accept connections
+	if err = waitForDB(DB, cfg.ConnectMaxAttempts, cfg.ConnectRetryInterval); err != nil {
 		return fmt.Errorf("failed to ping database: %v", err)
 	}
+	slog.Info("Database connected successfully")
+
+	ReadDB = DB
+	if cfg.ReplicaDSN == "" {
+		return nil
+	}
 
-	log.Println("Database connected successfully")
+	replicaDSN := cfg.ReplicaDSN
+	if strings.HasPrefix(replicaDSN, "postgres://") || strings.HasPrefix(replicaDSN, "postgresql://") {
+		if replicaDSN, err = pq.ParseURL(replicaDSN); err != nil {
+			return fmt.Errorf("invalid replica DSN: %v", err)
+		}
+	}
+	if cfg.StatementTimeout > 0 {
+		replicaDSN += fmt.Sprintf(" options='-c statement_timeout=%d'", cfg.StatementTimeout.Milliseconds())
+	}
+
+	replica, err := openPool(replicaDSN)
+	if err != nil {
+		return fmt.Errorf("failed to open replica: %v", err)
+	}
+	if err = waitForDB(replica, cfg.ConnectMaxAttempts, cfg.ConnectRetryInterval); err != nil {
+		replica.Close()
+		return fmt.Errorf("failed to ping replica: %v", err)
+	}
+	ReadDB = replica
+	slog.Info("Read replica connected successfully")
 	return nil
 }
 
-// CreateTables creates the heroes table if it doesn't exist
-func CreateTables() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS heroes (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(255) NOT NULL,
-		role VARCHAR(100) NOT NULL,
-		difficulty VARCHAR(100) NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-	
-	-- Create trigger to update updated_at column
-	CREATE OR REPLACE FUNCTION update_updated_at_column()
-	RETURNS TRIGGER AS $$
-	BEGIN
-		NEW.updated_at = CURRENT_TIMESTAMP;
-		RETURN NEW;
-	END;
-	$$ language 'plpgsql';
-
-	DROP TRIGGER IF EXISTS update_heroes_updated_at ON heroes;
-	CREATE TRIGGER update_heroes_updated_at
-		BEFORE UPDATE ON heroes
-		FOR EACH ROW
-		EXECUTE FUNCTION update_updated_at_column();
-	`
-
-	_, err := DB.Exec(query)
+// openPool opens a connection pool on dsn with the shared pool settings
+func openPool(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
 	if err != nil {
-		return fmt.Errorf("failed to create tables: %v", err)
+		return nil, err
 	}
+	db.SetMaxOpenConns(dbMaxOpenConns)
+	db.SetMaxIdleConns(dbMaxIdleConns)
+	db.SetConnMaxLifetime(dbConnMaxLifetime)
+	return db, nil
+}
 
-	log.Println("Tables created successfully")
+// beginTx starts a transaction on the primary, failing fast while the circuit is open
+func beginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if err := dbPool.allow(); err != nil {
+		return nil, err
+	}
+	tx, err := DB.BeginTx(ctx, opts)
+	dbPool.record(err)
+	return tx, err
+}
+
+// CloseDB closes the primary pool and the replica pool, if one is open
+func CloseDB() {
+	if ReadDB != nil && ReadDB != DB {
+		ReadDB.Close()
+	}
+	DB.Close()
+}
+
+// dataSourceName builds the lib/pq connection string for cfg
+func dataSourceName(cfg DatabaseConfig) string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode)
+}
+
+// waitForDB pings db until it responds, doubling the wait between attempts
+func waitForDB(db *sql.DB, maxAttempts int, interval time.Duration) error {
+	const maxInterval = 30 * time.Second
+
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err = db.Ping(); err == nil {
+			return nil
+		}
+
+		if attempt == maxAttempts {
+			break
+		}
+
+		slog.Warn("Database not ready", "attempt", attempt, "max_attempts", maxAttempts, "error", err, "retry_in", interval)
+		time.Sleep(interval)
+
+		interval *= 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+
+	return fmt.Errorf("database not reachable after %d attempts: %v", maxAttempts, err)
+}
+
+// Queries used while preparing the schema and seed data
+var (
+	queryTrigramInstalled = registerQuery("schema.trigram_installed", "SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm')")
+	querySeedCount        = registerQuery("heroes.seed_count", "SELECT COUNT(*) FROM heroes")
+	querySeedHero         = registerBuiltQuery("heroes.seed")
+)
+
+// CreateTables applies pending schema migrations and detects optional extensions
+func CreateTables(cfg DatabaseConfig) error {
+	if cfg.AutoMigrate {
+		if err := runMigrations(); err != nil {
+			return fmt.Errorf("failed to create tables: %v", err)
+		}
+	}
+
+	if err := detectTrigramSearch(); err != nil {
+		slog.Warn("Could not check for pg_trgm, hero search falls back to ILIKE", "error", err)
+	} else if !trigramEnabled {
+		slog.Warn("pg_trgm not available, hero search falls back to ILIKE")
+	}
+
+	slog.Info("Tables created successfully")
 	return nil
 }
 
-// InsertInitialData inserts initial heroes data
-func InsertInitialData() error {
+// detectTrigramSearch checks whether migration 0020 could install pg_trgm
+func detectTrigramSearch() error {
+	return queryTrigramInstalled.QueryRow().Scan(&trigramEnabled)
+}
+
+// seedHero is one hero of the starter roster
+type seedHero struct {
+	Name       string `json:"name" yaml:"name"`
+	Role       string `json:"role" yaml:"role"`
+	Difficulty string `json:"difficulty" yaml:"difficulty"`
+}
+
+// Starter roster used when no seed file is configured
+var defaultSeedHeroes = []seedHero{
+	{"Alucard", "Fighter", "Mudah"},
+	{"Miya", "Marksman", "Mudah"},
+	{"Fanny", "Assassin", "Sulit"},
+}
+
+// loadSeedHeroes reads a starter roster from a JSON (.json) or YAML file
+func loadSeedHeroes(path string) ([]seedHero, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var heroes []seedHero
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &heroes)
+	} else {
+		err = yaml.Unmarshal(data, &heroes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+
+	for i, hero := range heroes {
+		if hero.Name == "" || hero.Role == "" || hero.Difficulty == "" {
+			return nil, fmt.Errorf("%s: hero %d needs name, role, and difficulty", path, i+1)
+		}
+	}
+	return heroes, nil
+}
+
+// InsertInitialData inserts the starter roster when seeding is enabled and the
+// heroes table is empty. Inserts skip names that already exist, so instances
+// starting concurrently don't fail or duplicate heroes.
+func InsertInitialData(cfg DatabaseConfig) error {
+	if !cfg.SeedInitialData {
+		slog.Info("Seeding initial data is disabled")
+		return nil
+	}
+
 	// Check if data already exists
 	var count int
-	err := DB.QueryRow("SELECT COUNT(*) FROM heroes").Scan(&count)
+	err := querySeedCount.QueryRow().Scan(&count)
 	if err != nil {
 		return fmt.Errorf("failed to check existing data: %v", err)
 	}
 
 	if count > 0 {
-		log.Println("Initial data already exists, skipping insertion")
+		slog.Info("Initial data already exists, skipping insertion")
 		return nil
 	}
 
-	heroes := []struct {
-		name       string
-		role       string
-		difficulty string
-	}{
-		{"Alucard", "Fighter", "Mudah"},
-		{"Miya", "Marksman", "Mudah"},
-		{"Fanny", "Assassin", "Sulit"},
+	heroes, err := seedRoster(cfg)
+	if err != nil {
+		return err
 	}
 
-	query := "INSERT INTO heroes (name, role, difficulty) VALUES ($1, $2, $3)"
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start seeding: %v", err)
+	}
+	defer tx.Rollback()
+
+	inserted, err := insertSeedHeroes(tx, heroes)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit initial data: %v", err)
+	}
+
+	slog.Info("Inserted initial heroes", "inserted", inserted, "total", len(heroes))
+	return nil
+}
+
+// seedRoster returns the heroes of SEED_FILE, or the default roster
+func seedRoster(cfg DatabaseConfig) ([]seedHero, error) {
+	if cfg.SeedFile == "" {
+		return defaultSeedHeroes, nil
+	}
+	heroes, err := loadSeedHeroes(cfg.SeedFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load seed file: %v", err)
+	}
+	return heroes, nil
+}
+
+// insertSeedHeroes inserts heroes inside tx, skipping names that already
+// exist, and returns how many were inserted
+func insertSeedHeroes(tx *sql.Tx, heroes []seedHero) (int64, error) {
+	var inserted int64
 	for _, hero := range heroes {
-		_, err := DB.Exec(query, hero.name, hero.role, hero.difficulty)
+		difficulty, err := resolveDifficulty(DifficultyField(hero.Difficulty), nil)
 		if err != nil {
-			return fmt.Errorf("failed to insert hero %s: %v", hero.name, err)
+			return 0, fmt.Errorf("invalid difficulty for hero %s: %v", hero.Name, err)
+		}
+
+		// Another instance starting at the same time may have inserted it already
+		query, args := heroInsert(hero.Name, hero.Role, difficulty, HeroDetails{})
+		result, err := querySeedHero.Build(query + " ON CONFLICT (name) DO NOTHING").In(tx).Exec(args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert hero %s: %v", hero.Name, err)
+		}
+		if rows, err := result.RowsAffected(); err == nil {
+			inserted += rows
 		}
 	}
+	return inserted, nil
+}
 
-	log.Println("Initial data inserted successfully")
-	return nil
+// Columns selected for a Hero, in heroScanDest order; tags come from a
+// subquery so every statement that reads heroes returns them
+const heroColumns = "id, name, role, difficulty, difficulty_score, created_at, updated_at, archived_at, deleted_at, lore, specialty, lane, release_date, " +
+	"ARRAY(SELECT tg.name FROM hero_tags ht JOIN tags tg ON tg.id = ht.tag_id WHERE ht.hero_id = heroes.id::text ORDER BY tg.name)"
+
+// heroScanDest returns the Scan destinations for heroColumns, followed by extra
+func heroScanDest(hero *Hero, extra ...interface{}) []interface{} {
+	return append([]interface{}{
+		&hero.ID, &hero.Name, &hero.Role, &hero.Difficulty, &hero.DifficultyScore,
+		utcTime{&hero.CreatedAt}, utcTime{&hero.UpdatedAt}, nullUTCTime{&hero.ArchivedAt}, nullUTCTime{&hero.DeletedAt},
+		&hero.Lore, &hero.Specialty, &hero.Lane, &hero.ReleaseDate, pq.Array(&hero.Tags),
+	}, extra...)
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// Unique constraint on heroes.name, added by migration 0003
+const heroNameConstraint = "heroes_name_key"
+
+// constraintViolation maps database errors caused by the request's data to a
+// client error status, code and message. Integrity violations (class 23) are
+// conflicts or invalid values; data exceptions (class 22) are values the
+// column can't hold.
+func constraintViolation(err error) (status int, code, message string, ok bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return 0, "", "", false
+	}
+
+	switch pqErr.Code {
+	case "23505":
+		return http.StatusConflict, ErrCodeConflict, "A resource with these values already exists", true
+	case "23503":
+		return http.StatusConflict, ErrCodeConflict, "A referenced resource does not exist or is still referenced", true
+	case "23502":
+		if pqErr.Column != "" {
+			return http.StatusUnprocessableEntity, ErrCodeValidationFailed, pqErr.Column + " is required", true
+		}
+		return http.StatusUnprocessableEntity, ErrCodeValidationFailed, "A required value is missing", true
+	case "23514":
+		return http.StatusUnprocessableEntity, ErrCodeValidationFailed, "A value is outside its allowed range", true
+	case "22001":
+		return http.StatusBadRequest, ErrCodeValueTooLong, "A value is longer than its column allows", true
+	case "22003":
+		return http.StatusBadRequest, ErrCodeValidationFailed, "A number is out of range", true
+	case "22007", "22008":
+		return http.StatusBadRequest, ErrCodeValidationFailed, "A date or time value is invalid", true
+	case "22P02":
+		return http.StatusBadRequest, ErrCodeValidationFailed, "A value has an invalid format", true
+	}
+	if pqErr.Code.Class() == "22" {
+		return http.StatusBadRequest, ErrCodeValidationFailed, "A value is not valid for its column", true
+	}
+	return 0, "", "", false
+}
+
+// respondWithHeroWriteError answers a failed hero insert, update or delete:
+// 404 when the statement matched no hero, 409 HERO_NAME_TAKEN when the name
+// belongs to another hero, and constraintViolation's mapping or a 500 otherwise
+func respondWithHeroWriteError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	var pqErr *pq.Error
+	switch {
+	case err == sql.ErrNoRows:
+		respondWithError(w, r, http.StatusNotFound, ErrCodeHeroNotFound, "Hero not found")
+	case errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == heroNameConstraint:
+		respondWithError(w, r, http.StatusConflict, ErrCodeHeroNameTaken, "A hero with this name already exists")
+	default:
+		respondWithInternalError(w, r, err, message)
+	}
+}
+
+// heroFilter builds a WHERE clause shared by a list query and its total count
+type heroFilter struct {
+	conditions []string
+	args       []interface{}
+}
+
+// add appends a condition whose single placeholder is written as %d
+func (f *heroFilter) add(condition string, value interface{}) {
+	f.args = append(f.args, value)
+	f.conditions = append(f.conditions, fmt.Sprintf(condition, len(f.args)))
 }
 
-// getEnv gets environment variable with default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// where renders the accumulated conditions, or an empty string when there are none
+func (f *heroFilter) where() string {
+	if len(f.conditions) == 0 {
+		return ""
 	}
-	return defaultValue
+	return " WHERE " + strings.Join(f.conditions, " AND ")
 }
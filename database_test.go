@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// Database errors shared by the error mapping tests
+var (
+	errHeroNameTaken = &pq.Error{Code: "23505", Constraint: heroNameConstraint, Message: `duplicate key value violates unique constraint "heroes_name_key"`}
+	errOtherUnique   = &pq.Error{Code: "23505", Constraint: "favorites_pkey"}
+	errForeignKey    = &pq.Error{Code: "23503", Constraint: "hero_tiers_hero_id_fkey"}
+	errNotNullColumn = &pq.Error{Code: "23502", Column: "role"}
+	errNotNull       = &pq.Error{Code: "23502"}
+	errCheck         = &pq.Error{Code: "23514", Constraint: "heroes_difficulty_score_check"}
+	errTooLong       = &pq.Error{Code: "22001"}
+	errOutOfRange    = &pq.Error{Code: "22003"}
+	errBadDate       = &pq.Error{Code: "22007"}
+	errBadText       = &pq.Error{Code: "22P02"}
+	errDataException = &pq.Error{Code: "22012"}
+	errConnection    = &pq.Error{Code: "08006"}
+	errSyntax        = &pq.Error{Code: "42601", Message: `syntax error at or near "FORM"`}
+)
+
+func TestConstraintViolation(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{"unique", errOtherUnique, http.StatusConflict, ErrCodeConflict, "A resource with these values already exists"},
+		{"foreign key", errForeignKey, http.StatusConflict, ErrCodeConflict, "A referenced resource does not exist or is still referenced"},
+		{"not null with column", errNotNullColumn, http.StatusUnprocessableEntity, ErrCodeValidationFailed, "role is required"},
+		{"not null without column", errNotNull, http.StatusUnprocessableEntity, ErrCodeValidationFailed, "A required value is missing"},
+		{"check", errCheck, http.StatusUnprocessableEntity, ErrCodeValidationFailed, "A value is outside its allowed range"},
+		{"too long", errTooLong, http.StatusBadRequest, ErrCodeValueTooLong, "A value is longer than its column allows"},
+		{"numeric out of range", errOutOfRange, http.StatusBadRequest, ErrCodeValidationFailed, "A number is out of range"},
+		{"invalid datetime", errBadDate, http.StatusBadRequest, ErrCodeValidationFailed, "A date or time value is invalid"},
+		{"invalid text representation", errBadText, http.StatusBadRequest, ErrCodeValidationFailed, "A value has an invalid format"},
+		{"other data exception", errDataException, http.StatusBadRequest, ErrCodeValidationFailed, "A value is not valid for its column"},
+		{"wrapped", fmt.Errorf("inserting hero: %w", errCheck), http.StatusUnprocessableEntity, ErrCodeValidationFailed, "A value is outside its allowed range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code, message, ok := constraintViolation(tt.err)
+			if !ok {
+				t.Fatalf("constraintViolation(%v) not mapped", tt.err)
+			}
+			if status != tt.wantStatus || code != tt.wantCode || message != tt.wantMessage {
+				t.Errorf("got %d %s %q, want %d %s %q", status, code, message, tt.wantStatus, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+
+	for _, err := range []error{sql.ErrNoRows, errors.New("boom"), errConnection, errSyntax} {
+		if status, _, _, ok := constraintViolation(err); ok {
+			t.Errorf("constraintViolation(%v) = %d, want it left to the caller", err, status)
+		}
+	}
+}
+
+func TestRespondWithHeroWriteError(t *testing.T) {
+	useTestConfig(t)
+	captureLogs(t)
+
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{"no rows", sql.ErrNoRows, http.StatusNotFound, ErrCodeHeroNotFound, "Hero not found"},
+		{"hero name taken", errHeroNameTaken, http.StatusConflict, ErrCodeHeroNameTaken, "A hero with this name already exists"},
+		{"wrapped hero name taken", fmt.Errorf("upsert: %w", errHeroNameTaken), http.StatusConflict, ErrCodeHeroNameTaken, "A hero with this name already exists"},
+		{"other unique", errOtherUnique, http.StatusConflict, ErrCodeConflict, "A resource with these values already exists"},
+		{"foreign key", errForeignKey, http.StatusConflict, ErrCodeConflict, "A referenced resource does not exist or is still referenced"},
+		{"not null with column", errNotNullColumn, http.StatusUnprocessableEntity, ErrCodeValidationFailed, "role is required"},
+		{"not null without column", errNotNull, http.StatusUnprocessableEntity, ErrCodeValidationFailed, "A required value is missing"},
+		{"check", errCheck, http.StatusUnprocessableEntity, ErrCodeValidationFailed, "A value is outside its allowed range"},
+		{"too long", errTooLong, http.StatusBadRequest, ErrCodeValueTooLong, "A value is longer than its column allows"},
+		{"invalid text representation", errBadText, http.StatusBadRequest, ErrCodeValidationFailed, "A value has an invalid format"},
+		{"other data exception", errDataException, http.StatusBadRequest, ErrCodeValidationFailed, "A value is not valid for its column"},
+		{"connection", errConnection, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "Database temporarily unavailable"},
+		{"internal", errSyntax, http.StatusInternalServerError, ErrCodeInternal, "Failed to update hero"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			respondWithHeroWriteError(rec, httptest.NewRequest(http.MethodPut, "/api/heroes/1", nil), tt.err, "Failed to update hero")
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			body := decodeError(t, rec)
+			if body.Code != tt.wantCode || body.Error != tt.wantMessage {
+				t.Errorf("error = %s %q, want %s %q", body.Code, body.Error, tt.wantCode, tt.wantMessage)
+			}
+			if strings.Contains(rec.Body.String(), "heroes_") || strings.Contains(rec.Body.String(), "FORM") {
+				t.Errorf("body %s leaks database details", rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusServiceUnavailable && rec.Header().Get("Retry-After") == "" {
+				t.Error("503 without Retry-After")
+			}
+		})
+	}
+}
+
+// failingHeroHandler answers every request with respondWithInternalError(err)
+func failingHeroHandler(err error) http.Handler {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/heroes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		respondWithInternalError(w, r, err, "Failed to fetch hero")
+	})
+	return requestLogMiddleware(router)
+}
+
+func TestRespondWithInternalErrorLogsRequestContext(t *testing.T) {
+	useTestConfig(t)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantLevel  string
+	}{
+		{"internal", errSyntax, http.StatusInternalServerError, "ERROR"},
+		{"constraint", errCheck, http.StatusUnprocessableEntity, "WARN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+
+			r := httptest.NewRequest(http.MethodGet, "/api/heroes/7", nil)
+			r.Header.Set(requestIDHeader, "req-"+tt.name)
+			rec := httptest.NewRecorder()
+			failingHeroHandler(tt.err).ServeHTTP(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if strings.Contains(rec.Body.String(), tt.err.Error()) {
+				t.Errorf("body %s contains the database error", rec.Body.String())
+			}
+
+			var found bool
+			for _, record := range logs.records(t) {
+				if record["msg"] != "Failed to fetch hero" {
+					continue
+				}
+				found = true
+				for key, want := range map[string]interface{}{
+					"level":      tt.wantLevel,
+					"request_id": "req-" + tt.name,
+					"hero_id":    "7",
+					"method":     http.MethodGet,
+					"path":       "/api/heroes/7",
+					"error":      tt.err.Error(),
+				} {
+					if record[key] != want {
+						t.Errorf("log %s = %v, want %v", key, record[key], want)
+					}
+				}
+			}
+			if !found {
+				t.Fatalf("no log entry for the failure in %v", logs.records(t))
+			}
+		})
+	}
+}
+
+func TestRespondWithInternalErrorTimeout(t *testing.T) {
+	useTestConfig(t)
+	captureLogs(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/heroes", nil).WithContext(ctx)
+	respondWithInternalError(rec, r, context.DeadlineExceeded, "Failed to fetch heroes")
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504", rec.Code)
+	}
+	if body := decodeError(t, rec); body.Code != ErrCodeRequestTimeout {
+		t.Errorf("code = %s, want %s", body.Code, ErrCodeRequestTimeout)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Date is a calendar date without time of day, written as YYYY-MM-DD
+type Date struct {
+	time.Time
+}
+
+// String returns the date as YYYY-MM-DD
+func (d Date) String() string {
+	return d.Format(dateLayout)
+}
+
+// MarshalText writes YYYY-MM-DD, used for XML
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// MarshalJSON writes the date as a YYYY-MM-DD string
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts a YYYY-MM-DD string
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("date must be a YYYY-MM-DD string")
+	}
+	parsed, err := time.Parse(dateLayout, text)
+	if err != nil {
+		return fmt.Errorf("date must be a YYYY-MM-DD string")
+	}
+	d.Time = parsed
+	return nil
+}
+
+// Scan reads a DATE column
+func (d *Date) Scan(src interface{}) error {
+	value, ok := src.(time.Time)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into Date", src)
+	}
+	d.Time = time.Date(value.Year(), value.Month(), value.Day(), 0, 0, 0, 0, time.UTC)
+	return nil
+}
+
+// Value passes the date as YYYY-MM-DD
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// utcTime scans a TIMESTAMPTZ column into a time.Time in UTC, so JSON and XML
+// output is Z-suffixed regardless of the session time zone
+type utcTime struct {
+	t *time.Time
+}
+
+// Scan reads a timestamp column
+func (u utcTime) Scan(src interface{}) error {
+	value, ok := src.(time.Time)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into a timestamp", src)
+	}
+	*u.t = value.UTC()
+	return nil
+}
+
+// nullUTCTime is utcTime for nullable columns; NULL leaves the pointer nil
+type nullUTCTime struct {
+	t **time.Time
+}
+
+// Scan reads a nullable timestamp column
+func (u nullUTCTime) Scan(src interface{}) error {
+	if src == nil {
+		*u.t = nil
+		return nil
+	}
+	var value time.Time
+	if err := (utcTime{&value}).Scan(src); err != nil {
+		return err
+	}
+	*u.t = &value
+	return nil
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mustParseRFC3339 parses value as RFC 3339 or fails the test
+func mustParseRFC3339(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		t.Fatalf("parsing %q: %v", value, err)
+	}
+	return parsed
+}
+
+func TestUTCTimeScan(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"positive offset", "2024-03-10T07:30:00+07:00", "2024-03-10T00:30:00Z"},
+		{"negative offset crossing midnight", "2024-03-09T21:15:00-05:00", "2024-03-10T02:15:00Z"},
+		{"half hour offset", "2024-03-10T06:00:00+05:30", "2024-03-10T00:30:00Z"},
+		{"Z suffix", "2024-03-10T00:30:00Z", "2024-03-10T00:30:00Z"},
+		{"fractional seconds", "2024-03-10T07:30:00.123456+07:00", "2024-03-10T00:30:00.123456Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := mustParseRFC3339(t, tt.input)
+
+			var got time.Time
+			if err := (utcTime{&got}).Scan(input); err != nil {
+				t.Fatalf("Scan(%s): %v", tt.input, err)
+			}
+			if got.Location() != time.UTC {
+				t.Errorf("location = %v, want UTC", got.Location())
+			}
+			if !got.Equal(input) {
+				t.Errorf("Scan(%s) = %s, want the same instant", tt.input, got)
+			}
+			if formatted := got.Format(time.RFC3339Nano); formatted != tt.want {
+				t.Errorf("formatted = %s, want %s", formatted, tt.want)
+			}
+			body, err := json.Marshal(Hero{CreatedAt: got})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := `"created_at":"` + tt.want + `"`; !strings.Contains(string(body), want) {
+				t.Errorf("JSON %s, want it to contain %s", body, want)
+			}
+		})
+	}
+}
+
+func TestUTCTimeScanRejectsNonTimestamps(t *testing.T) {
+	for _, src := range []interface{}{nil, "2024-03-10T00:30:00Z", []byte("2024-03-10T00:30:00Z"), int64(1710030600)} {
+		var got time.Time
+		err := (utcTime{&got}).Scan(src)
+		if err == nil || !strings.Contains(err.Error(), "cannot scan") {
+			t.Errorf("Scan(%#v) error = %v, want it rejected", src, err)
+		}
+		if !got.IsZero() {
+			t.Errorf("Scan(%#v) wrote %s", src, got)
+		}
+	}
+}
+
+func TestNullUTCTimeScan(t *testing.T) {
+	t.Run("non-UTC timestamp", func(t *testing.T) {
+		input := mustParseRFC3339(t, "2024-03-10T07:30:00+07:00")
+
+		var got *time.Time
+		if err := (nullUTCTime{&got}).Scan(input); err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		if got == nil {
+			t.Fatal("Scan left the timestamp nil")
+		}
+		if got.Location() != time.UTC || !got.Equal(input) {
+			t.Errorf("Scan = %s, want %s in UTC", got, input)
+		}
+		if formatted := got.Format(time.RFC3339); formatted != "2024-03-10T00:30:00Z" {
+			t.Errorf("formatted = %s, want 2024-03-10T00:30:00Z", formatted)
+		}
+	})
+
+	t.Run("NULL clears a previous value", func(t *testing.T) {
+		previous := time.Now()
+		got := &previous
+		if err := (nullUTCTime{&got}).Scan(nil); err != nil {
+			t.Fatalf("Scan(nil): %v", err)
+		}
+		if got != nil {
+			t.Errorf("Scan(nil) = %s, want nil", got)
+		}
+	})
+
+	t.Run("not a timestamp", func(t *testing.T) {
+		var got *time.Time
+		if err := (nullUTCTime{&got}).Scan("2024-03-10T00:30:00Z"); err == nil {
+			t.Error("Scan(string) succeeded, want an error")
+		}
+		if got != nil {
+			t.Errorf("Scan(string) wrote %s", got)
+		}
+	})
+}
+
+func TestHeroScanDestNormalisesTimestamps(t *testing.T) {
+	var hero Hero
+	dest := heroScanDest(&hero)
+	created := mustParseRFC3339(t, "2024-03-10T07:30:00+07:00")
+	archived := mustParseRFC3339(t, "2024-03-11T01:00:00-03:00")
+
+	for i, src := range map[int]interface{}{5: created, 6: created, 7: archived, 8: nil} {
+		scanner, ok := dest[i].(interface{ Scan(interface{}) error })
+		if !ok {
+			t.Fatalf("destination %d is %T, want a Scanner", i, dest[i])
+		}
+		if err := scanner.Scan(src); err != nil {
+			t.Fatalf("destination %d: %v", i, err)
+		}
+	}
+	if hero.CreatedAt.Location() != time.UTC || hero.UpdatedAt.Location() != time.UTC {
+		t.Errorf("created_at %s, updated_at %s, want UTC", hero.CreatedAt, hero.UpdatedAt)
+	}
+	if hero.ArchivedAt == nil || hero.ArchivedAt.Format(time.RFC3339) != "2024-03-11T04:00:00Z" {
+		t.Errorf("archived_at = %v, want 2024-03-11T04:00:00Z", hero.ArchivedAt)
+	}
+	if hero.DeletedAt != nil {
+		t.Errorf("deleted_at = %s, want nil", hero.DeletedAt)
+	}
+}
+
+// updated_at is maintained by the update_heroes_updated_at trigger from the first migration
+func TestHeroUpdatedAtTrigger(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var trigger, function string
+	for _, migration := range migrations {
+		for _, statement := range strings.Split(migration.SQL, ";") {
+			normalized := strings.Join(strings.Fields(statement), " ")
+			switch {
+			case strings.Contains(normalized, "TRIGGER update_heroes_updated_at"):
+				trigger = normalized
+			case strings.Contains(normalized, "FUNCTION update_updated_at_column()"):
+				function = normalized
+			}
+		}
+	}
+	if !strings.HasPrefix(trigger, "CREATE TRIGGER update_heroes_updated_at BEFORE UPDATE ON heroes FOR EACH ROW") {
+		t.Errorf("last statement on the trigger is %q, want it created BEFORE UPDATE on every heroes row", trigger)
+	}
+	if !strings.Contains(function, "NEW.updated_at = CURRENT_TIMESTAMP") {
+		t.Errorf("trigger function %q doesn't refresh updated_at", function)
+	}
+}
+
+// An update returns the updated_at the trigger wrote, in UTC; the fake
+// database plays the trigger, answering in a non-UTC session time zone
+func TestUpdateHeroRefreshesUpdatedAt(t *testing.T) {
+	cfg := useTestConfig(t)
+	captureLogs(t)
+	adminToken := addTestSession(t, roleAdmin)
+
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	created := time.Date(2024, 3, 10, 7, 30, 0, 0, jakarta)
+	stored := Hero{ID: "1", Name: "Alucard", Role: "Fighter", Difficulty: "Sedang", Tags: []string{}, CreatedAt: created, UpdatedAt: created}
+
+	connector := useFakeDB(t)
+	connector.respond = func(query string, args []interface{}) *fakeResult {
+		switch {
+		case strings.HasPrefix(query, "UPDATE heroes"):
+			// BEFORE UPDATE trigger: NEW.updated_at = CURRENT_TIMESTAMP
+			stored.Name, stored.UpdatedAt = args[0].(string), time.Now().In(jakarta)
+			return fakeHeroes(stored)
+		case strings.Contains(query, "FROM heroes"):
+			return fakeHeroes(stored)
+		}
+		return respondNotRevoked(query, args)
+	}
+
+	before := serve(cfg, httptest.NewRequest(http.MethodGet, "/api/heroes/1", nil))
+	var original Hero
+	if err := json.Unmarshal(before.Body.Bytes(), &original); err != nil {
+		t.Fatalf("get: %v; body %s", err, before.Body.String())
+	}
+
+	r := httptest.NewRequest(http.MethodPut, "/api/heroes/1", strings.NewReader(`{"name":"Alucard Prime","role":"Fighter","difficulty":"Sedang"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authorization", "Bearer "+adminToken)
+	rec := serve(cfg, r)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: status = %d, want 200; body %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		Name      string `json:"name"`
+		CreatedAt string `json:"created_at"`
+		UpdatedAt string `json:"updated_at"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Name != "Alucard Prime" {
+		t.Errorf("name = %q, want the updated row", body.Name)
+	}
+	for field, value := range map[string]string{"created_at": body.CreatedAt, "updated_at": body.UpdatedAt} {
+		if !strings.HasSuffix(value, "Z") {
+			t.Errorf("%s = %q, want RFC 3339 in UTC", field, value)
+		}
+	}
+
+	createdAt := mustParseRFC3339(t, body.CreatedAt)
+	updatedAt := mustParseRFC3339(t, body.UpdatedAt)
+	if !createdAt.Equal(created) {
+		t.Errorf("created_at = %s, want it unchanged at %s", createdAt, created.UTC())
+	}
+	if !updatedAt.After(original.UpdatedAt) {
+		t.Errorf("updated_at = %s, want it after the previous %s", updatedAt, original.UpdatedAt)
+	}
+	if updatedAt.Before(createdAt) {
+		t.Errorf("updated_at %s is before created_at %s", updatedAt, createdAt)
+	}
+
+	// The timestamp comes from the trigger: the statement leaves updated_at alone
+	// and returns the row as written
+	for _, exec := range connector.executed() {
+		if strings.HasPrefix(exec.query, "UPDATE heroes") {
+			if strings.Contains(exec.query, "updated_at =") || !strings.Contains(exec.query, "RETURNING "+heroColumns) {
+				t.Errorf("update statement %q should return the row and leave updated_at to the trigger", exec.query)
+			}
+		}
+	}
+}
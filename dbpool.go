@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Connection pool settings, temporarily tightened while the pool is recycled
+const (
+	dbMaxOpenConns       = 25
+	dbMaxIdleConns       = 5
+	dbConnMaxLifetime    = 5 * time.Minute
+	recycleConnLifetime  = 5 * time.Second
+	recycleProbeTimeout  = 2 * time.Second
+	recycleProbeInterval = 250 * time.Millisecond
+	recycleProbeMaxWait  = 5 * time.Second
+)
+
+// errCircuitOpen is returned instead of running a query while the circuit is open
+var errCircuitOpen = errors.New("database circuit open, failing fast")
+
+// poolRecycler is a circuit breaker around one DB pool. After threshold
+// consecutive connection errors it opens: queries fail fast with
+// errCircuitOpen, dead connections are flushed, and after the cooldown the
+// database is probed until it answers again, which closes the circuit.
+type poolRecycler struct {
+	name string
+	// db returns the pool, which is only opened after the recycler exists
+	db func() *sql.DB
+
+	recycling     atomic.Bool
+	recycles      atomic.Int64
+	recoveries    atomic.Int64
+	probeFailures atomic.Int64
+	// Connection errors since the last successful query
+	failures  atomic.Int64
+	threshold atomic.Int64
+	cooldown  atomic.Int64
+
+	mu            sync.Mutex
+	lastRecycleAt time.Time
+	lastRecovery  time.Time
+	lastError     string
+}
+
+// Recyclers for the primary pool and the read replica pool. While no replica
+// is configured ReadDB is DB and every query goes through dbPool.
+var (
+	dbPool      = newPoolRecycler("primary", func() *sql.DB { return DB }, 1, 0)
+	replicaPool = newPoolRecycler("replica", func() *sql.DB { return ReadDB }, 1, 0)
+)
+
+// newPoolRecycler returns a breaker for the pool returned by db that opens after
+// threshold consecutive connection errors and stays open for at least cooldown
+func newPoolRecycler(name string, db func() *sql.DB, threshold int, cooldown time.Duration) *poolRecycler {
+	p := &poolRecycler{name: name, db: db}
+	p.configure(threshold, cooldown)
+	return p
+}
+
+// configure changes the breaker threshold and cooldown
+func (p *poolRecycler) configure(threshold int, cooldown time.Duration) {
+	p.threshold.Store(int64(threshold))
+	p.cooldown.Store(int64(cooldown))
+}
+
+// isConnectionError reports whether err means the connection itself is unusable,
+// as opposed to a query or constraint error
+func isConnectionError(err error) bool {
+	// A cancelled request says nothing about the database; DeadlineExceeded
+	// would otherwise pass as a net.Error below
+	if err == nil || isCancellation(err) {
+		return false
+	}
+
+	if errors.Is(err, errCircuitOpen) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	// Class 08 is connection_exception; 57P01-57P03 are sent while the server shuts down or starts
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "57P01", "57P02", "57P03":
+			return true
+		}
+		return pqErr.Code.Class() == "08"
+	}
+
+	return false
+}
+
+// record counts the outcome of a database call. Connection errors open the
+// circuit once threshold of them follow each other; any other outcome,
+// including a query error, resets the count.
+func (p *poolRecycler) record(err error) {
+	if errors.Is(err, errCircuitOpen) {
+		return
+	}
+	if !isConnectionError(err) {
+		p.failures.Store(0)
+		return
+	}
+	if p.failures.Add(1) >= p.threshold.Load() {
+		p.trigger(err)
+	}
+}
+
+// allow returns errCircuitOpen while the circuit is open
+func (p *poolRecycler) allow() error {
+	if p.recycling.Load() {
+		return errCircuitOpen
+	}
+	return nil
+}
+
+// retryAfter is the Retry-After value sent while the database is unavailable,
+// the cooldown rounded up to whole seconds
+func (p *poolRecycler) retryAfter() string {
+	seconds := (time.Duration(p.cooldown.Load()) + time.Second - 1) / time.Second
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.FormatInt(int64(seconds), 10)
+}
+
+// trigger closes idle connections and shortens their lifetime, then probes in
+// the background. Concurrent triggers while a recycle is running are ignored.
+func (p *poolRecycler) trigger(cause error) {
+	if !p.recycling.CompareAndSwap(false, true) {
+		return
+	}
+
+	p.recycles.Add(1)
+	p.mu.Lock()
+	p.lastRecycleAt = time.Now()
+	p.lastError = cause.Error()
+	p.mu.Unlock()
+
+	slog.Error("Database connection error, circuit open", "pool", p.name, "error", cause, "consecutive_failures", p.failures.Load())
+
+	// SetMaxIdleConns(0) closes every idle connection immediately
+	db := p.db()
+	db.SetMaxIdleConns(0)
+	db.SetConnMaxLifetime(recycleConnLifetime)
+
+	go p.probe()
+}
+
+// probe waits for the cooldown, then pings the database until it responds and
+// restores the normal pool settings
+func (p *poolRecycler) probe() {
+	time.Sleep(time.Duration(p.cooldown.Load()))
+
+	db := p.db()
+	interval := recycleProbeInterval
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), recycleProbeTimeout)
+		err := db.PingContext(ctx)
+		cancel()
+
+		if err == nil {
+			break
+		}
+
+		p.probeFailures.Add(1)
+		time.Sleep(interval)
+		interval *= 2
+		if interval > recycleProbeMaxWait {
+			interval = recycleProbeMaxWait
+		}
+	}
+
+	db.SetMaxIdleConns(dbMaxIdleConns)
+	db.SetConnMaxLifetime(dbConnMaxLifetime)
+
+	p.recoveries.Add(1)
+	p.mu.Lock()
+	p.lastRecovery = time.Now()
+	downtime := p.lastRecovery.Sub(p.lastRecycleAt)
+	p.mu.Unlock()
+	p.failures.Store(0)
+	p.recycling.Store(false)
+
+	slog.Info("Database reachable again, circuit closed", "pool", p.name, "downtime_ms", downtime.Milliseconds())
+}
+
+// healthy reports whether the circuit is closed
+func (p *poolRecycler) healthy() bool {
+	return !p.recycling.Load()
+}
+
+// stats returns the recycle counters together with the pool statistics
+func (p *poolRecycler) stats() DBPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	dbStats := p.db().Stats()
+	circuit := "closed"
+	if p.recycling.Load() {
+		circuit = "open"
+	}
+	stats := DBPoolStats{
+		Pool:                p.name,
+		Circuit:             circuit,
+		ConsecutiveFailures: p.failures.Load(),
+		FailureThreshold:    p.threshold.Load(),
+		Recycling:           p.recycling.Load(),
+		Recycles:            p.recycles.Load(),
+		Recoveries:          p.recoveries.Load(),
+		ProbeFailures:       p.probeFailures.Load(),
+		LastError:           p.lastError,
+		OpenConnections:     dbStats.OpenConnections,
+		InUse:               dbStats.InUse,
+		Idle:                dbStats.Idle,
+		WaitCount:           dbStats.WaitCount,
+		MaxIdleClosed:       dbStats.MaxIdleClosed,
+		MaxLifetimeClosed:   dbStats.MaxLifetimeClosed,
+	}
+	if !p.lastRecycleAt.IsZero() {
+		lastRecycleAt := p.lastRecycleAt
+		stats.LastRecycleAt = &lastRecycleAt
+	}
+	if !p.lastRecovery.IsZero() {
+		lastRecovery := p.lastRecovery
+		stats.LastRecoveryAt = &lastRecovery
+	}
+	return stats
+}
+
+// GET /api/admin/db-pool - Connection pool statistics
+// @Summary Database pool statistics
+// @Description Connection pool usage, circuit breaker state and recycle counters after database connection errors
+// @Tags admin
+// @Produce json,application/xml
+// @Success 200 {object} DBPoolStats
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/db-pool [get]
+func getDBPoolStats(w http.ResponseWriter, r *http.Request) {
+	respondWith(w, r, http.StatusOK, databasePoolStats())
+}
+
+// databasePoolStats returns the primary pool statistics, with the replica's
+// nested when a separate replica pool is open
+func databasePoolStats() DBPoolStats {
+	stats := dbPool.stats()
+	if ReadDB != nil && ReadDB != DB {
+		replica := replicaPool.stats()
+		stats.Replica = &replica
+	}
+	return stats
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeConnector opens connections that record the statements they execute
+// and answer queries from respond, or refuses them while down
+type fakeConnector struct {
+	down atomic.Bool
+	// delay is added to every statement
+	delay time.Duration
+	// respond returns the result of a query; nil, or a nil result, is no rows
+	respond func(query string, args []interface{}) *fakeResult
+
+	mu    sync.Mutex
+	execs []fakeExec
+}
+
+// fakeResult is the answer to a query on a fake connection
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+}
+
+// fakeExec is one statement run through a fake connection
+type fakeExec struct {
+	query string
+	args  []interface{}
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	if c.down.Load() {
+		return nil, syscall.ECONNREFUSED
+	}
+	return fakeConn{c}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return nil }
+
+// executed returns the statements run so far
+func (c *fakeConnector) executed() []fakeExec {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]fakeExec(nil), c.execs...)
+}
+
+type fakeConn struct {
+	connector *fakeConnector
+}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+// fakeTx runs its statements like any other; commit and rollback do nothing
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+// run records a statement and waits out the connector's delay
+func (c fakeConn) run(query string, args []driver.NamedValue) fakeExec {
+	exec := fakeExec{query: query}
+	for _, arg := range args {
+		exec.args = append(exec.args, arg.Value)
+	}
+	c.connector.mu.Lock()
+	c.connector.execs = append(c.connector.execs, exec)
+	c.connector.mu.Unlock()
+	time.Sleep(c.connector.delay)
+	return exec
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.run(query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	exec := c.run(query, args)
+	result := &fakeResult{}
+	if c.connector.respond != nil {
+		if answer := c.connector.respond(query, exec.args); answer != nil {
+			result = answer
+		}
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+	return &fakeRows{fakeResult: *result}, nil
+}
+
+// fakeRows reads a fakeResult
+type fakeRows struct {
+	fakeResult
+	next int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+// Columns of heroColumns, in order
+var heroColumnNames = []string{"id", "name", "role", "difficulty", "difficulty_score", "created_at", "updated_at",
+	"archived_at", "deleted_at", "lore", "specialty", "lane", "release_date", "tags"}
+
+// fakeHeroes answers a query selecting heroColumns with a row per hero
+func fakeHeroes(heroes ...Hero) *fakeResult {
+	result := &fakeResult{columns: heroColumnNames}
+	for _, hero := range heroes {
+		var score, lore, specialty, lane, releaseDate, archivedAt, deletedAt driver.Value
+		if hero.DifficultyScore != nil {
+			score = int64(*hero.DifficultyScore)
+		}
+		if hero.Lore != nil {
+			lore = *hero.Lore
+		}
+		if hero.Specialty != nil {
+			specialty = *hero.Specialty
+		}
+		if hero.Lane != nil {
+			lane = *hero.Lane
+		}
+		if hero.ReleaseDate != nil {
+			releaseDate = hero.ReleaseDate.Time
+		}
+		if hero.ArchivedAt != nil {
+			archivedAt = *hero.ArchivedAt
+		}
+		if hero.DeletedAt != nil {
+			deletedAt = *hero.DeletedAt
+		}
+		tags := []byte("{" + strings.Join(hero.Tags, ",") + "}")
+		result.rows = append(result.rows, []driver.Value{
+			string(hero.ID), hero.Name, hero.Role, hero.Difficulty, score,
+			hero.CreatedAt, hero.UpdatedAt, archivedAt, deletedAt, lore, specialty, lane, releaseDate, tags,
+		})
+	}
+	return result
+}
+
+// openFakePool returns a pool on a fake connector, closed when the test ends
+func openFakePool(t *testing.T) (*sql.DB, *fakeConnector) {
+	t.Helper()
+	connector := &fakeConnector{}
+	db := sql.OpenDB(connector)
+	t.Cleanup(func() { db.Close() })
+	return db, connector
+}
+
+// waitFor polls condition for up to timeout
+func waitFor(t *testing.T, timeout time.Duration, condition func() bool) bool {
+	t.Helper()
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if condition() {
+			return true
+		}
+	}
+	return condition()
+}
+
+func TestPoolRecyclerCircuit(t *testing.T) {
+	captureLogs(t)
+	db, connector := openFakePool(t)
+	breaker := newPoolRecycler("test", func() *sql.DB { return db }, 2, 50*time.Millisecond)
+	connErr := fmt.Errorf("query heroes.list: %w", syscall.ECONNRESET)
+
+	// Below the threshold, and any other outcome resets the count
+	breaker.record(connErr)
+	breaker.record(errors.New("syntax error"))
+	breaker.record(connErr)
+	if err := breaker.allow(); err != nil {
+		t.Fatalf("allow() = %v after non-consecutive connection errors, want nil", err)
+	}
+
+	// The database goes away: the second error in a row opens the circuit
+	connector.down.Store(true)
+	breaker.record(connErr)
+	if err := breaker.allow(); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("allow() = %v at the threshold, want errCircuitOpen", err)
+	}
+	if breaker.healthy() {
+		t.Error("healthy() = true with the circuit open")
+	}
+	if got := breaker.retryAfter(); got != "1" {
+		t.Errorf("retryAfter() = %q, want the 50ms cooldown rounded up to 1", got)
+	}
+	// Fail-fast errors don't count as further failures
+	breaker.record(errCircuitOpen)
+	if stats := breaker.stats(); stats.Circuit != "open" || stats.ConsecutiveFailures != 2 || stats.Recycles != 1 || stats.Pool != "test" {
+		t.Errorf("open stats = %+v", stats)
+	}
+
+	// Half-open: after the cooldown the database is probed, and stays open while probes fail
+	if !waitFor(t, 2*time.Second, func() bool { return breaker.probeFailures.Load() > 0 }) {
+		t.Fatal("no failed probe while the database is down")
+	}
+	if err := breaker.allow(); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("allow() = %v while probes fail, want errCircuitOpen", err)
+	}
+
+	// Once a probe succeeds the circuit closes and the count starts over
+	connector.down.Store(false)
+	if !waitFor(t, 2*time.Second, breaker.healthy) {
+		t.Fatal("circuit still open after the database came back")
+	}
+	if err := breaker.allow(); err != nil {
+		t.Errorf("allow() = %v after recovery, want nil", err)
+	}
+	if stats := breaker.stats(); stats.Circuit != "closed" || stats.ConsecutiveFailures != 0 || stats.Recoveries != 1 || stats.LastRecoveryAt == nil {
+		t.Errorf("closed stats = %+v", stats)
+	}
+}
+
+func TestPoolRecyclerIgnoresNonConnectionErrors(t *testing.T) {
+	breaker := newPoolRecycler("test", func() *sql.DB { return nil }, 1, time.Minute)
+	for _, err := range []error{nil, sql.ErrNoRows, errSyntax, errCheck, context.Canceled, context.DeadlineExceeded} {
+		breaker.record(err)
+		if breaker.allow() != nil {
+			t.Fatalf("circuit opened after %v", err)
+		}
+	}
+}
+
+// A failing replica must not stop writes on the primary, and vice versa
+func TestQueriesUseThePoolOfTheirConnection(t *testing.T) {
+	captureLogs(t)
+	primary, _ := openFakePool(t)
+	replica, replicaConnector := openFakePool(t)
+
+	previousDB, previousReadDB := DB, ReadDB
+	previousPrimary, previousReplica := dbPool, replicaPool
+	t.Cleanup(func() {
+		DB, ReadDB = previousDB, previousReadDB
+		dbPool, replicaPool = previousPrimary, previousReplica
+	})
+	DB, ReadDB = primary, replica
+	dbPool = newPoolRecycler("primary", func() *sql.DB { return DB }, 1, time.Minute)
+	replicaPool = newPoolRecycler("replica", func() *sql.DB { return ReadDB }, 1, time.Minute)
+
+	query := &namedQuery{Name: "test.query"}
+	if got := query.bind().pool; got != dbPool {
+		t.Errorf("bind() pool = %s, want primary", got.name)
+	}
+	if got := query.Replica().pool; got != replicaPool {
+		t.Errorf("Replica() pool = %s, want replica", got.name)
+	}
+	if got := query.Replica().In(&sql.Tx{}).pool; got != dbPool {
+		t.Errorf("In(tx) pool = %s, want primary", got.name)
+	}
+
+	replicaConnector.down.Store(true)
+	query.record(replicaPool, syscall.ECONNREFUSED)
+	if err := query.Replica().Build("SELECT 1").check(nil); !errors.Is(err, errCircuitOpen) {
+		t.Errorf("replica query check() = %v, want errCircuitOpen", err)
+	}
+	if err := query.Build("SELECT 1").check(nil); err != nil {
+		t.Errorf("primary query check() = %v with only the replica down, want nil", err)
+	}
+	if stats := databasePoolStats(); stats.Circuit != "closed" || stats.Replica == nil || stats.Replica.Circuit != "open" {
+		t.Errorf("databasePoolStats() = %+v", stats)
+	}
+	if _, err := xml.Marshal(databasePoolStats()); err != nil {
+		t.Errorf("databasePoolStats() as XML: %v", err)
+	}
+}
+
+// useFakeDB makes a fake pool the primary and read pool for the rest of the test
+func useFakeDB(t *testing.T) *fakeConnector {
+	t.Helper()
+	db, connector := openFakePool(t)
+	previousDB, previousReadDB := DB, ReadDB
+	t.Cleanup(func() { DB, ReadDB = previousDB, previousReadDB })
+	DB, ReadDB = db, db
+	return connector
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Path prefix of the debug routes, left out of the request log
+const debugPathPrefix = "/debug/"
+
+// Number of recent GC pauses reported by /debug/vars
+const recentGCPauses = 10
+
+// DebugConfig enables the profiling and development endpoints
+type DebugConfig struct {
+	Pprof bool `yaml:"pprof"`
+	// DevEndpoints mounts POST /api/admin/reset; never enable it in production
+	DevEndpoints bool `yaml:"dev_endpoints"`
+}
+
+// registerDebugRoutes mounts net/http/pprof and runtime statistics for
+// admins. They sit outside /api, so rate limits don't apply to them.
+// basePath is stripped for the pprof index, which finds profiles by path.
+func registerDebugRoutes(router *mux.Router, basePath string) {
+	debug := router.PathPrefix("/debug").Subrouter()
+	debug.Use(adminMiddleware)
+	debug.Use(profileDeadlineMiddleware)
+
+	debug.HandleFunc("/vars", getRuntimeStats).Methods("GET")
+	debug.HandleFunc("/pprof/cmdline", pprof.Cmdline).Methods("GET")
+	debug.HandleFunc("/pprof/profile", pprof.Profile).Methods("GET")
+	debug.HandleFunc("/pprof/symbol", pprof.Symbol).Methods("GET", "POST")
+	debug.HandleFunc("/pprof/trace", pprof.Trace).Methods("GET")
+	// Index also serves the named profiles such as heap and goroutine
+	debug.PathPrefix("/pprof/").Handler(http.StripPrefix(basePath, http.HandlerFunc(pprof.Index))).Methods("GET")
+}
+
+// profileDeadlineMiddleware lifts SERVER_WRITE_TIMEOUT, since CPU profiles
+// and traces run for ?seconds= (30 by default) before writing anything
+func profileDeadlineMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isDebugRequest reports whether r is for one of the debug routes
+func isDebugRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, config.Server.BasePath+debugPathPrefix)
+}
+
+// GET /debug/vars - Runtime statistics
+// @Summary Runtime statistics
+// @Description Goroutines, heap usage, recent GC pauses and database pool statistics.
+// @Description Only mounted when DEBUG_PPROF=true.
+// @Tags admin
+// @Produce json,application/xml
+// @Success 200 {object} RuntimeStats
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /debug/vars [get]
+func getRuntimeStats(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := RuntimeStats{
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: mem.HeapAlloc,
+		HeapSysBytes:   mem.HeapSys,
+		HeapObjects:    mem.HeapObjects,
+		NumGC:          mem.NumGC,
+		GCPauseTotalMs: float64(mem.PauseTotalNs) / float64(time.Millisecond),
+		RecentGCPauses: []float64{},
+		Sessions:       len(activeSessions()),
+		Database:       databasePoolStats(),
+	}
+
+	// PauseNs is a circular buffer with the latest pause at (NumGC+255)%256
+	for i := uint32(0); i < recentGCPauses && i < mem.NumGC; i++ {
+		pause := mem.PauseNs[(mem.NumGC-1-i)%uint32(len(mem.PauseNs))]
+		stats.RecentGCPauses = append(stats.RecentGCPauses, float64(pause)/float64(time.Millisecond))
+	}
+
+	respondWith(w, r, http.StatusOK, stats)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Bounds of difficulty_score
+const (
+	minDifficultyScore = 1
+	maxDifficultyScore = 10
+)
+
+// difficultyBand is the score range covered by a difficulty label
+type difficultyBand struct {
+	Min, Max int
+	// Default is stored when only the label is given
+	Default int
+}
+
+// Score ranges of the known labels; heroes with other labels only have a
+// score when the client sends one. Migration 0008 backfills the defaults.
+var difficultyBands = map[string]difficultyBand{
+	"Mudah":  {Min: 1, Max: 3, Default: 2},
+	"Sedang": {Min: 4, Max: 6, Default: 5},
+	"Sulit":  {Min: 7, Max: 10, Default: 8},
+}
+
+// knownDifficulties returns the labels of difficultyBands from easiest to hardest
+func knownDifficulties() []string {
+	labels := make([]string, 0, len(difficultyBands))
+	for label := range difficultyBands {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return difficultyBands[labels[i]].Min < difficultyBands[labels[j]].Min
+	})
+	return labels
+}
+
+// DifficultyField is a difficulty label, or a 1-10 score sent as a JSON number
+type DifficultyField string
+
+// UnmarshalJSON accepts either a string label or a number
+func (d *DifficultyField) UnmarshalJSON(data []byte) error {
+	var label string
+	if err := json.Unmarshal(data, &label); err == nil {
+		*d = DifficultyField(label)
+		return nil
+	}
+
+	var score int
+	if err := json.Unmarshal(data, &score); err != nil {
+		return fmt.Errorf("difficulty must be a label or a number")
+	}
+	*d = DifficultyField(strconv.Itoa(score))
+	return nil
+}
+
+// heroDifficulty is a difficulty label with its score, nil when unknown
+type heroDifficulty struct {
+	Label string
+	Score *int
+}
+
+// labelForScore returns the known label whose band contains score
+func labelForScore(score int) string {
+	for label, band := range difficultyBands {
+		if score >= band.Min && score <= band.Max {
+			return label
+		}
+	}
+	return strconv.Itoa(score)
+}
+
+// resolveDifficulty derives the stored label and score from a request. A
+// numeric difficulty picks the label of its band; a known label fills in its
+// default score. When both are given they must agree.
+func resolveDifficulty(value DifficultyField, score *int) (heroDifficulty, error) {
+	label := string(value)
+	if n, err := strconv.Atoi(label); err == nil {
+		if score != nil && *score != n {
+			return heroDifficulty{}, fmt.Errorf("difficulty %d and difficulty_score %d differ", n, *score)
+		}
+		score, label = &n, ""
+	}
+
+	if score != nil && (*score < minDifficultyScore || *score > maxDifficultyScore) {
+		return heroDifficulty{}, fmt.Errorf("difficulty_score must be between %d and %d", minDifficultyScore, maxDifficultyScore)
+	}
+
+	if label == "" {
+		return heroDifficulty{Label: labelForScore(*score), Score: score}, nil
+	}
+
+	band, known := difficultyBands[label]
+	switch {
+	case !known:
+		return heroDifficulty{Label: label, Score: score}, nil
+	case score == nil:
+		defaultScore := band.Default
+		return heroDifficulty{Label: label, Score: &defaultScore}, nil
+	case *score < band.Min || *score > band.Max:
+		return heroDifficulty{}, fmt.Errorf("difficulty_score %d does not match %s (%d-%d)", *score, label, band.Min, band.Max)
+	}
+	return heroDifficulty{Label: label, Score: score}, nil
+}
+
+// Sets the difficulty of one hero, leaving every other column alone
+var querySetHeroDifficulty = registerQuery("heroes.set_difficulty", `UPDATE heroes SET difficulty = $1, difficulty_score = $2
+	WHERE id = $3 RETURNING `+heroColumns, paramText, paramNullInt, paramHeroID)
+
+// PUT /api/heroes/{id}/difficulty - Change a hero's difficulty
+// @Summary Set hero difficulty
+// @Description Change only the difficulty of a hero, e.g. after a rework. difficulty must be one of the known labels
+// @Description (Mudah, Sedang, Sulit) or a 1-10 score; difficulty_score is optional and must fall within the label's range.
+// @Tags heroes
+// @Accept json
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Param difficulty body HeroDifficultyRequest true "New difficulty"
+// @Success 200 {object} Hero
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/{id}/difficulty [put]
+func setHeroDifficulty(w http.ResponseWriter, r *http.Request) {
+	id, err := heroIDs.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
+		return
+	}
+
+	var req HeroDifficultyRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	req.Difficulty = canonicalDifficulty(req.Difficulty)
+	if req.Difficulty == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, "difficulty is required")
+		return
+	}
+
+	difficulty, err := resolveDifficulty(req.Difficulty, req.DifficultyScore)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, err.Error())
+		return
+	}
+	// Unlike full updates, this endpoint only takes the known labels
+	if _, known := difficultyBands[difficulty.Label]; !known {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidDifficulty,
+			fmt.Sprintf("difficulty must be one of %s or a score from %d to %d",
+				strings.Join(knownDifficulties(), ", "), minDifficultyScore, maxDifficultyScore))
+		return
+	}
+
+	var hero Hero
+	err = querySetHeroDifficulty.WithContext(r.Context()).QueryRow(difficulty.Label, difficulty.Score, id).Scan(heroScanDest(&hero)...)
+	if err != nil {
+		respondWithHeroWriteError(w, r, err, "Failed to update hero difficulty")
+		return
+	}
+
+	invalidateHeroCache()
+	publishHeroEvent(r, eventUpdated, hero)
+
+	respondWith(w, r, http.StatusOK, hero)
+}
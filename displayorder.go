@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// DisplayOrder lists enum values in the order frontends should show them
+type DisplayOrder struct {
+	Roles        []string `json:"roles" yaml:"roles"`
+	Difficulties []string `json:"difficulties" yaml:"difficulties"`
+}
+
+// withDefaults fills unset lists with the standard ordering
+func (d DisplayOrder) withDefaults() DisplayOrder {
+	if len(d.Roles) == 0 {
+		d.Roles = []string{"Tank", "Fighter", "Assassin", "Mage", "Marksman", "Support"}
+	}
+	if len(d.Difficulties) == 0 {
+		d.Difficulties = []string{"Mudah", "Sedang", "Sulit"}
+	}
+	return d
+}
+
+// Runtime display order, seeded from config.yaml and changeable through the admin API
+var (
+	displayOrder      DisplayOrder
+	displayOrderMutex sync.RWMutex
+)
+
+// currentDisplayOrder returns the active display order
+func currentDisplayOrder() DisplayOrder {
+	displayOrderMutex.RLock()
+	defer displayOrderMutex.RUnlock()
+	return displayOrder
+}
+
+// setDisplayOrder replaces the active display order
+func setDisplayOrder(order DisplayOrder) {
+	displayOrderMutex.Lock()
+	displayOrder = order.withDefaults()
+	displayOrderMutex.Unlock()
+}
+
+// orderValues sorts values by their position in order; values missing from
+// order go last, alphabetically
+func orderValues(values, order []string) []ReferenceValue {
+	position := make(map[string]int, len(order))
+	for i, value := range order {
+		position[value] = i
+	}
+
+	sorted := append([]string(nil), values...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, knownI := position[sorted[i]]
+		pj, knownJ := position[sorted[j]]
+		switch {
+		case knownI && knownJ:
+			return pi < pj
+		case knownI != knownJ:
+			return knownI
+		default:
+			return sorted[i] < sorted[j]
+		}
+	})
+
+	result := make([]ReferenceValue, len(sorted))
+	for i, value := range sorted {
+		result[i] = ReferenceValue{Value: value, Order: i}
+	}
+	return result
+}
+
+// GET /api/admin/display-order - Get the enum display order
+// @Summary Get display order
+// @Description Order in which roles and difficulties are listed by reference endpoints
+// @Tags admin
+// @Produce json,application/xml
+// @Success 200 {object} DisplayOrder
+// @Security BearerAuth
+// @Router /api/admin/display-order [get]
+func getDisplayOrder(w http.ResponseWriter, r *http.Request) {
+	respondWith(w, r, http.StatusOK, currentDisplayOrder())
+}
+
+// PUT /api/admin/display-order - Change the enum display order
+// @Summary Update display order
+// @Description Change the order of roles and difficulties without a restart. Omitted lists keep their defaults.
+// @Tags admin
+// @Accept json
+// @Produce json,application/xml
+// @Param order body DisplayOrder true "Display order"
+// @Success 200 {object} DisplayOrder
+// @Failure 400 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/display-order [put]
+func updateDisplayOrder(w http.ResponseWriter, r *http.Request) {
+	var order DisplayOrder
+	if !decodeJSONBody(w, r, &order) {
+		return
+	}
+
+	setDisplayOrder(order)
+
+	session, _ := sessionFromRequest(r)
+	requestLogger(r).Info("AUDIT display order changed", "by", session.Username)
+
+	respondWith(w, r, http.StatusOK, currentDisplayOrder())
+}
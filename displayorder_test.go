@@ -0,0 +1,157 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// draftedRoles runs a draft against a fake database holding one hero per
+// role and returns the roles in the order they were queried and returned
+func draftedRoles(t *testing.T, cfg AppConfig) (queried, returned []string) {
+	t.Helper()
+	connector := useFakeDB(t)
+	connector.respond = func(query string, args []interface{}) *fakeResult {
+		if !strings.Contains(query, "ORDER BY random()") {
+			return fakeHeroes()
+		}
+		role := args[0].(string)
+		queried = append(queried, role)
+		return fakeHeroes(Hero{ID: "1", Name: role + " hero", Role: role, Difficulty: "Sedang", Tags: []string{}})
+	}
+
+	rec := serve(cfg, httptest.NewRequest(http.MethodGet, "/api/heroes/draft", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body.String())
+	}
+	var draft HeroDraft
+	if err := json.Unmarshal(rec.Body.Bytes(), &draft); err != nil {
+		t.Fatal(err)
+	}
+	for _, hero := range draft.Heroes {
+		returned = append(returned, hero.Role)
+	}
+	return queried, returned
+}
+
+func TestDraftFollowsDisplayOrder(t *testing.T) {
+	cfg := useTestConfig(t)
+	captureLogs(t)
+
+	defaults := []string{"Tank", "Fighter", "Assassin", "Mage", "Marksman"}
+	queried, returned := draftedRoles(t, cfg)
+	if !reflect.DeepEqual(queried, defaults) || !reflect.DeepEqual(returned, defaults) {
+		t.Errorf("default draft queried %v and returned %v, want %v", queried, returned, defaults)
+	}
+
+	setDisplayOrder(DisplayOrder{Roles: []string{"Support", "Mage", "Tank", "Marksman", "Fighter", "Assassin"}})
+	reordered := []string{"Support", "Mage", "Tank", "Marksman", "Fighter"}
+	queried, returned = draftedRoles(t, cfg)
+	if !reflect.DeepEqual(queried, reordered) || !reflect.DeepEqual(returned, reordered) {
+		t.Errorf("reordered draft queried %v and returned %v, want %v", queried, returned, reordered)
+	}
+
+	setDisplayOrder(DisplayOrder{Roles: []string{"Mage", "Tank"}})
+	if queried, _ = draftedRoles(t, cfg); !reflect.DeepEqual(queried, []string{"Mage", "Tank"}) {
+		t.Errorf("short display order drafted %v, want [Mage Tank]", queried)
+	}
+}
+
+// respondTierList answers the tier list query with heroes placed in tiers
+func respondTierList(placements map[string]string) func(string, []interface{}) *fakeResult {
+	return func(query string, args []interface{}) *fakeResult {
+		if !strings.Contains(query, "hero_tiers") {
+			return fakeHeroes()
+		}
+		result := &fakeResult{columns: append(append([]string(nil), heroColumnNames...), "tier")}
+		for name, tier := range placements {
+			row := fakeHeroes(Hero{ID: HeroID(name), Name: name, Role: "Mage", Difficulty: "Sedang", Tags: []string{}}).rows[0]
+			result.rows = append(result.rows, append(row, driver.Value(tier)))
+		}
+		return result
+	}
+}
+
+// tierKeys returns the keys of the tiers object in the order they appear in body
+func tierKeys(t *testing.T, body []byte) []string {
+	t.Helper()
+	var doc struct {
+		Tiers json.RawMessage `json:"tiers"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatal(err)
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(doc.Tiers)))
+	if _, err := decoder.Token(); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key.(string))
+		var skip json.RawMessage
+		if err := decoder.Decode(&skip); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return keys
+}
+
+func TestTierListIsOrdered(t *testing.T) {
+	cfg := useTestConfig(t)
+	config.Validation = config.Validation.withDefaults()
+	captureLogs(t)
+	connector := useFakeDB(t)
+	// "X" was a tier before the configuration changed, so it goes last
+	connector.respond = respondTierList(map[string]string{"Eudora": "S", "Layla": "X", "Miya": "B"})
+
+	want := []string{"S", "A", "B", "C", "D", "X"}
+
+	rec := serve(cfg, httptest.NewRequest(http.MethodGet, "/api/tierlist?patch=1.8.42", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body.String())
+	}
+	var list struct {
+		Order []string          `json:"tier_order"`
+		Tiers map[string][]Hero `json:"tiers"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(list.Order, want) {
+		t.Errorf("tier_order = %v, want %v", list.Order, want)
+	}
+	if keys := tierKeys(t, rec.Body.Bytes()); !reflect.DeepEqual(keys, want) {
+		t.Errorf("tiers keys are in order %v, want %v", keys, want)
+	}
+	if len(list.Tiers["S"]) != 1 || len(list.Tiers["A"]) != 0 || list.Tiers["A"] == nil {
+		t.Errorf("tiers = %v, want Eudora in S and an empty A", list.Tiers)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/tierlist?patch=1.8.42", nil)
+	r.Header.Set("Accept", "application/xml")
+	rec = serve(cfg, r)
+	var doc struct {
+		Tiers []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"tier"`
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("%v; body %s", err, rec.Body.String())
+	}
+	var names []string
+	for _, tier := range doc.Tiers {
+		names = append(names, tier.Name)
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("XML tiers are in order %v, want %v", names, want)
+	}
+}
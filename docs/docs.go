@@ -24,64 +24,124 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/heroes": {
+        "/api/admin/cache": {
             "get": {
-                "description": "Retrieve all heroes from the database",
-                "consumes": [
-                    "application/json"
+                "description": "Size of the in-memory hero response cache and its hits and misses since startup",
+                "produces": [
+                    "application/json",
+                    "application/xml"
                 ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Hero cache statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.CacheStats"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/admin/db-pool": {
+            "get": {
+                "description": "Connection pool usage, circuit breaker state and recycle counters after database connection errors",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/xml"
                 ],
                 "tags": [
-                    "heroes"
+                    "admin"
                 ],
-                "summary": "Get all heroes",
+                "summary": "Database pool statistics",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/main.Hero"
-                            }
+                            "$ref": "#/definitions/main.DBPoolStats"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
+                ]
+            }
+        },
+        "/api/admin/display-order": {
+            "get": {
+                "description": "Order in which roles and difficulties are listed by reference endpoints",
+                "produces": [
+                    "application/json",
+                    "application/xml"
                 ],
-                "description": "Create a new hero in the database",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get display order",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.DisplayOrder"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Change the order of roles and difficulties without a restart. Omitted lists keep their defaults.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/xml"
                 ],
                 "tags": [
-                    "heroes"
+                    "admin"
                 ],
-                "summary": "Create a new hero",
+                "summary": "Update display order",
                 "parameters": [
                     {
-                        "description": "Hero data",
-                        "name": "hero",
+                        "description": "Display order",
+                        "name": "order",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.HeroCreateRequest"
+                            "$ref": "#/definitions/main.DisplayOrder"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.Hero"
+                            "$ref": "#/definitions/main.DisplayOrder"
                         }
                     },
                     "400": {
@@ -90,78 +150,73 @@ const docTemplate = `{
                             "$ref": "#/definitions/main.ErrorResponse"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
         },
-        "/api/heroes/{id}": {
+        "/api/admin/export": {
             "get": {
-                "description": "Retrieve a specific hero by ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Download every hero (archived and deleted ones included) with its tags, counters and tier placements as one JSON document,\nread from a single consistent snapshot. The document is streamed, so a failure midway leaves it truncated; check that it parses before relying on it.\nFavorites and view counts are not included.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "heroes"
-                ],
-                "summary": "Get hero by ID",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Hero ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "admin"
                 ],
+                "summary": "Export heroes",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.Hero"
+                            "$ref": "#/definitions/main.Snapshot"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/main.ErrorResponse"
                         }
                     }
-                }
-            },
-            "put": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
-                "description": "Update an existing hero by ID",
+                ]
+            }
+        },
+        "/api/admin/import": {
+            "post": {
+                "description": "Restore heroes, tags, counters and tier placements from a document produced by GET /api/admin/export, in one transaction.\nThe schema_version and every record are validated before anything is written; any problem fails the whole import with 422.\nmode=replace (the default) empties heroes, tags, counters and tier placements first and, with DESTRUCTIVE_CONFIRMATION on, asks for confirmation when they aren't empty.\nmode=merge keeps existing rows: heroes are matched by ID and overwritten when they differ, counters are added and tier placements overwritten.\nThe body may be up to SERVER_MAX_IMPORT_BYTES. Favorites and view counts are left untouched.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/xml"
                 ],
                 "tags": [
-                    "heroes"
+                    "admin"
                 ],
-                "summary": "Update hero by ID",
+                "summary": "Import heroes",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Hero ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "replace or merge (default replace)",
+                        "name": "mode",
+                        "in": "query"
                     },
                     {
-                        "description": "Hero data",
-                        "name": "hero",
+                        "description": "Exported dataset",
+                        "name": "snapshot",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.HeroUpdateRequest"
+                            "$ref": "#/definitions/main.Snapshot"
                         }
                     }
                 ],
@@ -169,7 +224,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.Hero"
+                            "$ref": "#/definitions/main.ImportSummary"
                         }
                     },
                     "400": {
@@ -178,136 +233,4117 @@ const docTemplate = `{
                             "$ref": "#/definitions/main.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/main.ValidationErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/main.ConfirmationRequiredResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/admin/logout-all": {
+            "post": {
+                "description": "Revoke every active session, including the caller's, so all users must log in again.\nWith ?user= only that user's sessions are revoked.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Force logout everyone",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only revoke sessions of this username",
+                        "name": "user",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.RevokedSessionsResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/main.ErrorResponse"
                         }
                     }
-                }
-            },
-            "delete": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
+                ]
+            }
+        },
+        "/api/admin/maintenance": {
+            "get": {
+                "description": "Whether writes are rejected for maintenance, and who last changed it",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "admin"
                 ],
-                "description": "Delete an existing hero by ID",
+                "summary": "Maintenance mode status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.MaintenanceStatus"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "While enabled, every request that changes data gets 503 MAINTENANCE with Retry-After and\nthe given message; reads, login, logout and this endpoint keep working. The setting is\nsaved and survives restarts.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/xml"
                 ],
                 "tags": [
-                    "heroes"
+                    "admin"
                 ],
-                "summary": "Delete hero by ID",
+                "summary": "Set maintenance mode",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Hero ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "New maintenance mode",
+                        "name": "maintenance",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.MaintenanceRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.SuccessResponse"
+                            "$ref": "#/definitions/main.MaintenanceStatus"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/main.ErrorResponse"
                         }
                     }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "main.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
                 },
-                "message": {
-                    "type": "string"
-                }
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
         },
-        "main.Hero": {
-            "type": "object",
-            "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "difficulty": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "role": {
-                    "type": "string"
+        "/api/admin/queries": {
+            "get": {
+                "description": "Every registered SQL query with invocation, failure and slow call counts and time spent, and those never executed",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Query inventory",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.QueryCoverageReport"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
                 },
-                "updated_at": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/admin/reset": {
+            "post": {
+                "description": "Empty the heroes table and every table keyed by a hero, then insert the starter roster (SEED_FILE or the built-in one) in the same transaction.\nWith seed=false the tables are only emptied. Only available when ENABLE_DEV_ENDPOINTS is set; otherwise 404.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reset heroes (development only)",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Insert the starter roster after emptying the tables (default true)",
+                        "name": "seed",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.ResetResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/main.ConfirmationRequiredResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/admin/sessions": {
+            "get": {
+                "description": "Number of currently valid tokens and their sessions, for spotting unusual activity.\nTokens are truncated to a prefix.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Active session summary",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.ActiveSessionsReport"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/admin/storage": {
+            "get": {
+                "description": "Row counts, disk size, oldest row age and retention for each managed table",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Storage report",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.TableStorageReport"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/admin/storage/prune": {
+            "post": {
+                "description": "Immediately delete rows past retention in every managed table",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Prune managed tables",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.PruneResult"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/difficulties": {
+            "get": {
+                "description": "The difficulty labels that have a score range with the number of heroes in each, in\nconfigured display order. Labels found on heroes without a range follow with \"canonical\": false.\nSupports If-None-Match.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "reference"
+                ],
+                "summary": "Get hero difficulties",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.ReferenceValue"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Hash of the response"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    }
+                }
+            }
+        },
+        "/api/heroes": {
+            "get": {
+                "description": "Retrieve all heroes from the database. When page or limit is given\nthe result is paginated and X-Total-Count/Link headers are set.\nNDJSON responses are streamed without those headers and bypass the list cache.\nlimit is capped at the configured maximum (100 by default). When exact counts are\ndisabled (PAGINATION_EXACT_COUNT=false), paginated responses omit X-Total-Count and the\nlast link, and next is only linked after a full page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Get all heroes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Heroes per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json, xml, or ndjson to stream one hero per line; overrides Accept",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admins only: 'all' also returns soft-deleted heroes",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only heroes created at or after this RFC 3339 timestamp or YYYY-MM-DD date",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only heroes created before this RFC 3339 timestamp or YYYY-MM-DD date",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only heroes released on or after this date",
+                        "name": "released_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only heroes released before this date",
+                        "name": "released_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum difficulty_score (1-10)",
+                        "name": "min_difficulty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum difficulty_score (1-10)",
+                        "name": "max_difficulty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "id (default), difficulty_score or -difficulty_score",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only the hero with exactly this name, ignoring case",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only heroes with this tag",
+                        "name": "tag",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Hero"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "RFC 5988 first/prev/next/last links"
+                            },
+                            "X-Cache": {
+                                "type": "string",
+                                "description": "HIT or MISS when the hero cache is enabled"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Total number of matching heroes, omitted on pages when exact counts are disabled"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new hero in the database",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Create a new hero",
+                "parameters": [
+                    {
+                        "description": "Hero data",
+                        "name": "hero",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.HeroCreateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key and body replay the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.CreatedHero"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the created hero"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "head": {
+                "description": "Retrieve all heroes from the database. When page or limit is given\nthe result is paginated and X-Total-Count/Link headers are set.\nNDJSON responses are streamed without those headers and bypass the list cache.\nlimit is capped at the configured maximum (100 by default). When exact counts are\ndisabled (PAGINATION_EXACT_COUNT=false), paginated responses omit X-Total-Count and the\nlast link, and next is only linked after a full page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Get all heroes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Heroes per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json, xml, or ndjson to stream one hero per line; overrides Accept",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admins only: 'all' also returns soft-deleted heroes",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only heroes created at or after this RFC 3339 timestamp or YYYY-MM-DD date",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only heroes created before this RFC 3339 timestamp or YYYY-MM-DD date",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only heroes released on or after this date",
+                        "name": "released_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only heroes released before this date",
+                        "name": "released_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum difficulty_score (1-10)",
+                        "name": "min_difficulty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum difficulty_score (1-10)",
+                        "name": "max_difficulty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "id (default), difficulty_score or -difficulty_score",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only the hero with exactly this name, ignoring case",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only heroes with this tag",
+                        "name": "tag",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Hero"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "RFC 5988 first/prev/next/last links"
+                            },
+                            "X-Cache": {
+                                "type": "string",
+                                "description": "HIT or MISS when the hero cache is enabled"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Total number of matching heroes, omitted on pages when exact counts are disabled"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/heroes/by-name/{name}": {
+            "put": {
+                "description": "Update the hero with this name, or create it if none exists",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Upsert hero by name",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Hero data",
+                        "name": "hero",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.HeroUpsertRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the created hero"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/heroes/draft": {
+            "get": {
+                "description": "A random composition with one hero for each of the first five roles in display_order\n(Tank, Fighter, Assassin, Mage and Marksman by default), in that order.\nRoles without an eligible hero are listed in unfilled_roles instead of failing.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Draft a team",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated banned hero IDs, e.g. 1,2,3",
+                        "name": "exclude",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only draft heroes with this difficulty",
+                        "name": "difficulty",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.HeroDraft"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/heroes/events": {
+            "get": {
+                "description": "Server-sent events stream of created, updated and deleted heroes. A hero the caller can't see, such as one just archived for an anonymous caller, arrives as a deleted event carrying only its id.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Stream hero changes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.HeroEvent"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/heroes/roles": {
+            "patch": {
+                "description": "Change the role of several heroes in one transaction, e.g. after a meta shift. Every role must be one of the\nroles in display_order (matched case-insensitively); any invalid entry rejects the whole batch with 422 and nothing is changed.\nIDs that match no hero are listed in not_found while the other heroes are still updated.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Reassign hero roles",
+                "parameters": [
+                    {
+                        "description": "New role per hero",
+                        "name": "assignments",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.HeroRoleAssignment"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.RoleReassignmentResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/main.ValidationErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/heroes/search": {
+            "get": {
+                "description": "Fuzzy search heroes by name or role, ordered by relevance score\nWithout exact counts (PAGINATION_EXACT_COUNT=false) X-Total-Count and the last link are omitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Search heroes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Results per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.HeroSearchResult"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "RFC 5988 first/prev/next/last links"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Total number of matching heroes, omitted when exact counts are disabled"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/heroes/trending": {
+            "get": {
+                "description": "Heroes with the most detail views over a recent window. Views are flushed\nevery 30 seconds, so the newest ones may not be counted yet.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Trending heroes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Period to count views over, e.g. 7d or 24h (default 7d)",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of heroes (default 10, at most the configured max page size, 100 by default)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.TrendingHero"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/heroes/{id}": {
+            "get": {
+                "description": "Retrieve a specific hero by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Get hero by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admins only: 'all' also returns soft-deleted heroes",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json or xml; overrides Accept",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        },
+                        "headers": {
+                            "X-Cache": {
+                                "type": "string",
+                                "description": "HIT or MISS when the hero cache is enabled"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update an existing hero by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Update hero by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Hero data",
+                        "name": "hero",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.HeroUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete an existing hero by ID. With DESTRUCTIVE_CONFIRMATION on, the first call returns 428\nwith a confirmation token that must be echoed in X-Confirmation-Token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Delete hero by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Confirmation token from the 428 response",
+                        "name": "X-Confirmation-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/main.ConfirmationRequiredResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "head": {
+                "description": "Retrieve a specific hero by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Get hero by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admins only: 'all' also returns soft-deleted heroes",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json or xml; overrides Accept",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        },
+                        "headers": {
+                            "X-Cache": {
+                                "type": "string",
+                                "description": "HIT or MISS when the hero cache is enabled"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Apply a JSON Merge Patch (RFC 7386) to a hero. Absent fields are kept and null clears\noptional fields. Changing difficulty without difficulty_score resets the score to the\nlabel's default. The patched hero is validated like a full update.",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Patch hero by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.HeroUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/main.ValidationErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/heroes/{id}/archive": {
+            "post": {
+                "description": "Hide a hero from anonymous callers; authenticated users still see it. Archiving an archived hero keeps its archived_at.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Archive hero",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/heroes/{id}/clone": {
+            "post": {
+                "description": "Create a copy of a hero named \"\u003cname\u003e (copy)\", or \"(copy 2)\", \"(copy 3)\", ... when taken",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Clone hero",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the hero to copy",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the created hero"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/heroes/{id}/counters": {
+            "get": {
+                "description": "The heroes that are strong against this hero, by name. Counters the caller can't see are left out.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "List hero counters",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Hero"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Record that another hero counters this one. A hero can't counter itself, and each pair is stored once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Add hero counter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "The countering hero",
+                        "name": "counter",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.HeroCounterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the hero's counters"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/heroes/{id}/counters/{counterId}": {
+            "delete": {
+                "description": "Remove a recorded counter of a hero",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Remove hero counter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the countering hero",
+                        "name": "counterId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/heroes/{id}/difficulty": {
+            "put": {
+                "description": "Change only the difficulty of a hero, e.g. after a rework. difficulty must be one of the known labels\n(Mudah, Sedang, Sulit) or a 1-10 score; difficulty_score is optional and must fall within the label's range.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Set hero difficulty",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New difficulty",
+                        "name": "difficulty",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.HeroDifficultyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/heroes/{id}/exists": {
+            "get": {
+                "description": "Report whether a hero with this ID exists and is visible to the caller, with 200 either way.\nA cheaper alternative to HEAD /api/heroes/{id} for clients that prefer a JSON answer.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Check hero existence",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admins only: 'all' also counts soft-deleted heroes",
+                        "name": "include",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.HeroExistence"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/heroes/{id}/favorite": {
+            "post": {
+                "description": "Add a hero to the caller's favorites. Favoriting a hero twice is a no-op.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Favorite hero",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Remove a hero from the caller's favorites. Removing a hero that isn't a favorite is a no-op.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Unfavorite hero",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/heroes/{id}/restore": {
+            "post": {
+                "description": "Clear deleted_at; a hero archived before it was deleted stays archived.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Restore hero",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/heroes/{id}/soft-delete": {
+            "post": {
+                "description": "Hide a hero from everyone except admins reading with ?include=all. Unlike DELETE /api/heroes/{id}\nthe row, its tags, counters and tier placements are kept and can be restored.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Soft-delete hero",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/heroes/{id}/tags/{tag}": {
+            "put": {
+                "description": "Attach a tag to a hero, creating the tag if it is new. Tags are lowercased; adding a tag the hero already has is a no-op.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Add hero tag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tag, e.g. meta or beginner-friendly",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Detach a tag from a hero. Removing a tag the hero doesn't have is a no-op.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Remove hero tag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tag",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/heroes/{id}/tier": {
+            "put": {
+                "description": "Place a hero in a tier for a balance patch, moving it out of the tier it had in that patch.\nTier names and tier sizes follow the validation.tier_lists rules in config.yaml.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "tiers"
+                ],
+                "summary": "Set hero tier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tier and patch",
+                        "name": "tier",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.TierAssignmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.TierAssignment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/main.ValidationErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/heroes/{id}/unarchive": {
+            "post": {
+                "description": "Clear archived_at so the hero is visible to everyone again.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "heroes"
+                ],
+                "summary": "Unarchive hero",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hero ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Hero"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/login": {
+            "post": {
+                "description": "Exchange a username and password for a bearer token valid for 24 hours.\nRepeated wrong passwords lock the account for a while (429 with Retry-After).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log in",
+                "parameters": [
+                    {
+                        "description": "Username and password",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logout": {
+            "post": {
+                "description": "Revoke the bearer token sent in the Authorization header. An unknown token is accepted and nothing is revoked.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log out",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/me/favorites": {
+            "get": {
+                "description": "Heroes the caller has favorited, most recently favorited first. Heroes the caller can no longer see are left out.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List own favorites",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Hero"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/me/password": {
+            "post": {
+                "description": "Verify the current password and set a new one. Every other session of the\ncaller is revoked; the token making the request stays valid.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Change own password",
+                "parameters": [
+                    {
+                        "description": "Current and new password",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.PasswordChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/roles": {
+            "get": {
+                "description": "The canonical roles from display_order in config.yaml with the number of heroes in each,\nin display order. Roles found on heroes but not configured follow with \"canonical\": false.\nSupports If-None-Match.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "reference"
+                ],
+                "summary": "Get hero roles",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.ReferenceValue"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Hash of the response"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    }
+                }
+            }
+        },
+        "/api/schema/hero": {
+            "get": {
+                "description": "JSON Schema (draft 2020-12) of Hero and HeroCreateRequest under $defs, with the configured roles and difficulties as examples",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reference"
+                ],
+                "summary": "Hero JSON Schema",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/sessions": {
+            "get": {
+                "description": "Active sessions with a truncated token, most recently used first",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "List active sessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.SessionInfo"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Revoke all sessions belonging to a user",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Revoke user sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "user",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.RevokedSessionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/sessions/{id}": {
+            "delete": {
+                "description": "Revoke one session by its ID; its token stops working immediately",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Revoke session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/tierlist": {
+            "get": {
+                "description": "Heroes grouped by tier for a balance patch. Without ?patch the most recently changed patch\nis returned. Every configured tier is present, empty tiers as an empty list. tier_order lists\nthe tiers in configured order, tiers no longer configured last; tiers follows the same order.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "tiers"
+                ],
+                "summary": "Tier list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Balance patch, e.g. 1.8.42",
+                        "name": "patch",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.TierList"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users": {
+            "get": {
+                "description": "List all API users; password hashes are never returned",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List users",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.UserAccount"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create an API user with a bcrypt-hashed password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create user",
+                "parameters": [
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.UserCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.UserAccount"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the created user"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/users/{id}": {
+            "put": {
+                "description": "Change a user's username and role. Existing tokens of the user are revoked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.UserUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.UserAccount"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a user and revoke their tokens. With DESTRUCTIVE_CONFIRMATION on, the first call returns 428\nwith a confirmation token that must be echoed in X-Confirmation-Token.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Confirmation token from the 428 response",
+                        "name": "X-Confirmation-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/main.ConfirmationRequiredResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/api/users/{id}/password": {
+            "post": {
+                "description": "Set a new password for a user. Existing tokens of the user are revoked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Reset user password",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New password",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.PasswordResetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/debug/vars": {
+            "get": {
+                "description": "Goroutines, heap usage, recent GC pauses and database pool statistics.\nOnly mounted when DEBUG_PPROF=true.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Runtime statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.RuntimeStats"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/health/live": {
+            "get": {
+                "description": "Reports that the process is running",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/ready": {
+            "get": {
+                "description": "Reports whether the database is reachable, its schema matches this build and the\ntoken cleanup has run within two of its 30 minute cycles. Maintenance mode is\nreported, but only makes the probe fail with ?maintenance=true.",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Report unavailable while maintenance mode is on",
+                        "name": "maintenance",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/main.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "description": "Version, git commit and build time of the running binary",
+                "produces": [
+                    "application/json",
+                    "application/xml"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Build information",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.VersionInfo"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "main.ActiveSessionsReport": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.SessionInfo"
+                    }
+                }
+            }
+        },
+        "main.AffectedRows": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "integer"
+            }
+        },
+        "main.CacheStats": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "entries": {
+                    "type": "integer"
+                },
+                "hit_rate": {
+                    "description": "HitRate is hits / (hits + misses), 0 before the first lookup",
+                    "type": "number"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "ttl_seconds": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.ConfirmationRequiredResponse": {
+            "type": "object",
+            "properties": {
+                "affected": {
+                    "$ref": "#/definitions/main.AffectedRows"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "confirmation_token": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "operation": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.CreatedHero": {
+            "type": "object",
+            "properties": {
+                "archived_at": {
+                    "description": "Lifecycle markers, only shown to callers who can see such heroes",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "type": "string"
+                },
+                "difficulty_score": {
+                    "description": "DifficultyScore is 1-10, null for labels without a known score",
+                    "type": "integer",
+                    "example": 8
+                },
+                "id": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "lane": {
+                    "type": "string",
+                    "example": "EXP Lane"
+                },
+                "lore": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "2016-07-14"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "specialty": {
+                    "type": "string",
+                    "example": "Charge/Burst"
+                },
+                "tags": {
+                    "description": "Tags are sorted by name",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "meta"
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.DBPoolStats": {
+            "type": "object",
+            "properties": {
+                "circuit": {
+                    "description": "Circuit is \"open\" while queries fail fast after repeated connection errors",
+                    "type": "string",
+                    "example": "closed"
+                },
+                "consecutive_failures": {
+                    "type": "integer"
+                },
+                "failure_threshold": {
+                    "type": "integer"
+                },
+                "idle": {
+                    "type": "integer"
+                },
+                "in_use": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_recovery_at": {
+                    "type": "string"
+                },
+                "last_recycle_at": {
+                    "type": "string"
+                },
+                "max_idle_closed": {
+                    "type": "integer"
+                },
+                "max_lifetime_closed": {
+                    "type": "integer"
+                },
+                "open_connections": {
+                    "type": "integer"
+                },
+                "pool": {
+                    "description": "Pool is \"primary\" or \"replica\"",
+                    "type": "string",
+                    "example": "primary"
+                },
+                "probe_failures": {
+                    "type": "integer"
+                },
+                "recoveries": {
+                    "type": "integer"
+                },
+                "recycles": {
+                    "type": "integer"
+                },
+                "recycling": {
+                    "type": "boolean"
+                },
+                "replica": {
+                    "description": "Replica is the read replica pool, when DB_REPLICA_DSN is set",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/main.DBPoolStats"
+                        }
+                    ]
+                },
+                "wait_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.DisplayOrder": {
+            "type": "object",
+            "properties": {
+                "difficulties": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.Hero": {
+            "type": "object",
+            "properties": {
+                "archived_at": {
+                    "description": "Lifecycle markers, only shown to callers who can see such heroes",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "type": "string"
+                },
+                "difficulty_score": {
+                    "description": "DifficultyScore is 1-10, null for labels without a known score",
+                    "type": "integer",
+                    "example": 8
+                },
+                "id": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "lane": {
+                    "type": "string",
+                    "example": "EXP Lane"
+                },
+                "lore": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "2016-07-14"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "specialty": {
+                    "type": "string",
+                    "example": "Charge/Burst"
+                },
+                "tags": {
+                    "description": "Tags are sorted by name",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "meta"
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.HeroCounter": {
+            "type": "object",
+            "properties": {
+                "countered_by_id": {
+                    "type": "string",
+                    "example": "2"
+                },
+                "hero_id": {
+                    "type": "string",
+                    "example": "1"
+                }
+            }
+        },
+        "main.HeroCounterRequest": {
+            "type": "object",
+            "required": [
+                "countered_by_id"
+            ],
+            "properties": {
+                "countered_by_id": {
+                    "type": "string",
+                    "example": "3"
+                }
+            }
+        },
+        "main.HeroCreateRequest": {
+            "type": "object",
+            "required": [
+                "difficulty",
+                "name",
+                "role"
+            ],
+            "properties": {
+                "difficulty": {
+                    "type": "string",
+                    "example": "Sulit"
+                },
+                "difficulty_score": {
+                    "description": "DifficultyScore is optional and must fall within the label's range",
+                    "type": "integer",
+                    "example": 8
+                },
+                "lane": {
+                    "type": "string",
+                    "example": "EXP Lane"
+                },
+                "lore": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "2016-07-14"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "specialty": {
+                    "type": "string",
+                    "example": "Charge/Burst"
+                },
+                "tags": {
+                    "description": "Tags are created as needed and attached to the new hero",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "meta"
+                    ]
+                }
+            }
+        },
+        "main.HeroDifficultyRequest": {
+            "type": "object",
+            "required": [
+                "difficulty"
+            ],
+            "properties": {
+                "difficulty": {
+                    "type": "string",
+                    "example": "Sulit"
+                },
+                "difficulty_score": {
+                    "description": "DifficultyScore is optional and must fall within the label's range",
+                    "type": "integer",
+                    "example": 8
+                }
+            }
+        },
+        "main.HeroDraft": {
+            "type": "object",
+            "properties": {
+                "heroes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Hero"
+                    }
+                },
+                "unfilled_roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.HeroEvent": {
+            "type": "object",
+            "properties": {
+                "hero": {
+                    "$ref": "#/definitions/main.Hero"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.HeroExistence": {
+            "type": "object",
+            "properties": {
+                "exists": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "main.HeroRoleAssignment": {
+            "type": "object",
+            "required": [
+                "id",
+                "role"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "Mage"
+                }
+            }
+        },
+        "main.HeroSearchResult": {
+            "type": "object",
+            "properties": {
+                "archived_at": {
+                    "description": "Lifecycle markers, only shown to callers who can see such heroes",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "type": "string"
+                },
+                "difficulty_score": {
+                    "description": "DifficultyScore is 1-10, null for labels without a known score",
+                    "type": "integer",
+                    "example": 8
+                },
+                "id": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "lane": {
+                    "type": "string",
+                    "example": "EXP Lane"
+                },
+                "lore": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "2016-07-14"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "specialty": {
+                    "type": "string",
+                    "example": "Charge/Burst"
+                },
+                "tags": {
+                    "description": "Tags are sorted by name",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "meta"
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.HeroUpdateRequest": {
+            "type": "object",
+            "required": [
+                "difficulty",
+                "name",
+                "role"
+            ],
+            "properties": {
+                "difficulty": {
+                    "type": "string",
+                    "example": "Sulit"
+                },
+                "difficulty_score": {
+                    "description": "DifficultyScore is optional and must fall within the label's range",
+                    "type": "integer",
+                    "example": 8
+                },
+                "lane": {
+                    "type": "string",
+                    "example": "EXP Lane"
+                },
+                "lore": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "2016-07-14"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "specialty": {
+                    "type": "string",
+                    "example": "Charge/Burst"
+                }
+            }
+        },
+        "main.HeroUpsertRequest": {
+            "type": "object",
+            "required": [
+                "difficulty",
+                "role"
+            ],
+            "properties": {
+                "difficulty": {
+                    "type": "string",
+                    "example": "Sulit"
+                },
+                "difficulty_score": {
+                    "description": "DifficultyScore is optional and must fall within the label's range",
+                    "type": "integer",
+                    "example": 8
+                },
+                "lane": {
+                    "type": "string",
+                    "example": "EXP Lane"
+                },
+                "lore": {
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "2016-07-14"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "specialty": {
+                    "type": "string",
+                    "example": "Charge/Burst"
+                }
+            }
+        },
+        "main.ImportCounts": {
+            "type": "object",
+            "properties": {
+                "inserted": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.ImportSummary": {
+            "type": "object",
+            "properties": {
+                "counters": {
+                    "$ref": "#/definitions/main.ImportCounts"
+                },
+                "heroes": {
+                    "$ref": "#/definitions/main.ImportCounts"
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "replace"
+                },
+                "tiers": {
+                    "$ref": "#/definitions/main.ImportCounts"
+                }
+            }
+        },
+        "main.LoginRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.MaintenanceRequest": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "message": {
+                    "description": "Message is returned with every rejected write, a default is used when empty",
+                    "type": "string",
+                    "example": "Database migration in progress"
+                },
+                "retry_after_seconds": {
+                    "description": "RetryAfterSeconds is sent as Retry-After, 60 when not given",
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "main.MaintenanceStatus": {
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "type": "string"
+                },
+                "changed_by": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "retry_after_seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.PasswordChangeRequest": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.PasswordResetRequest": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.PruneResult": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "ran_at": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "type": "boolean"
+                },
+                "table": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.QueryCoverageReport": {
+            "type": "object",
+            "properties": {
+                "coverage": {
+                    "type": "number"
+                },
+                "executed": {
+                    "type": "integer"
+                },
+                "queries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.QueryStats"
+                    }
+                },
+                "registered": {
+                    "type": "integer"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "unused": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.QueryStats": {
+            "type": "object",
+            "properties": {
+                "calls": {
+                    "type": "integer"
+                },
+                "failures": {
+                    "type": "integer"
+                },
+                "max_duration_ms": {
+                    "type": "number"
+                },
+                "name": {
                     "type": "string"
+                },
+                "slow_calls": {
+                    "description": "SlowCalls took at least DB_SLOW_QUERY_THRESHOLD",
+                    "type": "integer"
+                },
+                "total_duration_ms": {
+                    "type": "number"
                 }
             }
         },
-        "main.HeroCreateRequest": {
+        "main.ReferenceValue": {
+            "type": "object",
+            "properties": {
+                "canonical": {
+                    "description": "Canonical is false for values found on heroes that aren't configured",
+                    "type": "boolean"
+                },
+                "count": {
+                    "description": "Count is the number of heroes visible to the caller with this value",
+                    "type": "integer"
+                },
+                "order": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ResetResult": {
+            "type": "object",
+            "properties": {
+                "seeded": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "main.RevokedSessionsResponse": {
+            "type": "object",
+            "properties": {
+                "revoked": {
+                    "type": "integer"
+                },
+                "user": {
+                    "description": "User is empty when every user's sessions were revoked",
+                    "type": "string"
+                }
+            }
+        },
+        "main.RoleReassignmentResult": {
+            "type": "object",
+            "properties": {
+                "not_found": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "main.RuntimeStats": {
+            "type": "object",
+            "properties": {
+                "database": {
+                    "$ref": "#/definitions/main.DBPoolStats"
+                },
+                "gc_pause_total_ms": {
+                    "type": "number"
+                },
+                "goroutines": {
+                    "type": "integer"
+                },
+                "heap_alloc_bytes": {
+                    "type": "integer"
+                },
+                "heap_objects": {
+                    "type": "integer"
+                },
+                "heap_sys_bytes": {
+                    "type": "integer"
+                },
+                "num_gc": {
+                    "type": "integer"
+                },
+                "recent_gc_pauses_ms": {
+                    "description": "RecentGCPauses are the latest pauses in milliseconds, newest first",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "sessions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.SessionInfo": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "issued_at": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.Snapshot": {
+            "type": "object",
+            "properties": {
+                "counters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.HeroCounter"
+                    }
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "heroes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Hero"
+                    }
+                },
+                "schema_version": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "tiers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.TierPlacement"
+                    }
+                }
+            }
+        },
+        "main.SuccessResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.TableStorageReport": {
+            "type": "object",
+            "properties": {
+                "last_prune": {
+                    "$ref": "#/definitions/main.PruneResult"
+                },
+                "oldest_row_age_seconds": {
+                    "type": "integer"
+                },
+                "retention": {
+                    "type": "string"
+                },
+                "row_count": {
+                    "type": "integer"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                },
+                "table": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.TierAssignment": {
+            "type": "object",
+            "properties": {
+                "hero": {
+                    "$ref": "#/definitions/main.Hero"
+                },
+                "patch": {
+                    "type": "string"
+                },
+                "tier": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.TierAssignmentRequest": {
             "type": "object",
             "required": [
-                "difficulty",
-                "name",
-                "role"
+                "patch",
+                "tier"
             ],
             "properties": {
+                "patch": {
+                    "type": "string",
+                    "example": "1.8.42"
+                },
+                "tier": {
+                    "type": "string",
+                    "example": "S"
+                }
+            }
+        },
+        "main.TierList": {
+            "type": "object",
+            "properties": {
+                "patch": {
+                    "type": "string"
+                },
+                "tier_order": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "S",
+                        "A",
+                        "B",
+                        "C",
+                        "D"
+                    ]
+                },
+                "tiers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/main.Hero"
+                        }
+                    }
+                }
+            }
+        },
+        "main.TierPlacement": {
+            "type": "object",
+            "properties": {
+                "hero_id": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "patch": {
+                    "type": "string",
+                    "example": "1.8.20"
+                },
+                "tier": {
+                    "type": "string",
+                    "example": "S"
+                }
+            }
+        },
+        "main.TrendingHero": {
+            "type": "object",
+            "properties": {
+                "archived_at": {
+                    "description": "Lifecycle markers, only shown to callers who can see such heroes",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
                 "difficulty": {
                     "type": "string"
                 },
+                "difficulty_score": {
+                    "description": "DifficultyScore is 1-10, null for labels without a known score",
+                    "type": "integer",
+                    "example": 8
+                },
+                "id": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "lane": {
+                    "type": "string",
+                    "example": "EXP Lane"
+                },
+                "lore": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
+                "release_date": {
+                    "type": "string",
+                    "example": "2016-07-14"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "specialty": {
+                    "type": "string",
+                    "example": "Charge/Burst"
+                },
+                "tags": {
+                    "description": "Tags are sorted by name",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "meta"
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "views": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.UserAccount": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_login_at": {
+                    "type": "string"
+                },
                 "role": {
                     "type": "string"
+                },
+                "username": {
+                    "type": "string"
                 }
             }
         },
-        "main.HeroUpdateRequest": {
+        "main.UserCreateRequest": {
             "type": "object",
             "required": [
-                "difficulty",
-                "name",
-                "role"
+                "password",
+                "username"
             ],
             "properties": {
-                "difficulty": {
+                "password": {
                     "type": "string"
                 },
-                "name": {
+                "role": {
                     "type": "string"
                 },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.UserUpdateRequest": {
+            "type": "object",
+            "required": [
+                "role",
+                "username"
+            ],
+            "properties": {
                 "role": {
                     "type": "string"
+                },
+                "username": {
+                    "type": "string"
                 }
             }
         },
-        "main.SuccessResponse": {
+        "main.ValidationErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "violations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Violation"
+                    }
+                }
+            }
+        },
+        "main.VersionInfo": {
+            "type": "object",
+            "properties": {
+                "build_time": {
+                    "type": "string"
+                },
+                "commit": {
+                    "type": "string"
+                },
+                "go_version": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.Violation": {
             "type": "object",
             "properties": {
-                "data": {},
                 "message": {
                     "type": "string"
+                },
+                "path": {
+                    "type": "string"
                 }
             }
         }
@@ -326,7 +4362,7 @@ const docTemplate = `{
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0",
 	Host:             "localhost:8080",
-	BasePath:         "/api",
+	BasePath:         "/",
 	Schemes:          []string{},
 	Title:            "Mobile Legends Heroes API",
 	Description:      "REST API for managing Mobile Legends heroes with PostgreSQL database",
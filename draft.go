@@ -0,0 +1,75 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+)
+
+// Heroes in a drafted team, one per role
+const draftTeamSize = 5
+
+// draftRoles returns the roles a draft fills: the first draftTeamSize roles
+// of the active display order, so Tank, Fighter, Assassin, Mage and Marksman by default
+func draftRoles() []string {
+	roles := currentDisplayOrder().Roles
+	return roles[:min(draftTeamSize, len(roles))]
+}
+
+// Random hero of one role, filtered by visibility per request
+var queryDraftHero = registerBuiltQuery("heroes.draft")
+
+// GET /api/heroes/draft - Suggest a team
+// @Summary Draft a team
+// @Description A random composition with one hero for each of the first five roles in display_order
+// @Description (Tank, Fighter, Assassin, Mage and Marksman by default), in that order.
+// @Description Roles without an eligible hero are listed in unfilled_roles instead of failing.
+// @Tags heroes
+// @Produce json,application/xml
+// @Param exclude query string false "Comma separated banned hero IDs, e.g. 1,2,3"
+// @Param difficulty query string false "Only draft heroes with this difficulty"
+// @Success 200 {object} HeroDraft
+// @Failure 400 {object} ErrorResponse
+// @Router /api/heroes/draft [get]
+func getHeroDraft(w http.ResponseWriter, r *http.Request) {
+	var exclude []HeroID
+	if value := r.URL.Query().Get("exclude"); value != "" {
+		for _, raw := range strings.Split(value, ",") {
+			id, err := heroIDs.Parse(strings.TrimSpace(raw))
+			if err != nil {
+				respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, "exclude must be a comma separated list of hero IDs")
+				return
+			}
+			exclude = append(exclude, id)
+		}
+	}
+	difficulty := r.URL.Query().Get("difficulty")
+
+	draft := HeroDraft{Heroes: []Hero{}, UnfilledRoles: []string{}}
+	for _, role := range draftRoles() {
+		filter := &heroFilter{}
+		filter.add("role = $%d", role)
+		if difficulty != "" {
+			filter.add("difficulty = $%d", difficulty)
+		}
+		for _, id := range exclude {
+			filter.add("id <> $%d", id)
+		}
+		filter.restrictVisibility(r)
+
+		var hero Hero
+		err := queryDraftHero.WithContext(r.Context()).Build("SELECT " + heroColumns + " FROM heroes" + filter.where() + " ORDER BY random() LIMIT 1").
+			QueryRow(filter.args...).Scan(heroScanDest(&hero)...)
+		if err == sql.ErrNoRows {
+			draft.UnfilledRoles = append(draft.UnfilledRoles, role)
+			continue
+		}
+		if err != nil {
+			respondWithInternalError(w, r, err, "Failed to draft heroes")
+			return
+		}
+		draft.Heroes = append(draft.Heroes, hero)
+	}
+
+	respondWith(w, r, http.StatusOK, draft)
+}
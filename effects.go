@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Upper bound for a single background effect spawned by a request
+const effectTimeout = 30 * time.Second
+
+// detachedContext keeps the values of ctx (request ID, trace data) but drops its
+// cancellation and deadline, so work started by a request outlives the client.
+func detachedContext(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// runDetached runs a post-commit effect (webhooks, broadcasts, cache invalidation)
+// in its own goroutine. Effects must use the ctx they are given, never r.Context(),
+// and log through contextLogger(ctx) to keep the request ID.
+func runDetached(r *http.Request, name string, effect func(ctx context.Context)) {
+	ctx, cancel := context.WithTimeout(detachedContext(r.Context()), effectTimeout)
+
+	go func() {
+		defer cancel()
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				contextLogger(ctx).Error("Background effect panicked", "effect", name, "panic", recovered)
+			}
+		}()
+
+		effect(ctx)
+	}()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// startEffect runs effect detached from a request with ID requestID, then
+// cancels the request context as a disconnecting client would. The effect
+// waits for the returned release func before running.
+func startEffect(t *testing.T, requestID string, effect func(ctx context.Context)) (release func()) {
+	t.Helper()
+	gate := make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodPost, "/api/heroes", nil).WithContext(ctx)
+	r.Header.Set(requestIDHeader, requestID)
+	requestLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		runDetached(r, "test effect", func(ctx context.Context) {
+			<-gate
+			effect(ctx)
+		})
+	})).ServeHTTP(httptest.NewRecorder(), r)
+	cancel()
+
+	return func() { close(gate) }
+}
+
+// findLog returns the first record with message msg, waiting up to a second for it
+func findLog(t *testing.T, logs *syncBuffer, msg string) map[string]interface{} {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		for _, record := range logs.records(t) {
+			if record["msg"] == msg {
+				return record
+			}
+		}
+	}
+	t.Fatalf("no %q log entry in %v", msg, logs.records(t))
+	return nil
+}
+
+func TestRunDetachedOutlivesTheRequest(t *testing.T) {
+	logs := captureLogs(t)
+
+	type observed struct {
+		err         error
+		hasDeadline bool
+	}
+	done := make(chan observed, 1)
+	release := startEffect(t, "req-detached", func(ctx context.Context) {
+		contextLogger(ctx).Info("effect ran")
+		_, hasDeadline := ctx.Deadline()
+		done <- observed{ctx.Err(), hasDeadline}
+	})
+	release()
+
+	select {
+	case got := <-done:
+		if got.err != nil {
+			t.Errorf("effect ctx.Err() = %v after the request was cancelled, want nil", got.err)
+		}
+		if !got.hasDeadline {
+			t.Error("effect ctx has no deadline, want effectTimeout")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("effect did not run")
+	}
+
+	if got := findLog(t, logs, "effect ran")["request_id"]; got != "req-detached" {
+		t.Errorf("effect log request_id = %v, want req-detached", got)
+	}
+}
+
+func TestRunDetachedLogsPanicsWithRequestID(t *testing.T) {
+	logs := captureLogs(t)
+
+	release := startEffect(t, "req-panic", func(ctx context.Context) {
+		panic("boom")
+	})
+	release()
+
+	record := findLog(t, logs, "Background effect panicked")
+	for key, want := range map[string]interface{}{
+		"level":      "ERROR",
+		"request_id": "req-panic",
+		"effect":     "test effect",
+		"panic":      "boom",
+	} {
+		if record[key] != want {
+			t.Errorf("log %s = %v, want %v", key, record[key], want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+// Machine-readable error codes returned in ErrorResponse.Code
+const (
+	ErrCodeInvalidPayload       = "INVALID_PAYLOAD"
+	ErrCodeBodyRequired         = "BODY_REQUIRED"
+	ErrCodeValidationFailed     = "VALIDATION_FAILED"
+	ErrCodeInvalidQuery         = "INVALID_QUERY"
+	ErrCodeInvalidHeroID        = "INVALID_HERO_ID"
+	ErrCodeInvalidRole          = "INVALID_ROLE"
+	ErrCodeInvalidDifficulty    = "INVALID_DIFFICULTY"
+	ErrCodeHeroNotFound         = "HERO_NOT_FOUND"
+	ErrCodeHeroNameTaken        = "HERO_NAME_TAKEN"
+	ErrCodeUnauthorized         = "UNAUTHORIZED"
+	ErrCodeInvalidToken         = "INVALID_TOKEN"
+	ErrCodeInvalidCredentials   = "INVALID_CREDENTIALS"
+	ErrCodeAccountLocked        = "ACCOUNT_LOCKED"
+	ErrCodeSessionNotFound      = "SESSION_NOT_FOUND"
+	ErrCodeInvalidUserID        = "INVALID_USER_ID"
+	ErrCodeUserNotFound         = "USER_NOT_FOUND"
+	ErrCodeUsernameTaken        = "USERNAME_TAKEN"
+	ErrCodeForbidden            = "FORBIDDEN"
+	ErrCodeConfirmationRequired = "CONFIRMATION_REQUIRED"
+	ErrCodeInvalidConfirmation  = "INVALID_CONFIRMATION"
+	ErrCodeRateLimited          = "RATE_LIMITED"
+	ErrCodeServiceUnavailable   = "SERVICE_UNAVAILABLE"
+	ErrCodeInternal             = "INTERNAL_ERROR"
+	ErrCodeNotAcceptable        = "NOT_ACCEPTABLE"
+	ErrCodeIdempotencyConflict  = "IDEMPOTENCY_CONFLICT"
+	ErrCodeUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
+	ErrCodeMethodNotAllowed     = "METHOD_NOT_ALLOWED"
+	ErrCodeNotFound             = "NOT_FOUND"
+	ErrCodeConflict             = "CONFLICT"
+	ErrCodeValueTooLong         = "VALUE_TOO_LONG"
+	ErrCodeMaintenance          = "MAINTENANCE"
+	ErrCodePayloadTooLarge      = "PAYLOAD_TOO_LARGE"
+	ErrCodeRequestTimeout       = "REQUEST_TIMEOUT"
+)
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// respondWithETag is respondWith for cacheable reads. The ETag hashes the
+// payload as the caller would see it, so it changes whenever the data, the
+// format or the caller's audience does, and a matching If-None-Match gets a
+// 304 without a body.
+func respondWithETag(w http.ResponseWriter, r *http.Request, payload interface{}) {
+	data, err := json.Marshal(redact(payload, audienceFor(r)))
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	variant := negotiateFormat(r) + " pretty=" + strconv.FormatBool(wantsPretty(r)) + "\n"
+	sum := sha256.Sum256(append([]byte(variant), data...))
+	etag := fmt.Sprintf(`"%x"`, sum[:16])
+
+	w.Header().Set("ETag", etag)
+	w.Header().Add("Vary", "Accept")
+	w.Header().Add("Vary", "Authorization")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	respondWith(w, r, http.StatusOK, payload)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using the
+// weak comparison RFC 9110 prescribes for If-None-Match
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
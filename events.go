@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Hero change event types
+const (
+	eventCreated = "created"
+	eventUpdated = "updated"
+	eventDeleted = "deleted"
+)
+
+// Interval between SSE heartbeat comments that keep proxies from closing idle streams
+const sseHeartbeatInterval = 30 * time.Second
+
+// EventsConfig holds hero event stream settings
+type EventsConfig struct {
+	// Audience caps the fields sent to stream subscribers, whatever their role
+	Audience string `yaml:"audience"`
+}
+
+// eventAudience returns the audience of the event stream for the caller:
+// theirs, but at most the configured EVENTS_AUDIENCE
+func eventAudience(r *http.Request) audience {
+	limit, err := parseAudience(config.Events.Audience)
+	if err != nil {
+		return audiencePublic
+	}
+	return min(audienceFor(r), limit)
+}
+
+// HeroEvent is a change notification sent to event stream subscribers
+type HeroEvent struct {
+	Type string `json:"type"`
+	Hero Hero   `json:"hero"`
+}
+
+// heroTombstone is the body of an event for a hero the subscriber can't see,
+// carrying nothing but which hero is gone for them
+type heroTombstone struct {
+	ID HeroID `json:"id"`
+}
+
+// eventBroker fans out hero events to connected subscribers
+type eventBroker struct {
+	mu          sync.RWMutex
+	subscribers map[chan HeroEvent]struct{}
+}
+
+// In-process broker feeding the SSE endpoint, from hero_changes notifications
+// or directly from the mutation handlers
+var heroEvents = &eventBroker{subscribers: make(map[chan HeroEvent]struct{})}
+
+// subscribe registers a new subscriber channel
+func (b *eventBroker) subscribe() chan HeroEvent {
+	ch := make(chan HeroEvent, 16)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+// unsubscribe removes and closes a subscriber channel
+func (b *eventBroker) unsubscribe(ch chan HeroEvent) {
+	b.mu.Lock()
+	delete(b.subscribers, ch)
+	b.mu.Unlock()
+	close(ch)
+}
+
+// publish delivers event to every subscriber, dropping it for any that is not keeping up
+func (b *eventBroker) publish(event HeroEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			slog.Warn("Dropping event, subscriber is not keeping up", "event", event.Type, "hero_id", event.Hero.ID)
+		}
+	}
+}
+
+// publishHeroEvent broadcasts a hero change after the request has committed it,
+// to every instance when the hero_changes listener is running
+func publishHeroEvent(r *http.Request, eventType string, hero Hero) {
+	runDetached(r, "hero event broadcast", func(ctx context.Context) {
+		event := HeroEvent{Type: eventType, Hero: hero}
+		if heroEventsShared.Load() {
+			err := notifyHeroEvent(ctx, event)
+			if err == nil {
+				return
+			}
+			contextLogger(ctx).Error("Failed to notify hero change, delivering it on this instance only", "event", eventType, "hero_id", hero.ID, "error", err)
+		}
+		heroEvents.publish(event)
+	})
+}
+
+// GET /api/heroes/events - Stream hero changes
+// @Summary Stream hero changes
+// @Description Server-sent events stream of created, updated and deleted heroes. A hero the caller can't see, such as one just archived for an anonymous caller, arrives as a deleted event carrying only its id.
+// @Tags heroes
+// @Produce text/event-stream
+// @Success 200 {object} HeroEvent
+// @Router /api/heroes/events [get]
+func streamHeroEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Streaming not supported")
+		return
+	}
+
+	// The stream outlives SERVER_WRITE_TIMEOUT; heartbeats detect dead clients
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	level := eventAudience(r)
+	visibility := visibilityFor(r)
+	events := heroEvents.subscribe()
+	defer heroEvents.unsubscribe(events)
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event := <-events:
+			// A hero archived or soft-deleted out of this caller's sight is gone
+			// for them, and its changes must not leak
+			var body interface{} = redact(event.Hero, level)
+			if !visibility.allows(event.Hero) {
+				event.Type = eventDeleted
+				body = heroTombstone{ID: event.Hero.ID}
+			}
+			data, err := json.Marshal(body)
+			if err != nil {
+				requestLogger(r).Error("Failed to encode event", "event", event.Type, "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Queries on favorites; the favorites list is filtered by visibility per request
+var (
+	queryAddFavorite = registerQuery("favorites.add", `INSERT INTO favorites (username, hero_id) VALUES ($1, $2)
+		ON CONFLICT (username, hero_id) DO NOTHING`, paramText, paramHeroID)
+	queryRemoveFavorite    = registerQuery("favorites.remove", "DELETE FROM favorites WHERE username = $1 AND hero_id = $2", paramText, paramHeroID)
+	queryListFavorites     = registerBuiltQuery("favorites.list")
+	queryUserFavoriteCount = registerQuery("favorites.count_for_user", `SELECT COUNT(*) FROM favorites f
+		JOIN users u ON u.username = f.username WHERE u.id = $1`, paramInt)
+)
+
+// POST /api/heroes/{id}/favorite - Favorite a hero
+// @Summary Favorite hero
+// @Description Add a hero to the caller's favorites. Favoriting a hero twice is a no-op.
+// @Tags heroes
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/{id}/favorite [post]
+func addFavorite(w http.ResponseWriter, r *http.Request) {
+	id, err := heroIDs.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
+		return
+	}
+
+	filter := &heroFilter{}
+	filter.add("id = $%d", id)
+	filter.restrictVisibility(r)
+
+	var hero Hero
+	err = queryGetHero.WithContext(r.Context()).Build("SELECT " + heroColumns + " FROM heroes" + filter.where()).QueryRow(filter.args...).
+		Scan(heroScanDest(&hero)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, r, http.StatusNotFound, ErrCodeHeroNotFound, "Hero not found")
+		} else {
+			respondWithInternalError(w, r, err, "Failed to fetch hero")
+		}
+		return
+	}
+
+	session, _ := sessionFromRequest(r)
+	if _, err := queryAddFavorite.WithContext(r.Context()).Exec(session.Username, hero.ID); err != nil {
+		respondWithInternalError(w, r, err, "Failed to favorite hero")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /api/heroes/{id}/favorite - Unfavorite a hero
+// @Summary Unfavorite hero
+// @Description Remove a hero from the caller's favorites. Removing a hero that isn't a favorite is a no-op.
+// @Tags heroes
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/{id}/favorite [delete]
+func removeFavorite(w http.ResponseWriter, r *http.Request) {
+	id, err := heroIDs.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
+		return
+	}
+
+	session, _ := sessionFromRequest(r)
+	if _, err := queryRemoveFavorite.WithContext(r.Context()).Exec(session.Username, id); err != nil {
+		respondWithInternalError(w, r, err, "Failed to unfavorite hero")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/me/favorites - The caller's favorite heroes
+// @Summary List own favorites
+// @Description Heroes the caller has favorited, most recently favorited first. Heroes the caller can no longer see are left out.
+// @Tags users
+// @Produce json,application/xml
+// @Success 200 {array} Hero
+// @Failure 401 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/me/favorites [get]
+func getFavorites(w http.ResponseWriter, r *http.Request) {
+	session, _ := sessionFromRequest(r)
+
+	filter := &heroFilter{}
+	filter.add("f.username = $%d", session.Username)
+	filter.restrictVisibility(r)
+
+	query := "SELECT " + heroColumns + " FROM heroes JOIN favorites f ON f.hero_id = heroes.id::text" +
+		filter.where() + " ORDER BY f.favorited_at DESC, heroes.id"
+
+	rows, err := queryListFavorites.WithContext(r.Context()).Build(query).Query(filter.args...)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to fetch favorites")
+		return
+	}
+	defer rows.Close()
+
+	heroes := []Hero{}
+	for rows.Next() {
+		var hero Hero
+		if err := rows.Scan(heroScanDest(&hero)...); err != nil {
+			respondWithInternalError(w, r, err, "Failed to scan hero data")
+			return
+		}
+		heroes = append(heroes, hero)
+	}
+
+	if err := rows.Err(); err != nil {
+		respondWithInternalError(w, r, err, "Error iterating heroes")
+		return
+	}
+
+	respondWith(w, r, http.StatusOK, heroes)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Date-only layout accepted by date range filters
+const dateLayout = "2006-01-02"
+
+// parseDate accepts an RFC 3339 timestamp or a YYYY-MM-DD date (midnight UTC)
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(dateLayout, value)
+}
+
+// addDateRange limits column to [afterParam, beforeParam) from the query string.
+// Either bound may be omitted; an unparseable date or an empty range is an error.
+func (f *heroFilter) addDateRange(r *http.Request, column, afterParam, beforeParam string) error {
+	query := r.URL.Query()
+
+	var after, before time.Time
+	for _, bound := range []struct {
+		param string
+		value *time.Time
+	}{
+		{afterParam, &after},
+		{beforeParam, &before},
+	} {
+		raw := query.Get(bound.param)
+		if raw == "" {
+			continue
+		}
+		parsed, err := parseDate(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be an RFC 3339 timestamp or YYYY-MM-DD date", bound.param)
+		}
+		*bound.value = parsed
+	}
+
+	if !after.IsZero() && !before.IsZero() && after.After(before) {
+		return fmt.Errorf("%s must not be later than %s", afterParam, beforeParam)
+	}
+
+	if !after.IsZero() {
+		f.add(column+" >= $%d", after)
+	}
+	if !before.IsZero() {
+		f.add(column+" < $%d", before)
+	}
+	return nil
+}
+
+// addDifficultyRange limits difficulty_score to [min_difficulty, max_difficulty]
+func (f *heroFilter) addDifficultyRange(r *http.Request) error {
+	query := r.URL.Query()
+
+	bounds := map[string]int{}
+	for _, param := range []string{"min_difficulty", "max_difficulty"} {
+		raw := query.Get(param)
+		if raw == "" {
+			continue
+		}
+		score, err := strconv.Atoi(raw)
+		if err != nil || score < minDifficultyScore || score > maxDifficultyScore {
+			return fmt.Errorf("%s must be a number between %d and %d", param, minDifficultyScore, maxDifficultyScore)
+		}
+		bounds[param] = score
+	}
+
+	low, hasLow := bounds["min_difficulty"]
+	high, hasHigh := bounds["max_difficulty"]
+	if hasLow && hasHigh && low > high {
+		return fmt.Errorf("min_difficulty must not be greater than max_difficulty")
+	}
+
+	if hasLow {
+		f.add("difficulty_score >= $%d", low)
+	}
+	if hasHigh {
+		f.add("difficulty_score <= $%d", high)
+	}
+	return nil
+}
+
+// ORDER BY clauses accepted by ?sort= on the heroes list; a leading "-" sorts descending
+var heroSortOrders = map[string]string{
+	"id":                " ORDER BY id",
+	"difficulty_score":  " ORDER BY difficulty_score ASC NULLS LAST, id",
+	"-difficulty_score": " ORDER BY difficulty_score DESC NULLS LAST, id",
+}
+
+// heroOrder returns the ORDER BY clause for ?sort=, by id when absent
+func heroOrder(r *http.Request) (string, error) {
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = "id"
+	}
+
+	order, ok := heroSortOrders[sort]
+	if !ok {
+		return "", fmt.Errorf("sort must be id, difficulty_score or -difficulty_score")
+	}
+	return order, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// assertGolden compares got with testdata/name, or rewrites it under -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v (run go test -run %s -update to create it)", path, err, t.Name())
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the response:\ngot\n%s\nwant\n%s", path, got, want)
+	}
+}
+
+func TestGoldenResponses(t *testing.T) {
+	useTestConfig(t)
+	captureLogs(t)
+
+	score := 8
+	specialty, lane := "Charge/Burst", "EXP Lane"
+	created := time.Date(2016, 7, 14, 2, 0, 0, 0, time.UTC)
+	archived := created.Add(24 * time.Hour)
+	hero := Hero{
+		ID:              "1",
+		Name:            "Alucard",
+		Role:            "Fighter",
+		Difficulty:      "Medium",
+		DifficultyScore: &score,
+		CreatedAt:       created,
+		UpdatedAt:       created.Add(time.Hour),
+		Tags:            []string{"meta", "sustain"},
+		HeroDetails: HeroDetails{
+			Specialty:   &specialty,
+			Lane:        &lane,
+			ReleaseDate: &Date{created.Truncate(24 * time.Hour)},
+		},
+		// Stripped for anonymous callers
+		ArchivedAt: &archived,
+	}
+
+	tests := []struct {
+		name           string
+		golden         string
+		target         string
+		acceptLanguage string
+		respond        func(w http.ResponseWriter, r *http.Request)
+	}{
+		{
+			name:   "hero",
+			golden: "hero.golden.json",
+			target: "/api/heroes/1?pretty=true",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				respondWith(w, r, http.StatusOK, hero)
+			},
+		},
+		{
+			name:           "error",
+			golden:         "error.golden.json",
+			target:         "/api/heroes?pretty=true",
+			acceptLanguage: "id",
+			respond: func(w http.ResponseWriter, r *http.Request) {
+				respondWithHeroWriteError(w, r, errNotNullColumn, "Failed to create hero")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The same payload must serialize identically on every call
+			var first []byte
+			for i := 0; i < 5; i++ {
+				r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+				if tt.acceptLanguage != "" {
+					r.Header.Set("Accept-Language", tt.acceptLanguage)
+				}
+				rec := httptest.NewRecorder()
+				tt.respond(rec, r)
+
+				if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+					t.Fatalf("Content-Type = %q, want application/json", contentType)
+				}
+				if first == nil {
+					first = rec.Body.Bytes()
+				} else if !bytes.Equal(rec.Body.Bytes(), first) {
+					t.Fatalf("response %d differs from the first:\n%s\n%s", i, rec.Body.Bytes(), first)
+				}
+			}
+			assertGolden(t, tt.golden, first)
+		})
+	}
+}
@@ -1,33 +1,72 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
-	"io/ioutil"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"gopkg.in/yaml.v3"
+)
+
+// Queries on the heroes table; filtered queries are assembled per request
+var (
+	queryListHeroes         = registerBuiltQuery("heroes.list")
+	queryCountHeroes        = registerBuiltQuery("heroes.count")
+	querySearchHeroes       = registerBuiltQuery("heroes.search")
+	queryCountSearchHeroes  = registerBuiltQuery("heroes.search_count")
+	queryGetHero            = registerBuiltQuery("heroes.get")
+	queryCheckHero          = registerBuiltQuery("heroes.check")
+	queryCreateHero         = registerBuiltQuery("heroes.create")
+	queryUpsertHero         = registerBuiltQuery("heroes.upsert")
+	queryCountHeroesByValue = registerBuiltQuery("heroes.count_by_value")
+	queryUpdateHero         = registerQuery("heroes.update", `UPDATE heroes SET name = $1, role = $2, difficulty = $3, difficulty_score = $4,
+		lore = $5, specialty = $6, lane = $7, release_date = $8 WHERE id = $9 RETURNING `+heroColumns,
+		paramText, paramText, paramText, paramNullInt, paramNullText, paramNullText, paramNullText, paramDate, paramHeroID)
+	queryDeleteHero = registerQuery("heroes.delete", "DELETE FROM heroes WHERE id = $1 RETURNING "+heroColumns, paramHeroID)
 )
 
 // Authentication
 var (
-	validTokens = make(map[string]time.Time)
+	validTokens = make(map[string]Session)
 	tokenMutex  sync.RWMutex
-	config      Config
 )
 
+// Roles a user can have
+const (
+	roleUser  = "user"
+	roleAdmin = "admin"
+)
+
+// contextKey is the type for values this package stores in request contexts
+type contextKey string
+
+// Context key for the authenticated session
+const sessionContextKey contextKey = "session"
+
+// Get the session stored by authMiddleware, if the request is authenticated
+func sessionFromRequest(r *http.Request) (Session, bool) {
+	session, ok := r.Context().Value(sessionContextKey).(Session)
+	return session, ok
+}
+
 // CORS middleware to add CORS headers
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+requestIDHeader+", "+confirmationHeader)
+		w.Header().Set("Access-Control-Expose-Headers", "Location, X-Total-Count, Link, X-Cache, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, "+requestIDHeader)
 
 		// Handle preflight OPTIONS request
 		if r.Method == "OPTIONS" {
@@ -42,171 +81,630 @@ func corsMiddleware(next http.Handler) http.Handler {
 // Authentication middleware
 func authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Protected responses depend on the caller and must never be cached
+		w.Header().Set("Cache-Control", "no-store")
+
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			respondWithError(w, http.StatusUnauthorized, "Authorization header required")
+			respondWithError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authorization header required")
 			return
 		}
 
 		// Check if header starts with "Bearer "
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			respondWithError(w, http.StatusUnauthorized, "Invalid authorization format")
+			respondWithError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Invalid authorization format")
 			return
 		}
 
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 		if token == "" {
-			respondWithError(w, http.StatusUnauthorized, "Token required")
+			respondWithError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Token required")
 			return
 		}
 
 		// Check if token is valid
-		tokenMutex.RLock()
-		_, exists := validTokens[token]
-		tokenMutex.RUnlock()
-
+		session, exists := touchSession(token)
 		if !exists {
-			respondWithError(w, http.StatusUnauthorized, "Invalid or expired token")
+			respondWithError(w, r, http.StatusUnauthorized, ErrCodeInvalidToken, "Invalid or expired token")
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		// Fail closed when the revocation list can't be read
+		revoked, err := tokenRevoked(r.Context(), session.ID)
+		if err != nil {
+			respondWithInternalError(w, r, err, "Failed to check token revocation")
+			return
+		}
+		if revoked {
+			respondWithError(w, r, http.StatusUnauthorized, ErrCodeInvalidToken, "Invalid or expired token")
+			return
+		}
+
+		if entry := requestLogFrom(r.Context()); entry != nil {
+			entry.User = session.Username
+		}
+
+		ctx := context.WithValue(r.Context(), sessionContextKey, session)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// JSON response helper
-func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
-	response, err := json.Marshal(payload)
+// Look up the unexpired session for a bearer token
+func lookupSession(token string) (Session, bool) {
+	if token == "" {
+		return Session{}, false
+	}
+
+	tokenMutex.RLock()
+	session, exists := validTokens[token]
+	tokenMutex.RUnlock()
+
+	if !exists || time.Now().After(session.ExpiresAt) {
+		return Session{}, false
+	}
+	return session, true
+}
+
+// Admin middleware, authenticating the request and requiring the admin role
+func adminMiddleware(next http.Handler) http.Handler {
+	return authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, _ := sessionFromRequest(r)
+		if session.Role != roleAdmin {
+			respondWithError(w, r, http.StatusForbidden, ErrCodeForbidden, "Admin role required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// JSON response helper. Map keys are always emitted in sorted order by
+// encoding/json, so map-shaped payloads are deterministic across calls.
+func respondWithJSON(w http.ResponseWriter, r *http.Request, code int, payload interface{}) {
+	response, err := marshalJSON(r, payload)
 	if err != nil {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
+	writeBody(w, r, code, "application/json", response)
+}
+
+// Negotiated response helper, writing XML when the client accepts it and JSON otherwise.
+// Fields above the caller's audience are stripped first.
+func respondWith(w http.ResponseWriter, r *http.Request, code int, payload interface{}) {
+	payload = redact(payload, audienceFor(r))
+
+	if negotiateFormat(r) != formatXML {
+		respondWithJSON(w, r, code, payload)
+		return
+	}
+
+	response, err := marshalXML(r, payload)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	writeBody(w, r, code, "application/xml", response)
+}
+
+// Write a serialized response with its Content-Length. HEAD requests get the
+// same headers as GET but no body.
+func writeBody(w http.ResponseWriter, r *http.Request, code int, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
 	w.WriteHeader(code)
-	w.Write(response)
+	if r != nil && r.Method == http.MethodHead {
+		return
+	}
+	w.Write(body)
 }
 
-// Error response helper
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, ErrorResponse{Error: message})
+// Error response helper, localized from Accept-Language
+func respondWithError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	message, detail, language := localizeError(r, code, message)
+	w.Header().Set("Content-Language", language)
+	w.Header().Add("Vary", "Accept-Language")
+	respondWith(w, r, status, ErrorResponse{Code: code, Error: message, Message: detail})
 }
 
-// Load configuration from config.yaml
-func loadConfig() error {
-	data, err := ioutil.ReadFile("config.yaml")
-	if err != nil {
-		return err
+// respondWithInternalError reports a failed call. The error is logged with the
+// request context but never sent, since it may reveal schema details; the
+// client gets message. Constraint violations caused by the request map to a
+// 4xx, and connection errors, including those from an open circuit, return
+// 503 with Retry-After so clients back off briefly instead of seeing a 500.
+func respondWithInternalError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	// The query was cancelled with the request: the handler timeout passed or the client left
+	if ctxErr := r.Context().Err(); ctxErr != nil {
+		if errors.Is(ctxErr, context.DeadlineExceeded) {
+			requestLogger(r).Warn(message, "method", r.Method, "path", r.URL.Path, "status", http.StatusGatewayTimeout, "error", err)
+			respondWithError(w, r, http.StatusGatewayTimeout, ErrCodeRequestTimeout, "Request did not complete in time")
+		} else {
+			requestLogger(r).Info(message+", client disconnected", "method", r.Method, "path", r.URL.Path, "error", err)
+		}
+		return
 	}
 
-	err = yaml.Unmarshal(data, &config)
-	if err != nil {
-		return err
+	if status, code, clientMessage, ok := constraintViolation(err); ok {
+		requestLogger(r).Warn(message, "method", r.Method, "path", r.URL.Path, "status", status, "error", err)
+		respondWithError(w, r, status, code, clientMessage)
+		return
+	}
+
+	requestLogger(r).Error(message, "method", r.Method, "path", r.URL.Path, "error", err)
+	if isConnectionError(err) {
+		w.Header().Set("Retry-After", dbPool.retryAfter())
+		respondWithError(w, r, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "Database temporarily unavailable")
+		return
+	}
+	respondWithError(w, r, http.StatusInternalServerError, ErrCodeInternal, message)
+}
+
+// decodeJSONBody decodes the request body into dst, responding with a 400 and
+// returning false when it is missing or malformed
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(dst)
+	if err == nil {
+		// Anything after the first value, even another valid one, is rejected
+		var extra json.RawMessage
+		if err = decoder.Decode(&extra); errors.Is(err, io.EOF) {
+			return true
+		}
+		if err == nil {
+			err = errors.New("trailing data after JSON value")
+		}
+	} else if errors.Is(err, io.EOF) {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeBodyRequired, "Request body is required")
+		return false
+	}
+
+	if !respondWithBodyTooLarge(w, r, err) {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidPayload, "Invalid request payload")
+	}
+	return false
+}
+
+// bodyLimitMiddleware caps request bodies at limit bytes, or at the limit in
+// overrides for the request path; reads past it fail with *http.MaxBytesError,
+// which handlers report as a 413. A limit of 0 leaves bodies unbounded.
+func bodyLimitMiddleware(limit int64, overrides map[string]int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := limit
+			if override, ok := overrides[r.URL.Path]; ok {
+				limit = override
+			}
+			if limit > 0 && r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// respondWithBodyTooLarge sends a 413 when err came from reading past the body
+// limit, reporting whether it did
+func respondWithBodyTooLarge(w http.ResponseWriter, r *http.Request, err error) bool {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	respondWithError(w, r, http.StatusRequestEntityTooLarge, ErrCodePayloadTooLarge,
+		fmt.Sprintf("Request body must not exceed %d bytes", tooLarge.Limit))
+	return true
+}
+
+// Marshal payload, indenting the output when the client asked for ?pretty=true
+func marshalJSON(r *http.Request, payload interface{}) ([]byte, error) {
+	if wantsPretty(r) {
+		return json.MarshalIndent(payload, "", "  ")
 	}
+	return json.Marshal(payload)
+}
+
+// Check whether the request asks for indented JSON output
+func wantsPretty(r *http.Request) bool {
+	if r == nil {
+		return false
+	}
+	pretty, err := strconv.ParseBool(r.URL.Query().Get("pretty"))
+	return err == nil && pretty
+}
 
-	return nil
+// Escape LIKE wildcards so user input is matched literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
 }
 
+// How often cleanExpiredTokens runs
+const tokenCleanupInterval = 30 * time.Minute
+
+// Unix nanoseconds of the last cleanExpiredTokens cycle, or of its start
+var lastTokenCleanup atomic.Int64
+
 // Clean expired tokens (run in background)
 func cleanExpiredTokens() {
+	lastTokenCleanup.Store(time.Now().UnixNano())
 	for {
-		time.Sleep(30 * time.Minute) // Clean every 30 minutes
+		time.Sleep(tokenCleanupInterval)
+		started := time.Now()
+		expired := 0
 		tokenMutex.Lock()
-		now := time.Now()
-		for token, expiry := range validTokens {
-			if now.After(expiry) {
+		for token, session := range validTokens {
+			if started.After(session.ExpiresAt) {
 				delete(validTokens, token)
+				expired++
 			}
 		}
 		tokenMutex.Unlock()
+
+		purgeRevokedTokens()
+		pruneLockouts()
+		var pruned int64
+		for _, result := range pruneManagedTables() {
+			pruned += result.Deleted
+		}
+		lastTokenCleanup.Store(time.Now().UnixNano())
+
+		slog.Info("Token cleanup finished", "expired_sessions", expired, "pruned_rows", pruned,
+			"duration_ms", durationMs(time.Since(started)))
 	}
 }
 
 // POST /api/login - Login endpoint
+// @Summary Log in
+// @Description Exchange a username and password for a bearer token valid for 24 hours.
+// @Description Repeated wrong passwords lock the account for a while (429 with Retry-After).
+// @Tags auth
+// @Accept json
+// @Produce json,application/xml
+// @Param credentials body LoginRequest true "Username and password"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/login [post]
 func login(w http.ResponseWriter, r *http.Request) {
 	var loginReq LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&loginReq); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeJSONBody(w, r, &loginReq) {
 		return
 	}
 
-	// Validate credentials
-	var userFound bool
-	for _, user := range config.Users {
-		if user.Username == loginReq.Username && user.Password == loginReq.Password {
-			userFound = true
-			break
-		}
+	if locked, remaining := lockedOut(loginReq.Username); locked {
+		respondLockedOut(w, r, remaining)
+		return
 	}
 
-	if !userFound {
-		respondWithError(w, http.StatusUnauthorized, "Invalid username or password")
+	// Validate credentials
+	matched, ok, err := authenticateUser(r.Context(), loginReq.Username, loginReq.Password)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to check credentials")
+		return
+	}
+	if !ok {
+		recordPasswordFailure(loginReq.Username)
+		respondWithError(w, r, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Invalid username or password")
 		return
 	}
+	resetPasswordFailures(loginReq.Username)
 
 	// Generate token
 	token := uuid.New().String()
+	role := matched.Role
+	if role == "" {
+		role = roleUser
+	}
+	now := time.Now()
 	tokenMutex.Lock()
-	validTokens[token] = time.Now().Add(24 * time.Hour) // Token valid for 24 hours
+	validTokens[token] = Session{
+		ID:         uuid.New().String(),
+		Username:   matched.Username,
+		Role:       role,
+		IssuedAt:   now,
+		ExpiresAt:  now.Add(24 * time.Hour), // Token valid for 24 hours
+		LastUsedAt: now,
+	}
 	tokenMutex.Unlock()
 
-	respondWithJSON(w, http.StatusOK, LoginResponse{Token: token})
+	respondWith(w, r, http.StatusOK, LoginResponse{Token: token})
 }
 
 // POST /api/logout - Logout endpoint
+// @Summary Log out
+// @Description Revoke the bearer token sent in the Authorization header. An unknown token is accepted and nothing is revoked.
+// @Tags auth
+// @Produce json,application/xml
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/logout [post]
 func logout(w http.ResponseWriter, r *http.Request) {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
-		respondWithError(w, http.StatusUnauthorized, "Authorization header required")
+		respondWithError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authorization header required")
 		return
 	}
 
 	token := strings.TrimPrefix(authHeader, "Bearer ")
 	if token == "" {
-		respondWithError(w, http.StatusUnauthorized, "Token required")
+		respondWithError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Token required")
 		return
 	}
 
 	// Remove token
 	tokenMutex.Lock()
+	session, exists := validTokens[token]
 	delete(validTokens, token)
 	tokenMutex.Unlock()
 
-	respondWithJSON(w, http.StatusOK, SuccessResponse{Message: "Logged out successfully"})
+	if exists {
+		persistRevocations(session)
+	}
+
+	respondWith(w, r, http.StatusOK, SuccessResponse{Message: "Logged out successfully"})
 }
 
 // GET /api/heroes - Get all heroes
 // @Summary Get all heroes
-// @Description Retrieve all heroes from the database
+// @Description Retrieve all heroes from the database. When page or limit is given
+// @Description the result is paginated and X-Total-Count/Link headers are set.
+// @Description NDJSON responses are streamed without those headers and bypass the list cache.
+// @Description limit is capped at the configured maximum (100 by default). When exact counts are
+// @Description disabled (PAGINATION_EXACT_COUNT=false), paginated responses omit X-Total-Count and the
+// @Description last link, and next is only linked after a full page.
 // @Tags heroes
 // @Accept json
-// @Produce json
+// @Produce json,application/xml,application/x-ndjson
+// @Param page query int false "Page number"
+// @Param limit query int false "Heroes per page"
+// @Param format query string false "json, xml, or ndjson to stream one hero per line; overrides Accept"
+// @Param include query string false "Admins only: 'all' also returns soft-deleted heroes"
+// @Param created_after query string false "Only heroes created at or after this RFC 3339 timestamp or YYYY-MM-DD date"
+// @Param created_before query string false "Only heroes created before this RFC 3339 timestamp or YYYY-MM-DD date"
+// @Param released_after query string false "Only heroes released on or after this date"
+// @Param released_before query string false "Only heroes released before this date"
+// @Param min_difficulty query int false "Minimum difficulty_score (1-10)"
+// @Param max_difficulty query int false "Maximum difficulty_score (1-10)"
+// @Param sort query string false "id (default), difficulty_score or -difficulty_score"
+// @Param name query string false "Only the hero with exactly this name, ignoring case"
+// @Param tag query string false "Only heroes with this tag"
 // @Success 200 {array} Hero
+// @Failure 400 {object} ErrorResponse
+// @Failure 406 {object} ErrorResponse
+// @Header 200 {integer} X-Total-Count "Total number of matching heroes, omitted on pages when exact counts are disabled"
+// @Header 200 {string} Link "RFC 5988 first/prev/next/last links"
+// @Header 200 {string} X-Cache "HIT or MISS when the hero cache is enabled"
 // @Router /api/heroes [get]
+// @Router /api/heroes [head]
 func getHeroes(w http.ResponseWriter, r *http.Request) {
-	rows, err := DB.Query("SELECT id, name, role, difficulty, created_at, updated_at FROM heroes ORDER BY id")
+	filter := &heroFilter{}
+	for _, dates := range [][3]string{
+		{"created_at", "created_after", "created_before"},
+		{"release_date", "released_after", "released_before"},
+	} {
+		if err := filter.addDateRange(r, dates[0], dates[1], dates[2]); err != nil {
+			respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
+			return
+		}
+	}
+	if err := filter.addDifficultyRange(r); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
+		return
+	}
+	if name := r.URL.Query().Get("name"); name != "" {
+		filter.add("LOWER(name) = LOWER($%d)", name)
+	}
+	if value := r.URL.Query().Get("tag"); value != "" {
+		tag, err := normalizeTag(value)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
+			return
+		}
+		filter.add("EXISTS (SELECT 1 FROM hero_tags ht JOIN tags tg ON tg.id = ht.tag_id WHERE ht.hero_id = heroes.id::text AND tg.name = $%d)", tag)
+	}
+	filter.restrictVisibility(r)
+
+	order, err := heroOrder(r)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to fetch heroes")
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
+		return
+	}
+
+	query := "SELECT " + heroColumns + ", " + totalColumn() + " FROM heroes" +
+		filter.where() + order
+	args := filter.args
+
+	paginated := isPaginated(r)
+	var pagination Pagination
+	if paginated {
+		pagination, err = parsePagination(r)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
+			return
+		}
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+		args = append(args, pagination.Limit, pagination.Offset())
+	}
+
+	rows, err := queryListHeroes.WithContext(r.Context()).Build(query).Replica().Query(args...)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to fetch heroes")
 		return
 	}
 	defer rows.Close()
 
+	if negotiateFormat(r) == formatNDJSON {
+		streamHeroes(w, r, rows)
+		return
+	}
+
 	var heroes []Hero
+	total := initialTotal()
 	for rows.Next() {
 		var hero Hero
-		err := rows.Scan(&hero.ID, &hero.Name, &hero.Role, &hero.Difficulty, &hero.CreatedAt, &hero.UpdatedAt)
+		err := rows.Scan(heroScanDest(&hero, &total)...)
 		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "Failed to scan hero data")
+			respondWithInternalError(w, r, err, "Failed to scan hero data")
 			return
 		}
 		heroes = append(heroes, hero)
 	}
 
 	if err = rows.Err(); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error iterating heroes")
+		respondWithInternalError(w, r, err, "Error iterating heroes")
+		return
+	}
+
+	if paginated {
+		if needsCount(pagination, len(heroes)) {
+			if err := queryCountHeroes.WithContext(r.Context()).Build("SELECT COUNT(*) FROM heroes" + filter.where()).Replica().QueryRow(filter.args...).Scan(&total); err != nil {
+				respondWithInternalError(w, r, err, "Failed to count heroes")
+				return
+			}
+		}
+		setPaginationHeaders(w, r, pagination, total, len(heroes))
+	} else {
+		// Without pagination every matching hero is returned
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(heroes)))
+	}
+
+	setReadCacheControl(w, r)
+	respondWith(w, r, http.StatusOK, heroes)
+}
+
+// Rows written between flushes of an NDJSON stream
+const ndjsonFlushInterval = 100
+
+// streamHeroes writes the list rows as NDJSON, one hero per line, without
+// buffering them. Headers are sent before the first row, so an error midway
+// can only end the stream and be logged.
+func streamHeroes(w http.ResponseWriter, r *http.Request, rows *queryRows) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	// Long exports may take longer than SERVER_WRITE_TIMEOUT
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	flusher, _ := w.(http.Flusher)
+	level := audienceFor(r)
+	encoder := json.NewEncoder(w)
+
+	var total int
+	streamed := 0
+	for rows.Next() {
+		var hero Hero
+		if err := rows.Scan(heroScanDest(&hero, &total)...); err != nil {
+			requestLogger(r).Error("Heroes stream ended early", "rows", streamed, "error", err)
+			return
+		}
+		if err := encoder.Encode(redact(hero, level)); err != nil {
+			requestLogger(r).Error("Heroes stream ended early", "rows", streamed, "error", err)
+			return
+		}
+
+		streamed++
+		if flusher != nil && streamed%ndjsonFlushInterval == 0 {
+			flusher.Flush()
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		requestLogger(r).Error("Heroes stream ended early", "rows", streamed, "error", err)
+	}
+}
+
+// GET /api/heroes/search - Search heroes by name or role
+// @Summary Search heroes
+// @Description Fuzzy search heroes by name or role, ordered by relevance score
+// @Description Without exact counts (PAGINATION_EXACT_COUNT=false) X-Total-Count and the last link are omitted.
+// @Tags heroes
+// @Accept json
+// @Produce json,application/xml
+// @Param q query string true "Search query"
+// @Param page query int false "Page number"
+// @Param limit query int false "Results per page"
+// @Success 200 {array} HeroSearchResult
+// @Header 200 {integer} X-Total-Count "Total number of matching heroes, omitted when exact counts are disabled"
+// @Header 200 {string} Link "RFC 5988 first/prev/next/last links"
+// @Failure 400 {object} ErrorResponse
+// @Router /api/heroes/search [get]
+func searchHeroes(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, "Search query is required")
+		return
+	}
+
+	pagination, err := parsePagination(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
+		return
+	}
+
+	visibility := ""
+	if condition := visibilityFor(r).condition(); condition != "" {
+		visibility = " AND " + condition
+	}
+
+	// Use trigram similarity when pg_trgm is installed, otherwise rank ILIKE matches
+	var score, matches string
+	if trigramEnabled {
+		score = `GREATEST(similarity(name, $1), similarity(role, $1))`
+		matches = `
+			FROM heroes
+			WHERE (name % $1 OR role % $1 OR name ILIKE $2)` + visibility
+	} else {
+		score = `CASE WHEN LOWER(name) = LOWER($1) THEN 1.0
+				WHEN POSITION(LOWER($1) IN LOWER(name)) = 1 THEN 0.75
+				ELSE 0.5 END`
+		matches = `
+			FROM heroes
+			WHERE (name ILIKE $2 OR role ILIKE $2)` + visibility
+	}
+	query := `SELECT ` + heroColumns + `,
+			` + score + ` AS score,
+			` + totalColumn() + matches + `
+			ORDER BY score DESC, id
+			LIMIT $3 OFFSET $4`
+
+	pattern := "%" + escapeLike(q) + "%"
+	rows, err := querySearchHeroes.WithContext(r.Context()).Build(query).Replica().Query(q, pattern, pagination.Limit, pagination.Offset())
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to search heroes")
+		return
+	}
+	defer rows.Close()
+
+	results := []HeroSearchResult{}
+	total := initialTotal()
+	for rows.Next() {
+		var result HeroSearchResult
+		err := rows.Scan(heroScanDest(&result.Hero, &result.Score, &total)...)
+		if err != nil {
+			respondWithInternalError(w, r, err, "Failed to scan hero data")
+			return
+		}
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		respondWithInternalError(w, r, err, "Error iterating heroes")
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, heroes)
+	// The count keeps the score so $1 stays typed when only $2 filters
+	if needsCount(pagination, len(results)) {
+		count := "SELECT COUNT(*) FROM (SELECT " + score + " AS score" + matches + ") matches"
+		if err := queryCountSearchHeroes.WithContext(r.Context()).Build(count).Replica().QueryRow(q, pattern).Scan(&total); err != nil {
+			respondWithInternalError(w, r, err, "Failed to count heroes")
+			return
+		}
+	}
+	setPaginationHeaders(w, r, pagination, total, len(results))
+	respondWith(w, r, http.StatusOK, results)
 }
 
 // GET /api/heroes/{id} - Get hero by ID
@@ -214,33 +712,76 @@ func getHeroes(w http.ResponseWriter, r *http.Request) {
 // @Description Retrieve a specific hero by ID
 // @Tags heroes
 // @Accept json
-// @Produce json
-// @Param id path int true "Hero ID"
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Param include query string false "Admins only: 'all' also returns soft-deleted heroes"
+// @Param format query string false "json or xml; overrides Accept"
 // @Success 200 {object} Hero
 // @Failure 404 {object} ErrorResponse
+// @Failure 406 {object} ErrorResponse
+// @Header 200 {string} X-Cache "HIT or MISS when the hero cache is enabled"
 // @Router /api/heroes/{id} [get]
+// @Router /api/heroes/{id} [head]
 func getHeroByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := heroIDs.Parse(vars["id"])
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid hero ID")
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
 		return
 	}
 
+	filter := &heroFilter{}
+	filter.add("id = $%d", id)
+	filter.restrictVisibility(r)
+
 	var hero Hero
-	err = DB.QueryRow("SELECT id, name, role, difficulty, created_at, updated_at FROM heroes WHERE id = $1", id).
-		Scan(&hero.ID, &hero.Name, &hero.Role, &hero.Difficulty, &hero.CreatedAt, &hero.UpdatedAt)
+	err = queryGetHero.WithContext(r.Context()).Build("SELECT " + heroColumns + " FROM heroes" + filter.where()).Replica().QueryRow(filter.args...).
+		Scan(heroScanDest(&hero)...)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			respondWithError(w, http.StatusNotFound, "Hero not found")
+			respondWithError(w, r, http.StatusNotFound, ErrCodeHeroNotFound, "Hero not found")
 		} else {
-			respondWithError(w, http.StatusInternalServerError, "Failed to fetch hero")
+			respondWithInternalError(w, r, err, "Failed to fetch hero")
 		}
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, hero)
+	setReadCacheControl(w, r)
+	respondWith(w, r, http.StatusOK, hero)
+}
+
+// GET /api/heroes/{id}/exists - Check whether a hero exists
+// @Summary Check hero existence
+// @Description Report whether a hero with this ID exists and is visible to the caller, with 200 either way.
+// @Description A cheaper alternative to HEAD /api/heroes/{id} for clients that prefer a JSON answer.
+// @Tags heroes
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Param include query string false "Admins only: 'all' also counts soft-deleted heroes"
+// @Success 200 {object} HeroExistence
+// @Failure 400 {object} ErrorResponse
+// @Router /api/heroes/{id}/exists [get]
+func heroExists(w http.ResponseWriter, r *http.Request) {
+	id, err := heroIDs.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
+		return
+	}
+
+	filter := &heroFilter{}
+	filter.add("id = $%d", id)
+	filter.restrictVisibility(r)
+
+	var exists bool
+	err = queryCheckHero.WithContext(r.Context()).Build("SELECT EXISTS (SELECT 1 FROM heroes" + filter.where() + ")").Replica().QueryRow(filter.args...).
+		Scan(&exists)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to check hero")
+		return
+	}
+
+	respondWith(w, r, http.StatusOK, HeroExistence{Exists: exists})
 }
 
 // POST /api/heroes - Create a new hero
@@ -248,36 +789,78 @@ func getHeroByID(w http.ResponseWriter, r *http.Request) {
 // @Description Create a new hero in the database
 // @Tags heroes
 // @Accept json
-// @Produce json
+// @Produce json,application/xml
 // @Param hero body HeroCreateRequest true "Hero data"
-// @Success 201 {object} Hero
+// @Param Idempotency-Key header string false "Retries with the same key and body replay the first response"
+// @Success 201 {object} CreatedHero
+// @Header 201 {string} Location "URL of the created hero"
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /api/heroes [post]
 func createHero(w http.ResponseWriter, r *http.Request) {
 	var req HeroCreateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
+	req.Normalize()
 
 	// Validate required fields
 	if req.Name == "" || req.Role == "" || req.Difficulty == "" {
-		respondWithError(w, http.StatusBadRequest, "Name, role, and difficulty are required")
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, "Name, role, and difficulty are required")
+		return
+	}
+
+	difficulty, err := resolveDifficulty(req.Difficulty, req.DifficultyScore)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	// The hero and its tags are created together or not at all
+	tx, err := beginTx(r.Context(), nil)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to create hero")
 		return
 	}
+	defer tx.Rollback()
 
 	var hero Hero
-	err := DB.QueryRow("INSERT INTO heroes (name, role, difficulty) VALUES ($1, $2, $3) RETURNING id, name, role, difficulty, created_at, updated_at",
-		req.Name, req.Role, req.Difficulty).
-		Scan(&hero.ID, &hero.Name, &hero.Role, &hero.Difficulty, &hero.CreatedAt, &hero.UpdatedAt)
+	insert, args := heroInsert(req.Name, req.Role, difficulty, req.HeroDetails)
+	err = queryCreateHero.WithContext(r.Context()).Build(insert + " RETURNING " + heroColumns).In(tx).QueryRow(args...).
+		Scan(heroScanDest(&hero)...)
 
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to create hero")
+		respondWithHeroWriteError(w, r, err, "Failed to create hero")
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, hero)
+	if err := tagHero(tx, hero.ID, tags); err != nil {
+		respondWithInternalError(w, r, err, "Failed to tag hero")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithInternalError(w, r, err, "Failed to create hero")
+		return
+	}
+	hero.Tags = tags
+
+	invalidateHeroCache()
+	publishHeroEvent(r, eventCreated, hero)
+
+	w.Header().Set("Location", apiURL(r, "/heroes/"+hero.ID.String()))
+	respondWith(w, r, http.StatusCreated, CreatedHero{
+		Hero:     hero,
+		Warnings: roleDifficultyWarnings(hero.Role, hero.Difficulty, config.Validation.RoleDifficulties),
+	})
 }
 
 // PUT /api/heroes/{id} - Update a hero by ID
@@ -285,86 +868,235 @@ func createHero(w http.ResponseWriter, r *http.Request) {
 // @Description Update an existing hero by ID
 // @Tags heroes
 // @Accept json
-// @Produce json
-// @Param id path int true "Hero ID"
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
 // @Param hero body HeroUpdateRequest true "Hero data"
 // @Success 200 {object} Hero
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Security BearerAuth
 // @Router /api/heroes/{id} [put]
 func updateHero(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := heroIDs.Parse(vars["id"])
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid hero ID")
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
 		return
 	}
 
 	var req HeroUpdateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
+	req.Normalize()
 
 	// Validate required fields
 	if req.Name == "" || req.Role == "" || req.Difficulty == "" {
-		respondWithError(w, http.StatusBadRequest, "Name, role, and difficulty are required")
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, "Name, role, and difficulty are required")
+		return
+	}
+
+	difficulty, err := resolveDifficulty(req.Difficulty, req.DifficultyScore)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, err.Error())
 		return
 	}
 
 	var hero Hero
-	err = DB.QueryRow("UPDATE heroes SET name = $1, role = $2, difficulty = $3 WHERE id = $4 RETURNING id, name, role, difficulty, created_at, updated_at",
-		req.Name, req.Role, req.Difficulty, id).
-		Scan(&hero.ID, &hero.Name, &hero.Role, &hero.Difficulty, &hero.CreatedAt, &hero.UpdatedAt)
+	err = queryUpdateHero.WithContext(r.Context()).QueryRow(req.Name, req.Role, difficulty.Label, difficulty.Score,
+		req.Lore, req.Specialty, req.Lane, req.ReleaseDate, id).
+		Scan(heroScanDest(&hero)...)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			respondWithError(w, http.StatusNotFound, "Hero not found")
-		} else {
-			respondWithError(w, http.StatusInternalServerError, "Failed to update hero")
-		}
+		respondWithHeroWriteError(w, r, err, "Failed to update hero")
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, hero)
+	invalidateHeroCache()
+	publishHeroEvent(r, eventUpdated, hero)
+
+	respondWith(w, r, http.StatusOK, hero)
+}
+
+// PUT /api/heroes/by-name/{name} - Create or update a hero by name
+// @Summary Upsert hero by name
+// @Description Update the hero with this name, or create it if none exists
+// @Tags heroes
+// @Accept json
+// @Produce json,application/xml
+// @Param name path string true "Hero name"
+// @Param hero body HeroUpsertRequest true "Hero data"
+// @Success 200 {object} Hero
+// @Success 201 {object} Hero
+// @Header 201 {string} Location "URL of the created hero"
+// @Failure 400 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/by-name/{name} [put]
+func upsertHeroByName(w http.ResponseWriter, r *http.Request) {
+	name := normalizeHeroName(mux.Vars(r)["name"])
+
+	var req HeroUpsertRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	req.Normalize()
+
+	// Validate required fields
+	if name == "" || req.Role == "" || req.Difficulty == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, "Name, role, and difficulty are required")
+		return
+	}
+
+	difficulty, err := resolveDifficulty(req.Difficulty, req.DifficultyScore)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, err.Error())
+		return
+	}
+
+	// xmax is 0 only for a freshly inserted row
+	var hero Hero
+	var inserted bool
+	insert, args := heroInsert(name, req.Role, difficulty, req.HeroDetails)
+	err = queryUpsertHero.WithContext(r.Context()).Build(insert + `
+		ON CONFLICT (name) DO UPDATE SET role = EXCLUDED.role, difficulty = EXCLUDED.difficulty, difficulty_score = EXCLUDED.difficulty_score,
+			lore = EXCLUDED.lore, specialty = EXCLUDED.specialty, lane = EXCLUDED.lane, release_date = EXCLUDED.release_date
+		RETURNING ` + heroColumns + `, (xmax = 0)`).QueryRow(args...).
+		Scan(heroScanDest(&hero, &inserted)...)
+
+	if err != nil {
+		respondWithHeroWriteError(w, r, err, "Failed to upsert hero")
+		return
+	}
+
+	invalidateHeroCache()
+
+	if inserted {
+		publishHeroEvent(r, eventCreated, hero)
+		w.Header().Set("Location", apiURL(r, "/heroes/"+hero.ID.String()))
+		respondWith(w, r, http.StatusCreated, hero)
+		return
+	}
+
+	publishHeroEvent(r, eventUpdated, hero)
+	respondWith(w, r, http.StatusOK, hero)
 }
 
 // DELETE /api/heroes/{id} - Delete a hero by ID
 // @Summary Delete hero by ID
-// @Description Delete an existing hero by ID
+// @Description Delete an existing hero by ID. With DESTRUCTIVE_CONFIRMATION on, the first call returns 428
+// @Description with a confirmation token that must be echoed in X-Confirmation-Token.
 // @Tags heroes
 // @Accept json
-// @Produce json
-// @Param id path int true "Hero ID"
-// @Success 200 {object} SuccessResponse
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Param X-Confirmation-Token header string false "Confirmation token from the 428 response"
+// @Success 204 "No Content"
 // @Failure 404 {object} ErrorResponse
+// @Failure 428 {object} ConfirmationRequiredResponse
 // @Security BearerAuth
 // @Router /api/heroes/{id} [delete]
 func deleteHero(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := heroIDs.Parse(vars["id"])
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid hero ID")
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
 		return
 	}
 
-	result, err := DB.Exec("DELETE FROM heroes WHERE id = $1", id)
+	var hero Hero
+	err = queryDeleteHero.WithContext(r.Context()).QueryRow(id).
+		Scan(heroScanDest(&hero)...)
+
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to delete hero")
+		respondWithHeroWriteError(w, r, err, "Failed to delete hero")
 		return
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	invalidateHeroCache()
+	publishHeroEvent(r, eventDeleted, hero)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/roles - Get hero roles
+// @Summary Get hero roles
+// @Description The canonical roles from display_order in config.yaml with the number of heroes in each,
+// @Description in display order. Roles found on heroes but not configured follow with "canonical": false.
+// @Description Supports If-None-Match.
+// @Tags reference
+// @Produce json,application/xml
+// @Success 200 {array} ReferenceValue
+// @Success 304 "Not Modified"
+// @Header 200 {string} ETag "Hash of the response"
+// @Router /api/roles [get]
+func getRoles(w http.ResponseWriter, r *http.Request) {
+	order := currentDisplayOrder().Roles
+	respondWithValueCounts(w, r, "role", order, order, "Failed to fetch roles")
+}
+
+// GET /api/difficulties - Get hero difficulties
+// @Summary Get hero difficulties
+// @Description The difficulty labels that have a score range with the number of heroes in each, in
+// @Description configured display order. Labels found on heroes without a range follow with "canonical": false.
+// @Description Supports If-None-Match.
+// @Tags reference
+// @Produce json,application/xml
+// @Success 200 {array} ReferenceValue
+// @Success 304 "Not Modified"
+// @Header 200 {string} ETag "Hash of the response"
+// @Router /api/difficulties [get]
+func getDifficulties(w http.ResponseWriter, r *http.Request) {
+	respondWithValueCounts(w, r, "difficulty", knownDifficulties(), currentDisplayOrder().Difficulties, "Failed to fetch difficulties")
+}
+
+// Respond with the canonical values of a hero column and any other values
+// on heroes visible to the caller, in display order, each with its hero count
+func respondWithValueCounts(w http.ResponseWriter, r *http.Request, column string, canonical, order []string, failure string) {
+	filter := &heroFilter{}
+	filter.restrictVisibility(r)
+
+	query := fmt.Sprintf("SELECT %[1]s, COUNT(*) FROM heroes%[2]s GROUP BY %[1]s", column, filter.where())
+	rows, err := queryCountHeroesByValue.WithContext(r.Context()).Build(query).Replica().Query(filter.args...)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to check deletion result")
+		respondWithInternalError(w, r, err, failure)
 		return
 	}
+	defer rows.Close()
 
-	if rowsAffected == 0 {
-		respondWithError(w, http.StatusNotFound, "Hero not found")
+	counts := make(map[string]int)
+	for rows.Next() {
+		var value string
+		var count int
+		if err := rows.Scan(&value, &count); err != nil {
+			respondWithInternalError(w, r, err, failure)
+			return
+		}
+		counts[value] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		respondWithInternalError(w, r, err, failure)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	isCanonical := make(map[string]bool, len(canonical))
+	values := append([]string(nil), canonical...)
+	for _, value := range canonical {
+		isCanonical[value] = true
+	}
+	for value := range counts {
+		if !isCanonical[value] {
+			values = append(values, value)
+		}
+	}
+
+	result := orderValues(values, order)
+	for i := range result {
+		result[i].Count = counts[result[i].Value]
+		result[i].Canonical = isCanonical[result[i].Value]
+	}
+	respondWithETag(w, r, result)
 }
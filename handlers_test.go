@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	useTestConfig(t)
+	captureLogs(t)
+
+	// Decodes the body like every JSON handler does
+	decode := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if decodeJSONBody(w, r, &body) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	padded := func(n int) string {
+		return `{"name":"` + strings.Repeat("a", n-len(`{"name":""}`)) + `"}`
+	}
+
+	tests := []struct {
+		name        string
+		limit       int64
+		overrides   map[string]int64
+		path        string
+		body        string
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{"within the limit", 64, nil, "/api/heroes", padded(64), http.StatusNoContent, "", ""},
+		{"over the limit", 64, nil, "/api/heroes", padded(65), http.StatusRequestEntityTooLarge, ErrCodePayloadTooLarge, "Request body must not exceed 64 bytes"},
+		{"no limit", 0, nil, "/api/heroes", padded(4096), http.StatusNoContent, "", ""},
+		{"override raises the limit", 64, map[string]int64{"/api/admin/import": 256}, "/api/admin/import", padded(200), http.StatusNoContent, "", ""},
+		{"override still caps", 64, map[string]int64{"/api/admin/import": 256}, "/api/admin/import", padded(257), http.StatusRequestEntityTooLarge, ErrCodePayloadTooLarge, "Request body must not exceed 256 bytes"},
+		{"override lowers the limit", 256, map[string]int64{"/api/login": 32}, "/api/login", padded(64), http.StatusRequestEntityTooLarge, ErrCodePayloadTooLarge, "Request body must not exceed 32 bytes"},
+		{"override of 0 lifts the limit", 64, map[string]int64{"/api/admin/import": 0}, "/api/admin/import", padded(4096), http.StatusNoContent, "", ""},
+		{"override is per path", 64, map[string]int64{"/api/admin/import": 256}, "/api/heroes", padded(200), http.StatusRequestEntityTooLarge, ErrCodePayloadTooLarge, "Request body must not exceed 64 bytes"},
+		{"trailing JSON value", 64, nil, "/api/heroes", `{"name":"a"}{"name":"b"}`, http.StatusBadRequest, ErrCodeInvalidPayload, "Invalid request payload"},
+		{"trailing garbage", 64, nil, "/api/heroes", `{"name":"a"} x`, http.StatusBadRequest, ErrCodeInvalidPayload, "Invalid request payload"},
+		{"trailing whitespace", 64, nil, "/api/heroes", "{\"name\":\"a\"}\n\t ", http.StatusNoContent, "", ""},
+		{"empty body", 64, nil, "/api/heroes", "", http.StatusBadRequest, ErrCodeBodyRequired, "Request body is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			bodyLimitMiddleware(tt.limit, tt.overrides)(decode).ServeHTTP(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			if body := decodeError(t, rec); body.Code != tt.wantCode || body.Error != tt.wantMessage {
+				t.Errorf("error = %s %q, want %s %q", body.Code, body.Error, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+}
+
+// The router applies SERVER_MAX_BODY_BYTES before the handler reads the body
+func TestRouterRejectsOversizedBody(t *testing.T) {
+	cfg := useTestConfig(t)
+	cfg.Server.MaxBodyBytes = 32
+	config = cfg
+
+	r := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(`{"username":"alice","password":"`+strings.Repeat("x", 64)+`"}`))
+	r.Header.Set("Content-Type", "application/json")
+	rec := serve(cfg, r)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413; body %s", rec.Code, rec.Body.String())
+	}
+	if body := decodeError(t, rec); body.Code != ErrCodePayloadTooLarge {
+		t.Errorf("code = %s, want %s", body.Code, ErrCodePayloadTooLarge)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// respondWithAlucard answers hero queries as if only hero 1 existed
+func respondWithAlucard(query string, args []interface{}) *fakeResult {
+	missing := strings.Contains(fmt.Sprint(args), "99")
+	switch {
+	case strings.HasPrefix(query, "SELECT EXISTS (SELECT 1 FROM heroes"):
+		return &fakeResult{columns: []string{"exists"}, rows: [][]driver.Value{{!missing}}}
+	case strings.Contains(query, "FROM heroes") && !missing:
+		result := fakeHeroes(Hero{ID: "1", Name: "Alucard", Role: "Fighter", Difficulty: "Mudah", Tags: []string{}})
+		if strings.Contains(query, ", "+totalColumn()+" FROM heroes") {
+			result.columns = append(result.columns, "total")
+			result.rows[0] = append(result.rows[0], int64(1))
+		}
+		return result
+	}
+	return nil
+}
+
+func TestHeadMatchesGet(t *testing.T) {
+	cfg := useTestConfig(t)
+	captureLogs(t)
+	useFakeDB(t).respond = respondWithAlucard
+
+	for _, tt := range []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/api/heroes", http.StatusOK},
+		{"/api/heroes/1", http.StatusOK},
+		{"/api/heroes/99", http.StatusNotFound},
+		{"/api/heroes/abc", http.StatusBadRequest},
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			get := serve(cfg, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			head := serve(cfg, httptest.NewRequest(http.MethodHead, tt.path, nil))
+
+			if get.Code != tt.wantStatus || head.Code != tt.wantStatus {
+				t.Fatalf("GET = %d, HEAD = %d, want %d for both", get.Code, head.Code, tt.wantStatus)
+			}
+			if head.Body.Len() != 0 {
+				t.Errorf("HEAD wrote a body: %s", head.Body.String())
+			}
+			if want := fmt.Sprint(get.Body.Len()); head.Header().Get("Content-Length") != want {
+				t.Errorf("HEAD Content-Length = %q, want the GET body length %s", head.Header().Get("Content-Length"), want)
+			}
+			for _, header := range []string{"Content-Type", "ETag", "Access-Control-Allow-Origin"} {
+				if head.Header().Get(header) != get.Header().Get(header) {
+					t.Errorf("HEAD %s = %q, GET sent %q", header, head.Header().Get(header), get.Header().Get(header))
+				}
+			}
+			if head.Header().Get("Access-Control-Allow-Origin") != "*" {
+				t.Errorf("HEAD is missing CORS headers")
+			}
+		})
+	}
+}
+
+func TestHeroExists(t *testing.T) {
+	cfg := useTestConfig(t)
+	captureLogs(t)
+	useFakeDB(t).respond = respondWithAlucard
+
+	for path, want := range map[string]string{
+		"/api/heroes/1/exists":  `{"exists":true}`,
+		"/api/heroes/99/exists": `{"exists":false}`,
+	} {
+		rec := serve(cfg, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200 either way", path, rec.Code)
+		}
+		if got := strings.Join(strings.Fields(rec.Body.String()), ""); got != want {
+			t.Errorf("%s: body = %s, want %s", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// GET /health/live - Liveness probe
+// @Summary Liveness probe
+// @Description Reports that the process is running
+// @Tags health
+// @Produce json,application/xml
+// @Success 200 {object} HealthResponse
+// @Router /health/live [get]
+func liveness(w http.ResponseWriter, r *http.Request) {
+	respondWith(w, r, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// GET /health/ready - Readiness probe
+// @Summary Readiness probe
+// @Description Reports whether the database is reachable, its schema matches this build and the
+// @Description token cleanup has run within two of its 30 minute cycles. Maintenance mode is
+// @Description reported, but only makes the probe fail with ?maintenance=true.
+// @Tags health
+// @Produce json,application/xml
+// @Param maintenance query bool false "Report unavailable while maintenance mode is on"
+// @Success 200 {object} HealthResponse
+// @Failure 503 {object} HealthResponse
+// @Router /health/ready [get]
+func readiness(w http.ResponseWriter, r *http.Request) {
+	response := HealthResponse{Status: "ok", Checks: map[string]string{}}
+
+	if err := DB.PingContext(r.Context()); err != nil {
+		response.Status = "unavailable"
+		response.Checks["database"] = err.Error()
+		dbPool.record(err)
+	} else {
+		response.Checks["database"] = "ok"
+	}
+
+	// Reads fail without the replica, so it gates readiness like the primary
+	if ReadDB != DB {
+		if err := ReadDB.PingContext(r.Context()); err != nil {
+			response.Status = "unavailable"
+			response.Checks["replica"] = err.Error()
+			replicaPool.record(err)
+		} else {
+			response.Checks["replica"] = "ok"
+		}
+		if replicaPool.healthy() {
+			response.Checks["replica_pool"] = "ok"
+		} else {
+			response.Status = "unavailable"
+			response.Checks["replica_pool"] = "circuit open"
+		}
+	}
+
+	if dbPool.healthy() {
+		response.Checks["database_pool"] = "ok"
+	} else {
+		response.Status = "unavailable"
+		response.Checks["database_pool"] = "circuit open"
+	}
+
+	response.Checks["clock_skew"] = dbClock.Skew().String()
+
+	// A cleanup that missed two cycles is stuck or has died
+	if last := lastTokenCleanup.Load(); last == 0 {
+		response.Status = "unavailable"
+		response.Checks["token_cleanup"] = "not running"
+	} else if since := time.Since(time.Unix(0, last)); since > 2*tokenCleanupInterval {
+		response.Status = "unavailable"
+		response.Checks["token_cleanup"] = "stalled, last run " + since.Round(time.Second).String() + " ago"
+	} else {
+		response.Checks["token_cleanup"] = "ok"
+	}
+
+	if maintenanceStatus().Enabled {
+		response.Checks["maintenance"] = "enabled"
+		if r.URL.Query().Get("maintenance") == "true" {
+			response.Status = "unavailable"
+		}
+	} else {
+		response.Checks["maintenance"] = "off"
+	}
+
+	if !schemaReady {
+		response.Status = "unavailable"
+	}
+	response.Checks["schema"] = schemaMessage
+
+	code := http.StatusOK
+	if response.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+	respondWith(w, r, code, response)
+}
+
+// Schema gate middleware rejecting API requests while the schema version doesn't match
+func schemaGateMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !schemaReady {
+			respondWithError(w, r, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "Service unavailable: "+schemaMessage)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// useTestConfig installs the default configuration, with the schema marked
+// ready and the hero cache off, and restores the previous state afterwards.
+// Tests using it must not run in parallel.
+func useTestConfig(t *testing.T) AppConfig {
+	t.Helper()
+	previous, previousReady := config, schemaReady
+	t.Cleanup(func() {
+		config, schemaReady = previous, previousReady
+		setDisplayOrder(config.DisplayOrder)
+		initHeroCache(config.Cache)
+	})
+
+	config = defaultAppConfig()
+	config.Cache.Enabled = false
+	schemaReady = true
+	setDisplayOrder(config.DisplayOrder)
+	initHeroCache(config.Cache)
+	return config
+}
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of a logger
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// records decodes the JSON log lines written so far
+func (b *syncBuffer) records(t *testing.T) []map[string]interface{} {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// captureLogs sends the default logger to a JSON buffer for the rest of the test
+func captureLogs(t *testing.T) *syncBuffer {
+	t.Helper()
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	logs := &syncBuffer{}
+	slog.SetDefault(slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	return logs
+}
+
+// decodeError decodes the JSON error envelope of a recorded response
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json; body %s", contentType, rec.Body.String())
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error body %q is not JSON: %v", rec.Body.String(), err)
+	}
+	return body
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+)
+
+// How long a stored response is replayed for its Idempotency-Key
+const idempotencyKeyTTL = 24 * time.Hour
+
+// Queries on idempotency_keys; expired rows are pruned as a managed table
+var (
+	queryReserveIdempotencyKey = registerQuery("idempotency_keys.reserve", `INSERT INTO idempotency_keys (username, key, request_hash)
+		VALUES ($1, $2, $3) ON CONFLICT (username, key) DO NOTHING`, paramText, paramText, paramText)
+	queryGetIdempotencyKey = registerQuery("idempotency_keys.get", `SELECT request_hash, status, content_type, location, body
+		FROM idempotency_keys WHERE username = $1 AND key = $2 AND created_at > $3`, paramText, paramText, paramTime)
+	queryExpireIdempotencyKey = registerQuery("idempotency_keys.expire", "DELETE FROM idempotency_keys WHERE username = $1 AND key = $2 AND created_at <= $3",
+		paramText, paramText, paramTime)
+	queryStoreIdempotentResponse = registerQuery("idempotency_keys.store", `UPDATE idempotency_keys SET status = $3, content_type = $4, location = $5, body = $6
+		WHERE username = $1 AND key = $2`, paramText, paramText, paramInt, paramText, paramText, paramBytes)
+	queryReleaseIdempotencyKey = registerQuery("idempotency_keys.release", "DELETE FROM idempotency_keys WHERE username = $1 AND key = $2",
+		paramText, paramText)
+)
+
+// initIdempotencyKeys registers idempotency_keys for pruning once keys expire
+func initIdempotencyKeys() {
+	registerManagedTable("idempotency_keys", "created_at", idempotencyKeyTTL)
+}
+
+// storedResponse is a response kept for an Idempotency-Key
+type storedResponse struct {
+	requestHash string
+	status      int
+	contentType string
+	location    string
+	body        []byte
+}
+
+// idempotencyMiddleware replays the stored response when an authenticated
+// request is retried with the same Idempotency-Key and body. A key reused
+// with a different body, or while its first request is still running, gets a
+// 409. Only successful responses are kept, so a failed request can be retried.
+func idempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if respondWithBodyTooLarge(w, r, err) {
+			return
+		}
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidPayload, "Invalid request payload")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+		requestHash := hex.EncodeToString(sum[:])
+		session, _ := sessionFromRequest(r)
+
+		// Drop an expired reservation first so the key can be reused after its TTL
+		if _, err := queryExpireIdempotencyKey.WithContext(r.Context()).Exec(session.Username, key, dbClock.Now().Add(-idempotencyKeyTTL)); err != nil {
+			respondWithInternalError(w, r, err, "Failed to check idempotency key")
+			return
+		}
+
+		result, err := queryReserveIdempotencyKey.WithContext(r.Context()).Exec(session.Username, key, requestHash)
+		if err != nil {
+			respondWithInternalError(w, r, err, "Failed to check idempotency key")
+			return
+		}
+		if reserved, _ := result.RowsAffected(); reserved == 0 {
+			replayResponse(w, r, session.Username, key, requestHash)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// Recorded even when the client has gone or the request timed out, so the key isn't left reserved
+		ctx := detachedContext(r.Context())
+		if recorder.status >= 200 && recorder.status < 300 {
+			_, err = queryStoreIdempotentResponse.WithContext(ctx).Exec(session.Username, key, recorder.status,
+				w.Header().Get("Content-Type"), w.Header().Get("Location"), recorder.body.Bytes())
+		} else {
+			_, err = queryReleaseIdempotencyKey.WithContext(ctx).Exec(session.Username, key)
+		}
+		if err != nil {
+			requestLogger(r).Error("Failed to record response for idempotency key", "key", key, "error", err)
+		}
+	})
+}
+
+// replayResponse answers a request whose Idempotency-Key is already taken
+func replayResponse(w http.ResponseWriter, r *http.Request, username, key, requestHash string) {
+	var stored storedResponse
+	err := queryGetIdempotencyKey.WithContext(r.Context()).QueryRow(username, key, dbClock.Now().Add(-idempotencyKeyTTL)).
+		Scan(&stored.requestHash, &stored.status, &stored.contentType, &stored.location, &stored.body)
+	switch {
+	case err == sql.ErrNoRows:
+		// The first request failed and released the key in the meantime
+		respondWithError(w, r, http.StatusConflict, ErrCodeIdempotencyConflict, "A request with this Idempotency-Key is being retried, try again")
+		return
+	case err != nil:
+		respondWithInternalError(w, r, err, "Failed to check idempotency key")
+		return
+	case stored.requestHash != requestHash:
+		respondWithError(w, r, http.StatusConflict, ErrCodeIdempotencyConflict, "Idempotency-Key was already used with a different request")
+		return
+	case stored.status == 0:
+		respondWithError(w, r, http.StatusConflict, ErrCodeIdempotencyConflict, "A request with this Idempotency-Key is still in progress")
+		return
+	}
+
+	if stored.location != "" {
+		w.Header().Set("Location", stored.location)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	writeBody(w, r, stored.status, stored.contentType, stored.body)
+}
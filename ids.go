@@ -0,0 +1,269 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Supported strategies for assigning new hero IDs
+const (
+	idStrategySerial    = "serial"
+	idStrategySnowflake = "snowflake"
+	idStrategyUUIDv7    = "uuidv7"
+)
+
+// IDConfig selects how new hero IDs are assigned
+type IDConfig struct {
+	Strategy string `yaml:"strategy"`
+	NodeID   int64  `yaml:"node_id"`
+}
+
+// HeroID identifies a hero: a decimal integer under the serial and snowflake
+// strategies, a UUID under uuidv7. Only serial IDs are JSON numbers; snowflake
+// IDs exceed the 2^53 integers JavaScript can represent, so they are strings.
+type HeroID string
+
+// String returns the ID as used in paths
+func (id HeroID) String() string {
+	return string(id)
+}
+
+// MarshalJSON writes serial IDs as JSON numbers and everything else as strings
+func (id HeroID) MarshalJSON() ([]byte, error) {
+	if heroIDs.Name() == idStrategySerial {
+		if _, err := strconv.ParseInt(string(id), 10, 64); err == nil {
+			return []byte(id), nil
+		}
+	}
+	return json.Marshal(string(id))
+}
+
+// UnmarshalJSON accepts either a JSON number or a string
+func (id *HeroID) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*id = HeroID(text)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("hero id must be a number or string")
+	}
+	*id = HeroID(number.String())
+	return nil
+}
+
+// Scan reads an integer or text id column
+func (id *HeroID) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case int64:
+		*id = HeroID(strconv.FormatInt(value, 10))
+	case []byte:
+		*id = HeroID(value)
+	case string:
+		*id = HeroID(value)
+	default:
+		return fmt.Errorf("cannot scan %T into HeroID", src)
+	}
+	return nil
+}
+
+// Value passes the ID as text; Postgres casts it to the column type
+func (id HeroID) Value() (driver.Value, error) {
+	return string(id), nil
+}
+
+// idStrategy parses path IDs and generates IDs for new heroes
+type idStrategy interface {
+	Name() string
+	// Numeric reports whether IDs are integers
+	Numeric() bool
+	// Parse validates an ID taken from a request path
+	Parse(raw string) (HeroID, error)
+	// Next returns a new ID, or false when the database assigns it (SERIAL)
+	Next() (HeroID, bool)
+}
+
+// Strategy used for hero IDs, set by initHeroIDs
+var heroIDs idStrategy = serialIDs{}
+
+// parsePositiveID accepts a positive decimal integer
+func parsePositiveID(raw string) (HeroID, error) {
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || value < 1 {
+		return "", fmt.Errorf("invalid hero id %q", raw)
+	}
+	return HeroID(strconv.FormatInt(value, 10)), nil
+}
+
+// serialIDs leaves ID assignment to the SERIAL column default
+type serialIDs struct{}
+
+func (serialIDs) Name() string                     { return idStrategySerial }
+func (serialIDs) Numeric() bool                    { return true }
+func (serialIDs) Parse(raw string) (HeroID, error) { return parsePositiveID(raw) }
+func (serialIDs) Next() (HeroID, bool)             { return "", false }
+
+// Snowflake layout: 41 bits of milliseconds since snowflakeEpoch, 10 bits node, 12 bits sequence
+const (
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+	snowflakeMaxNode      = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSequence  = 1<<snowflakeSequenceBits - 1
+)
+
+// Custom epoch keeping snowflake IDs well inside BIGINT
+var snowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// snowflakeIDs generates time-ordered 64-bit IDs unique per node
+type snowflakeIDs struct {
+	mu       sync.Mutex
+	node     int64
+	lastMs   int64
+	sequence int64
+}
+
+func (*snowflakeIDs) Name() string                     { return idStrategySnowflake }
+func (*snowflakeIDs) Numeric() bool                    { return true }
+func (*snowflakeIDs) Parse(raw string) (HeroID, error) { return parsePositiveID(raw) }
+
+func (s *snowflakeIDs) Next() (HeroID, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Since(snowflakeEpoch).Milliseconds()
+	// Never go backwards if the clock does
+	if now < s.lastMs {
+		now = s.lastMs
+	}
+
+	if now == s.lastMs {
+		s.sequence = (s.sequence + 1) & snowflakeMaxSequence
+		if s.sequence == 0 {
+			// Sequence exhausted for this millisecond, wait for the next one
+			for now <= s.lastMs {
+				time.Sleep(100 * time.Microsecond)
+				now = time.Since(snowflakeEpoch).Milliseconds()
+			}
+		}
+	} else {
+		s.sequence = 0
+	}
+	s.lastMs = now
+
+	id := now<<(snowflakeNodeBits+snowflakeSequenceBits) | s.node<<snowflakeSequenceBits | s.sequence
+	return HeroID(strconv.FormatInt(id, 10)), true
+}
+
+// uuidV7IDs generates time-ordered UUIDs (RFC 9562 version 7)
+type uuidV7IDs struct{}
+
+func (uuidV7IDs) Name() string  { return idStrategyUUIDv7 }
+func (uuidV7IDs) Numeric() bool { return false }
+
+// Parse accepts UUIDs, and integers kept from before the column was converted
+func (uuidV7IDs) Parse(raw string) (HeroID, error) {
+	if parsed, err := uuid.Parse(raw); err == nil {
+		return HeroID(parsed.String()), nil
+	}
+	return parsePositiveID(raw)
+}
+
+func (uuidV7IDs) Next() (HeroID, bool) {
+	var id uuid.UUID
+	if _, err := rand.Read(id[6:]); err != nil {
+		panic(fmt.Sprintf("uuidv7: reading random bytes: %v", err))
+	}
+
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], uint64(time.Now().UnixMilli()))
+	copy(id[:6], timestamp[2:])
+
+	id[6] = id[6]&0x0f | 0x70 // version 7
+	id[8] = id[8]&0x3f | 0x80 // RFC 4122 variant
+	return HeroID(id.String()), true
+}
+
+// newIDStrategy builds the strategy named in cfg
+func newIDStrategy(cfg IDConfig) (idStrategy, error) {
+	switch cfg.Strategy {
+	case idStrategySerial:
+		return serialIDs{}, nil
+	case idStrategySnowflake:
+		if cfg.NodeID < 0 || cfg.NodeID > snowflakeMaxNode {
+			return nil, fmt.Errorf("HERO_ID_NODE must be between 0 and %d", snowflakeMaxNode)
+		}
+		return &snowflakeIDs{node: cfg.NodeID}, nil
+	case idStrategyUUIDv7:
+		return uuidV7IDs{}, nil
+	}
+	return nil, fmt.Errorf("unknown HERO_ID_STRATEGY %q (expected %s, %s or %s)",
+		cfg.Strategy, idStrategySerial, idStrategySnowflake, idStrategyUUIDv7)
+}
+
+// Queries inspecting and converting the heroes.id column
+var (
+	queryHeroIDType   = registerQuery("schema.hero_id_type", "SELECT data_type FROM information_schema.columns WHERE table_name = 'heroes' AND column_name = 'id'")
+	queryHeroIDToText = registerQuery("schema.hero_id_to_text", "ALTER TABLE heroes ALTER COLUMN id DROP DEFAULT, ALTER COLUMN id TYPE TEXT USING id::text")
+)
+
+// initHeroIDs selects the ID strategy and converts the heroes.id column to text
+// the first time uuidv7 is used. The conversion is one-way: existing integer IDs
+// become their decimal text and keep resolving.
+func initHeroIDs(cfg IDConfig) error {
+	strategy, err := newIDStrategy(cfg)
+	if err != nil {
+		return err
+	}
+
+	var dataType string
+	err = queryHeroIDType.QueryRow().Scan(&dataType)
+	if err == sql.ErrNoRows {
+		// Migrations disabled and no table yet; the schema gate reports it
+		heroIDs = strategy
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to inspect heroes.id: %v", err)
+	}
+
+	textColumn := strings.Contains(dataType, "char") || dataType == "text"
+	switch {
+	case strategy.Numeric() && textColumn:
+		return fmt.Errorf("heroes.id holds UUIDs; the %s strategy cannot be used on this database", strategy.Name())
+	case !strategy.Numeric() && !textColumn:
+		if _, err := queryHeroIDToText.Exec(); err != nil {
+			return fmt.Errorf("failed to convert heroes.id to text: %v", err)
+		}
+		slog.Info("Converted heroes.id to text", "strategy", strategy.Name())
+	}
+
+	heroIDs = strategy
+	slog.Info("Hero ID strategy", "strategy", strategy.Name())
+	return nil
+}
+
+// heroInsert returns the INSERT statement and arguments for a new hero, with an
+// application-generated ID unless the strategy leaves it to the database
+func heroInsert(name, role string, difficulty heroDifficulty, details HeroDetails) (string, []interface{}) {
+	const columns = "name, role, difficulty, difficulty_score, lore, specialty, lane, release_date"
+	args := []interface{}{name, role, difficulty.Label, difficulty.Score,
+		details.Lore, details.Specialty, details.Lane, details.ReleaseDate}
+
+	if id, ok := heroIDs.Next(); ok {
+		return "INSERT INTO heroes (id, " + columns + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)", append([]interface{}{id}, args...)
+	}
+	return "INSERT INTO heroes (" + columns + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)", args
+}
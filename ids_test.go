@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// Every supported strategy, as newIDStrategy builds it
+var idStrategyConfigs = []IDConfig{
+	{Strategy: idStrategySerial},
+	{Strategy: idStrategySnowflake, NodeID: 7},
+	{Strategy: idStrategyUUIDv7},
+}
+
+// useIDStrategy makes cfg the hero ID strategy for the rest of the test
+func useIDStrategy(t *testing.T, cfg IDConfig) idStrategy {
+	t.Helper()
+	strategy, err := newIDStrategy(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := heroIDs
+	t.Cleanup(func() { heroIDs = previous })
+	heroIDs = strategy
+	return strategy
+}
+
+// forEachIDStrategy runs test as a subtest under every ID strategy
+func forEachIDStrategy(t *testing.T, test func(t *testing.T, strategy idStrategy)) {
+	t.Helper()
+	for _, cfg := range idStrategyConfigs {
+		t.Run(cfg.Strategy, func(t *testing.T) {
+			test(t, useIDStrategy(t, cfg))
+		})
+	}
+}
+
+func TestIDStrategyParse(t *testing.T) {
+	const generated = "0190b2a4-5c3e-7d2f-8a1b-9c0d1e2f3a4b"
+	tests := []struct {
+		raw string
+		// normalized ID per strategy, "" when rejected
+		serial, snowflake, uuidv7 string
+	}{
+		{"42", "42", "42", "42"},
+		{"007", "7", "7", "7"},
+		{"225877695497371648", "225877695497371648", "225877695497371648", "225877695497371648"},
+		{"0", "", "", ""},
+		{"-3", "", "", ""},
+		{"abc", "", "", ""},
+		{"", "", "", ""},
+		{"99999999999999999999", "", "", ""},
+		{generated, "", "", generated},
+		{strings.ToUpper(generated), "", "", generated},
+	}
+	for _, tt := range tests {
+		want := map[string]string{idStrategySerial: tt.serial, idStrategySnowflake: tt.snowflake, idStrategyUUIDv7: tt.uuidv7}
+		forEachIDStrategy(t, func(t *testing.T, strategy idStrategy) {
+			got, err := strategy.Parse(tt.raw)
+			switch expected := want[strategy.Name()]; {
+			case expected == "" && err == nil:
+				t.Errorf("Parse(%q) = %q, want it rejected", tt.raw, got)
+			case expected != "" && (err != nil || got != HeroID(expected)):
+				t.Errorf("Parse(%q) = %q, %v, want %q", tt.raw, got, err, expected)
+			}
+		})
+	}
+}
+
+func TestIDStrategyNext(t *testing.T) {
+	forEachIDStrategy(t, func(t *testing.T, strategy idStrategy) {
+		seen := make(map[HeroID]bool)
+		var previous int64
+		for i := 0; i < 5000; i++ {
+			id, ok := strategy.Next()
+			if strategy.Name() == idStrategySerial {
+				if ok {
+					t.Fatalf("Next() = %q, want the database to assign serial IDs", id)
+				}
+				return
+			}
+			if !ok || seen[id] {
+				t.Fatalf("Next() = %q, %v, want a new ID", id, ok)
+			}
+			seen[id] = true
+			if _, err := strategy.Parse(string(id)); err != nil {
+				t.Fatalf("Parse(Next()) = %v", err)
+			}
+
+			switch strategy.Name() {
+			case idStrategySnowflake:
+				value, _ := strconv.ParseInt(string(id), 10, 64)
+				if value <= previous {
+					t.Fatalf("snowflake %d is not after %d", value, previous)
+				}
+				previous = value
+				if node := value >> snowflakeSequenceBits & snowflakeMaxNode; node != 7 {
+					t.Fatalf("snowflake %d has node %d, want 7", value, node)
+				}
+			case idStrategyUUIDv7:
+				parsed := uuid.MustParse(string(id))
+				if parsed.Version() != 7 || parsed.Variant() != uuid.RFC4122 {
+					t.Fatalf("%s is version %d variant %s, want a version 7 RFC 4122 UUID", id, parsed.Version(), parsed.Variant())
+				}
+			}
+		}
+	})
+}
+
+func TestNewIDStrategyRejectsBadConfig(t *testing.T) {
+	for _, cfg := range []IDConfig{
+		{Strategy: "ulid"},
+		{Strategy: ""},
+		{Strategy: idStrategySnowflake, NodeID: -1},
+		{Strategy: idStrategySnowflake, NodeID: snowflakeMaxNode + 1},
+	} {
+		if _, err := newIDStrategy(cfg); err == nil {
+			t.Errorf("newIDStrategy(%+v) succeeded, want an error", cfg)
+		}
+	}
+}
+
+func TestHeroIDJSON(t *testing.T) {
+	const generated = "0190b2a4-5c3e-7d2f-8a1b-9c0d1e2f3a4b"
+	want := map[string]map[HeroID]string{
+		idStrategySerial:    {"42": `42`, generated: `"` + generated + `"`},
+		idStrategySnowflake: {"225877695497371648": `"225877695497371648"`, "42": `"42"`},
+		idStrategyUUIDv7:    {generated: `"` + generated + `"`, "42": `"42"`},
+	}
+	forEachIDStrategy(t, func(t *testing.T, strategy idStrategy) {
+		for id, encoded := range want[strategy.Name()] {
+			data, err := json.Marshal(id)
+			if err != nil || string(data) != encoded {
+				t.Errorf("Marshal(%q) = %s, %v, want %s", id, data, err, encoded)
+			}
+			var decoded HeroID
+			if err := json.Unmarshal(data, &decoded); err != nil || decoded != id {
+				t.Errorf("Unmarshal(%s) = %q, %v, want %q", data, decoded, err, id)
+			}
+		}
+	})
+}
+
+func TestHeroInsertIDColumn(t *testing.T) {
+	forEachIDStrategy(t, func(t *testing.T, strategy idStrategy) {
+		statement, args := heroInsert("Alucard", "Fighter", heroDifficulty{Label: "Sedang"}, HeroDetails{})
+		hasID := strings.HasPrefix(statement, "INSERT INTO heroes (id, ")
+		if hasID == (strategy.Name() == idStrategySerial) {
+			t.Errorf("statement %q: id column present = %v", statement, hasID)
+		}
+		if placeholders := strings.Count(statement, "$"); placeholders != len(args) {
+			t.Errorf("statement has %d placeholders for %d arguments", placeholders, len(args))
+		}
+		if hasID {
+			if _, err := strategy.Parse(string(args[0].(HeroID))); err != nil {
+				t.Errorf("generated id %v: %v", args[0], err)
+			}
+		}
+	})
+}
+
+// An ID for each strategy that only it accepts in a path, or "" when there's none
+var foreignIDs = map[string]string{
+	idStrategySerial:    "0190b2a4-5c3e-7d2f-8a1b-9c0d1e2f3a4b",
+	idStrategySnowflake: "0190b2a4-5c3e-7d2f-8a1b-9c0d1e2f3a4b",
+	idStrategyUUIDv7:    "",
+}
+
+func TestRouterHeroIDsUnderEachStrategy(t *testing.T) {
+	cfg := useTestConfig(t)
+	captureLogs(t)
+	adminToken := addTestSession(t, roleAdmin)
+
+	forEachIDStrategy(t, func(t *testing.T, strategy idStrategy) {
+		connector := useFakeDB(t)
+		connector.respond = func(query string, args []interface{}) *fakeResult {
+			switch {
+			case strings.HasPrefix(query, "INSERT INTO heroes (id, "):
+				return fakeHeroes(Hero{ID: HeroID(args[0].(string)), Name: "Alucard", Role: "Fighter", Difficulty: "Sedang"})
+			case strings.HasPrefix(query, "INSERT INTO heroes"):
+				return fakeHeroes(Hero{ID: "41", Name: "Alucard", Role: "Fighter", Difficulty: "Sedang"})
+			case strings.Contains(query, "FROM heroes"):
+				return fakeHeroes(Hero{ID: HeroID(args[0].(string)), Name: "Alucard"})
+			}
+			return respondNotRevoked(query, args)
+		}
+
+		// Create: the Location header and body carry the ID in the strategy's format
+		r := httptest.NewRequest(http.MethodPost, "/api/heroes", strings.NewReader(`{"name":"Alucard","role":"Fighter","difficulty":"Sedang"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Authorization", "Bearer "+adminToken)
+		rec := serve(cfg, r)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create: status = %d, want 201; body %s", rec.Code, rec.Body.String())
+		}
+		var created struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+			t.Fatal(err)
+		}
+		var id HeroID
+		if err := json.Unmarshal(created.ID, &id); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := strategy.Parse(string(id)); err != nil {
+			t.Errorf("created id %s: %v", created.ID, err)
+		}
+		if quoted := strings.HasPrefix(string(created.ID), `"`); quoted == (strategy.Name() == idStrategySerial) {
+			t.Errorf("created id %s: quoted = %v under %s", created.ID, quoted, strategy.Name())
+		}
+		if got, want := rec.Header().Get("Location"), "/api/heroes/"+id.String(); !strings.HasSuffix(got, want) {
+			t.Errorf("Location = %q, want it to end in %q", got, want)
+		}
+
+		// Read it back by the Location path
+		rec = serve(cfg, httptest.NewRequest(http.MethodGet, "/api/heroes/"+id.String(), nil))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"id":`+string(created.ID)) {
+			t.Errorf("get: status %d, body %s, want id %s", rec.Code, rec.Body.String(), created.ID)
+		}
+
+		// IDs of another strategy are rejected before reaching the database
+		if foreign := foreignIDs[strategy.Name()]; foreign != "" {
+			before := len(connector.executed())
+			rec = serve(cfg, httptest.NewRequest(http.MethodGet, "/api/heroes/"+foreign, nil))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("foreign id: status = %d, want 400", rec.Code)
+			}
+			if body := decodeError(t, rec); body.Code != ErrCodeInvalidHeroID {
+				t.Errorf("foreign id: code = %s, want %s", body.Code, ErrCodeInvalidHeroID)
+			}
+			if len(connector.executed()) != before {
+				t.Error("foreign id reached the database")
+			}
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Header used to echo back the confirmation token for destructive operations
+const confirmationHeader = "X-Confirmation-Token"
+
+// pendingConfirmation is a single-use token issued for a destructive operation
+type pendingConfirmation struct {
+	Caller    string
+	Operation string
+	ExpiresAt time.Time
+}
+
+// Destructive operation interlock
+var (
+	pendingConfirmations = make(map[string]pendingConfirmation)
+	confirmationMutex    sync.Mutex
+)
+
+// Audit rows of destructive operations are kept this long
+const destructiveAuditRetention = 365 * 24 * time.Hour
+
+// Outcomes stored in destructive_audit
+const (
+	auditRequested = "requested"
+	auditConfirmed = "confirmed"
+	auditRejected  = "rejected"
+	// auditUnconfirmed is a destructive operation run while the interlock is off
+	auditUnconfirmed = "unconfirmed"
+)
+
+var queryRecordDestructiveAudit = registerQuery("destructive_audit.record", `INSERT INTO destructive_audit (operation, username, request_id, outcome, affected)
+	VALUES ($1, $2, $3, $4, $5::jsonb)`, paramText, paramText, paramText, paramText, paramNullText)
+
+// initDestructiveAudit registers destructive_audit for pruning
+func initDestructiveAudit() {
+	registerManagedTable("destructive_audit", "created_at", destructiveAuditRetention)
+}
+
+// persistDestructiveAudit stores one step of a destructive operation in
+// destructive_audit. A failed insert is logged but doesn't stop the operation.
+func persistDestructiveAudit(r *http.Request, operation, outcome string, affected AffectedRows) {
+	session, _ := sessionFromRequest(r)
+	requestID := ""
+	if entry := requestLogFrom(r.Context()); entry != nil {
+		requestID = entry.ID
+	}
+
+	var affectedJSON *string
+	if affected != nil {
+		data, err := json.Marshal(affected)
+		if err != nil {
+			requestLogger(r).Error("Failed to encode destructive operation audit", "operation", operation, "error", err)
+			return
+		}
+		encoded := string(data)
+		affectedJSON = &encoded
+	}
+
+	_, err := queryRecordDestructiveAudit.WithContext(r.Context()).Exec(operation, session.Username, requestID, outcome, affectedJSON)
+	if err != nil {
+		requestLogger(r).Error("Failed to store destructive operation audit", "operation", operation, "outcome", outcome, "error", err)
+	}
+}
+
+// describeFunc reports the rows a destructive operation would affect, keyed by table
+type describeFunc func(r *http.Request) (AffectedRows, error)
+
+// Destructive middleware requiring a two-step confirmation before running next
+// when DESTRUCTIVE_CONFIRMATION is on. The first call returns 428 with a
+// confirmation token; the operation proceeds only when the same caller repeats
+// the exact request with that token. Every step is stored in destructive_audit.
+func destructiveMiddleware(describe describeFunc, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operation := describeOperation(r)
+		if !config.Destructive.Confirmation {
+			requestLogger(r).Info("AUDIT destructive operation run without confirmation", "operation", operation)
+			persistDestructiveAudit(r, operation, auditUnconfirmed, nil)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		caller := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if token := r.Header.Get(confirmationHeader); token != "" {
+			if !consumeConfirmation(token, caller, operation) {
+				requestLogger(r).Warn("AUDIT destructive operation rejected, invalid confirmation token", "operation", operation)
+				persistDestructiveAudit(r, operation, auditRejected, nil)
+				respondWithError(w, r, http.StatusPreconditionRequired, ErrCodeInvalidConfirmation, "Invalid or expired confirmation token")
+				return
+			}
+
+			requestLogger(r).Info("AUDIT destructive operation confirmed", "operation", operation)
+			persistDestructiveAudit(r, operation, auditConfirmed, nil)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		affected, err := describe(r)
+		if err != nil {
+			respondWithInternalError(w, r, err, "Failed to describe operation")
+			return
+		}
+
+		// Nothing would be affected, let the handler report it (e.g. 404)
+		total := 0
+		for _, count := range affected {
+			total += count
+		}
+		if total == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, expiresAt := issueConfirmation(caller, operation, config.Destructive.ConfirmationTTL)
+		requestLogger(r).Info("AUDIT destructive operation requested", "operation", operation, "affected", affected)
+		persistDestructiveAudit(r, operation, auditRequested, affected)
+
+		respondWith(w, r, http.StatusPreconditionRequired, ConfirmationRequiredResponse{
+			Code:              ErrCodeConfirmationRequired,
+			Error:             "Confirmation required",
+			Operation:         operation,
+			Affected:          affected,
+			ConfirmationToken: token,
+			ExpiresAt:         expiresAt,
+		})
+	})
+}
+
+// Build a canonical description of the request so a token is bound to its exact parameters
+func describeOperation(r *http.Request) string {
+	query := r.URL.Query()
+	query.Del("pretty")
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var params []string
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			params = append(params, key+"="+value)
+		}
+	}
+
+	operation := r.Method + " " + r.URL.Path
+	if len(params) > 0 {
+		operation += "?" + strings.Join(params, "&")
+	}
+	return operation
+}
+
+// Issue a confirmation token bound to the caller and operation
+func issueConfirmation(caller, operation string, ttl time.Duration) (string, time.Time) {
+	token := uuid.New().String()
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	confirmationMutex.Lock()
+	defer confirmationMutex.Unlock()
+
+	// Drop expired confirmations while we hold the lock
+	for key, pending := range pendingConfirmations {
+		if now.After(pending.ExpiresAt) {
+			delete(pendingConfirmations, key)
+		}
+	}
+
+	pendingConfirmations[token] = pendingConfirmation{
+		Caller:    caller,
+		Operation: operation,
+		ExpiresAt: expiresAt,
+	}
+	return token, expiresAt
+}
+
+// Consume a confirmation token; it is removed whether or not it matches
+func consumeConfirmation(token, caller, operation string) bool {
+	confirmationMutex.Lock()
+	pending, exists := pendingConfirmations[token]
+	delete(pendingConfirmations, token)
+	confirmationMutex.Unlock()
+
+	return exists &&
+		pending.Caller == caller &&
+		pending.Operation == operation &&
+		time.Now().Before(pending.ExpiresAt)
+}
+
+// Counts the hero a delete would remove
+var queryHeroExists = registerQuery("heroes.exists", "SELECT COUNT(*) FROM heroes WHERE id = $1", paramHeroID)
+
+// Describe the rows removed by DELETE /api/heroes/{id}
+func describeHeroDelete(r *http.Request) (AffectedRows, error) {
+	id, err := heroIDs.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		// Let the handler reject the malformed ID
+		return AffectedRows{}, nil
+	}
+
+	var count int
+	if err := queryHeroExists.WithContext(r.Context()).QueryRow(id).Scan(&count); err != nil {
+		return nil, err
+	}
+	return AffectedRows{"heroes": count}, nil
+}
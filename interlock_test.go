@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDestructiveMiddlewareAudit(t *testing.T) {
+	useTestConfig(t)
+	captureLogs(t)
+	database := useFakeDB(t)
+
+	ran := 0
+	handler := requestLogMiddleware(destructiveMiddleware(
+		func(r *http.Request) (AffectedRows, error) { return AffectedRows{"heroes": 1}, nil },
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ran++
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	))
+	send := func(bearer, confirmation string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodDelete, "/api/heroes/1", nil)
+		r.Header.Set("Authorization", "Bearer "+bearer)
+		r.Header.Set(requestIDHeader, "req-"+bearer)
+		if confirmation != "" {
+			r.Header.Set(confirmationHeader, confirmation)
+		}
+		ctx := context.WithValue(r.Context(), sessionContextKey, Session{Username: bearer + "-user"})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r.WithContext(ctx))
+		return rec
+	}
+	requestToken := func(bearer string) string {
+		t.Helper()
+		rec := send(bearer, "")
+		if rec.Code != http.StatusPreconditionRequired {
+			t.Fatalf("status = %d, want 428", rec.Code)
+		}
+		var body ConfirmationRequiredResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.ConfirmationToken == "" {
+			t.Fatalf("428 body %s has no confirmation token: %v", rec.Body.String(), err)
+		}
+		return body.ConfirmationToken
+	}
+
+	config.Destructive.Confirmation = true
+
+	// Another caller can't use the token, and it is spent afterwards
+	token := requestToken("alice")
+	if rec := send("mallory", token); rec.Code != http.StatusPreconditionRequired {
+		t.Fatalf("foreign token: status = %d, want 428", rec.Code)
+	} else if body := decodeError(t, rec); body.Code != ErrCodeInvalidConfirmation {
+		t.Errorf("foreign token: code = %s, want %s", body.Code, ErrCodeInvalidConfirmation)
+	}
+	if rec := send("alice", token); rec.Code != http.StatusPreconditionRequired {
+		t.Fatalf("spent token: status = %d, want 428", rec.Code)
+	}
+
+	if rec := send("alice", requestToken("alice")); rec.Code != http.StatusNoContent {
+		t.Fatalf("confirmed: status = %d, want 204", rec.Code)
+	}
+
+	config.Destructive.Confirmation = false
+	if rec := send("bob", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("interlock off: status = %d, want 204", rec.Code)
+	}
+	if ran != 2 {
+		t.Errorf("operation ran %d times, want 2", ran)
+	}
+
+	affected := `{"heroes":1}`
+	want := []struct {
+		username, requestID, outcome string
+		affected                     interface{}
+	}{
+		{"alice-user", "req-alice", auditRequested, affected},
+		{"mallory-user", "req-mallory", auditRejected, nil},
+		{"alice-user", "req-alice", auditRejected, nil},
+		{"alice-user", "req-alice", auditRequested, affected},
+		{"alice-user", "req-alice", auditConfirmed, nil},
+		{"bob-user", "req-bob", auditUnconfirmed, nil},
+	}
+	execs := database.executed()
+	if len(execs) != len(want) {
+		t.Fatalf("%d audit rows stored, want %d: %v", len(execs), len(want), execs)
+	}
+	for i, exec := range execs {
+		if exec.query != queryRecordDestructiveAudit.SQL {
+			t.Fatalf("statement %d = %q, want the audit insert", i, exec.query)
+		}
+		got := exec.args
+		if got[0] != "DELETE /api/heroes/1" || got[1] != want[i].username || got[2] != want[i].requestID ||
+			got[3] != want[i].outcome || got[4] != want[i].affected {
+			t.Errorf("audit row %d = %v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestDestructiveConfirmationOffByDefault(t *testing.T) {
+	if defaultAppConfig().Destructive.Confirmation {
+		t.Error("destructive confirmation is on by default, which breaks single-step clients")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LockoutConfig limits consecutive failed password checks per username
+type LockoutConfig struct {
+	MaxAttempts int           `yaml:"max_attempts"`
+	Duration    time.Duration `yaml:"duration"`
+}
+
+// failedAttempts tracks consecutive failures for one username
+type failedAttempts struct {
+	count       int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// Brute-force lockout shared by login and password change
+var (
+	passwordFailures = make(map[string]*failedAttempts)
+	lockoutMutex     sync.Mutex
+)
+
+// lockedOut reports whether username is locked and for how much longer
+func lockedOut(username string) (bool, time.Duration) {
+	lockoutMutex.Lock()
+	defer lockoutMutex.Unlock()
+
+	attempts, exists := passwordFailures[username]
+	if !exists {
+		return false, 0
+	}
+
+	remaining := time.Until(attempts.lockedUntil)
+	if remaining <= 0 {
+		return false, 0
+	}
+	return true, remaining
+}
+
+// recordPasswordFailure counts a wrong password and locks the username once
+// MaxAttempts consecutive failures are reached
+func recordPasswordFailure(username string) {
+	if config.Lockout.MaxAttempts < 1 {
+		return
+	}
+
+	lockoutMutex.Lock()
+	defer lockoutMutex.Unlock()
+
+	attempts, exists := passwordFailures[username]
+	if !exists {
+		attempts = &failedAttempts{}
+		passwordFailures[username] = attempts
+	}
+
+	attempts.count++
+	attempts.lastFailure = time.Now()
+	if attempts.count >= config.Lockout.MaxAttempts {
+		attempts.count = 0
+		attempts.lockedUntil = time.Now().Add(config.Lockout.Duration)
+	}
+}
+
+// respondLockedOut rejects a request for a locked username with 429 and Retry-After
+func respondLockedOut(w http.ResponseWriter, r *http.Request, remaining time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(remaining.Seconds()))))
+	respondWithError(w, r, http.StatusTooManyRequests, ErrCodeAccountLocked, "Too many failed password attempts, try again later")
+}
+
+// resetPasswordFailures clears the failure count after a correct password
+func resetPasswordFailures(username string) {
+	lockoutMutex.Lock()
+	delete(passwordFailures, username)
+	lockoutMutex.Unlock()
+}
+
+// pruneLockouts forgets usernames that are not locked and have not failed for a lockout period
+func pruneLockouts() {
+	lockoutMutex.Lock()
+	defer lockoutMutex.Unlock()
+
+	now := time.Now()
+	for username, attempts := range passwordFailures {
+		if now.After(attempts.lockedUntil) && now.Sub(attempts.lastFailure) > config.Lockout.Duration {
+			delete(passwordFailures, username)
+		}
+	}
+}
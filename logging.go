@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Supported log output formats
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// Header carrying the request ID, accepted from clients and proxies and echoed back
+const requestIDHeader = "X-Request-ID"
+
+// LoggingConfig selects the log level and output format
+type LoggingConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// parseLogLevel maps debug, info, warn or error to a slog level
+func parseLogLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("log level %q must be debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+// newLogHandler builds the slog handler for cfg writing to out
+func newLogHandler(cfg LoggingConfig, out io.Writer) (slog.Handler, error) {
+	level, err := parseLogLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(cfg.Format) {
+	case logFormatText:
+		return slog.NewTextHandler(out, options), nil
+	case logFormatJSON:
+		return slog.NewJSONHandler(out, options), nil
+	}
+	return nil, fmt.Errorf("log format %q must be %s or %s", cfg.Format, logFormatText, logFormatJSON)
+}
+
+// initLogging installs the configured handler as the default logger. Output
+// of the standard log package goes through it as well, at info level.
+func initLogging(cfg LoggingConfig) error {
+	handler, err := newLogHandler(cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// fatal logs err at error level and exits
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	os.Exit(1)
+}
+
+// durationMs converts d to fractional milliseconds for the duration_ms key
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// requestLog is the per-request state shared with the logging middleware.
+// authMiddleware fills in the user once the token is checked.
+type requestLog struct {
+	ID   string
+	User string
+}
+
+// Context key for the request's *requestLog
+const requestLogContextKey contextKey = "request_log"
+
+// requestLogFrom returns the request's log state, or nil outside requestLogMiddleware
+func requestLogFrom(ctx context.Context) *requestLog {
+	entry, _ := ctx.Value(requestLogContextKey).(*requestLog)
+	return entry
+}
+
+// requestLogger returns the default logger annotated with the request ID,
+// the authenticated user and the hero in the path, where known
+func requestLogger(r *http.Request) *slog.Logger {
+	logger := contextLogger(r.Context())
+	if id := mux.Vars(r)["id"]; id != "" {
+		logger = logger.With("hero_id", id)
+	}
+	return logger
+}
+
+// contextLogger returns the default logger tagged with the request ID and user
+// carried by ctx, for work like detached effects that has no *http.Request
+func contextLogger(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	if entry := requestLogFrom(ctx); entry != nil {
+		logger = logger.With("request_id", entry.ID)
+		if entry.User != "" {
+			logger = logger.With("user", entry.User)
+		}
+	}
+	return logger
+}
+
+// requestLogMiddleware assigns each request an ID, returned in X-Request-ID,
+// and logs one entry per request once it completes. Debug routes are skipped.
+func requestLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isDebugRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		started := time.Now()
+
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		entry := &requestLog{ID: id}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), requestLogContextKey, entry)))
+
+		attrs := []any{
+			"request_id", entry.ID,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"duration_ms", durationMs(time.Since(started)),
+		}
+		if entry.User != "" {
+			attrs = append(attrs, "user", entry.User)
+		}
+
+		level := slog.LevelInfo
+		if recorder.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(r.Context(), level, "Request", attrs...)
+	})
+}
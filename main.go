@@ -1,16 +1,17 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
-	_ "mobile-legends-api/docs"
-
-	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
-	httpSwagger "github.com/swaggo/http-swagger"
+	"gopkg.in/yaml.v3"
 )
 
 // @title Mobile Legends Heroes API
@@ -26,110 +27,205 @@ import (
 // @license.url https://opensource.org/licenses/MIT
 
 // @host localhost:8080
-// @BasePath /api
+// @BasePath /
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 
 func main() {
+	printConfig := flag.Bool("print-config", false, "print the effective configuration (secrets redacted) and exit")
+	configFlag := flag.String("config", "", "configuration file to load (default $CONFIG_FILE, then config.yaml)")
+	flag.Parse()
+
 	// Load environment variables
 	if err := godotenv.Load("config.env"); err != nil {
-		log.Println("No .env file found, using system environment variables")
+		slog.Info("No .env file found, using system environment variables")
 	}
 
-	// Load configuration
-	if err := loadConfig(); err != nil {
-		log.Fatalf("Error loading config: %v", err)
+	// Load configuration; a file named explicitly must exist
+	configPath, explicit := configFile(*configFlag)
+	_, statErr := os.Stat(configPath)
+	if statErr != nil && explicit {
+		fatal("Error loading config", statErr)
+	}
+	cfg, err := LoadConfig(configPath)
+	if *printConfig {
+		out, marshalErr := yaml.Marshal(cfg.Redacted())
+		if marshalErr != nil {
+			fatal("Error printing config", marshalErr)
+		}
+		fmt.Print(string(out))
+		if err != nil {
+			fatal("Invalid configuration", err)
+		}
+		return
 	}
+	if err != nil {
+		fatal("Error loading config", err)
+	}
+	config = cfg
+	if err := initLogging(config.Logging); err != nil {
+		fatal("Error configuring logging", err)
+	}
+	if statErr == nil {
+		slog.Info("Configuration loaded", "file", configPath)
+	}
+	setDisplayOrder(config.DisplayOrder)
+	tlsConfig := config.TLS
+
+	setSlowQueryThreshold(config.Database.SlowQueryThreshold)
+	dbPool.configure(config.Database.BreakerThreshold, config.Database.BreakerCooldown)
+	replicaPool.configure(config.Database.BreakerThreshold, config.Database.BreakerCooldown)
 
 	// Initialize database
-	if err := InitDB(); err != nil {
-		log.Fatalf("Error initializing database: %v", err)
+	if err := InitDB(config.Database); err != nil {
+		fatal("Error initializing database", err)
 	}
-	defer DB.Close()
+	defer CloseDB()
 
 	// Create tables and insert initial data
-	if err := CreateTables(); err != nil {
-		log.Fatalf("Error creating tables: %v", err)
+	if err := CreateTables(config.Database); err != nil {
+		fatal("Error creating tables", err)
 	}
 
-	if err := InsertInitialData(); err != nil {
-		log.Fatalf("Error inserting initial data: %v", err)
+	if err := checkSchemaVersion(config.Database.SchemaVersionOverride); err != nil {
+		fatal("Error checking schema version", err)
 	}
 
-	// Start token cleanup goroutine
-	go cleanExpiredTokens()
-
-	// Create router
-	router := mux.NewRouter()
-
-	// Apply CORS middleware
-	router.Use(corsMiddleware)
-
-	// Swagger documentation
-	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
-
-	// API routes
-	api := router.PathPrefix("/api").Subrouter()
-
-	// Authentication routes (no auth required)
-	api.HandleFunc("/login", login).Methods("POST")
-	api.HandleFunc("/logout", logout).Methods("POST")
-
-	// Heroes routes
-	api.HandleFunc("/heroes", getHeroes).Methods("GET")
-	api.HandleFunc("/heroes/{id}", getHeroByID).Methods("GET")
-	api.HandleFunc("/heroes", authMiddleware(http.HandlerFunc(createHero)).ServeHTTP).Methods("POST")
-	api.HandleFunc("/heroes/{id}", authMiddleware(http.HandlerFunc(updateHero)).ServeHTTP).Methods("PUT")
-	api.HandleFunc("/heroes/{id}", authMiddleware(http.HandlerFunc(deleteHero)).ServeHTTP).Methods("DELETE")
+	if err := initHeroIDs(config.IDs); err != nil {
+		fatal("Error configuring hero IDs", err)
+	}
 
-	// Handle OPTIONS requests for all routes
-	api.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+	if schemaReady {
+		if err := InsertInitialData(config.Database); err != nil {
+			fatal("Error inserting initial data", err)
 		}
-	}).Methods("OPTIONS")
-
-	api.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+		if err := SeedUsers(config.Users); err != nil {
+			fatal("Error importing users", err)
 		}
-	}).Methods("OPTIONS")
-
-	api.HandleFunc("/heroes", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+		if config.AdminUser != nil {
+			if err := ensureAdminUser(*config.AdminUser); err != nil {
+				fatal("Error importing ADMIN_USER", err)
+			}
 		}
-	}).Methods("OPTIONS")
-
-	api.HandleFunc("/heroes/{id}", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+		if err := loadMaintenanceMode(); err != nil {
+			fatal("Error loading maintenance mode", err)
 		}
-	}).Methods("OPTIONS")
+	}
+
+	initHeroCache(config.Cache)
 
-	// Get server port from environment
-	port := os.Getenv("SERVER_PORT")
-	if port == "" {
-		port = "8080"
+	if err := initRateLimits(config.RateLimits); err != nil {
+		fatal("Error configuring rate limits", err)
+	}
+	initClockSkew(config.Clock)
+
+	// Cancelled on SIGINT/SIGTERM to shut down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start token cleanup goroutine
+	go cleanExpiredTokens()
+	viewsFlushed := initHeroViews(ctx)
+	initHeroEventListener(ctx, config.Database)
+	initIdempotencyKeys()
+	initDestructiveAudit()
+	if schemaReady {
+		go watchUserReload(ctx, configPath, config.Users)
 	}
 
+	router := NewRouter(config)
+
+	port := config.Server.Port
+
 	// Start server
-	fmt.Printf("Server starting on port %s...\n", port)
-	fmt.Println("Available endpoints:")
+	scheme := "http"
+	if tlsConfig.Enabled() {
+		scheme = "https"
+	}
+	fmt.Printf("Server %s (%s) starting on port %s (%s)...\n", version, commit, port, scheme)
+	if base := config.Server.BasePath; base != "" {
+		fmt.Printf("Available endpoints (all under BASE_PATH %s):\n", base)
+	} else {
+		fmt.Println("Available endpoints:")
+	}
 	fmt.Println("  POST   /api/login      - Login")
 	fmt.Println("  POST   /api/logout     - Logout")
-	fmt.Println("  GET    /api/heroes     - Get all heroes")
-	fmt.Println("  GET    /api/heroes/{id} - Get hero by ID")
+	fmt.Println("  GET    /api/heroes     - Get all heroes (HEAD supported)")
+	fmt.Println("  GET    /api/heroes/search?q= - Search heroes")
+	fmt.Println("  GET    /api/heroes/trending?window=7d - Most viewed heroes")
+	fmt.Println("  GET    /api/heroes/draft?exclude=1,2 - Suggest a team with one hero per role")
+	fmt.Println("  GET    /api/heroes/events - Stream hero changes (SSE)")
+	fmt.Println("  GET    /api/heroes/{id} - Get hero by ID (HEAD supported)")
+	fmt.Println("  GET    /api/heroes/{id}/exists - Check whether a hero exists")
 	fmt.Println("  POST   /api/heroes     - Create new hero (Auth Required)")
 	fmt.Println("  PUT    /api/heroes/{id} - Update hero (Auth Required)")
+	fmt.Println("  PATCH  /api/heroes/{id} - Merge-patch hero (Auth Required)")
+	fmt.Println("  PATCH  /api/heroes/roles - Reassign the roles of many heroes at once (Auth Required)")
+	fmt.Println("  PUT    /api/heroes/by-name/{name} - Create or update hero by name (Auth Required)")
 	fmt.Println("  DELETE /api/heroes/{id} - Delete hero (Auth Required)")
-	fmt.Printf("  Swagger UI: http://localhost:%s/swagger/\n", port)
+	fmt.Println("  PUT    /api/heroes/{id}/tier - Set hero tier for a patch (Auth Required)")
+	fmt.Println("  PUT    /api/heroes/{id}/difficulty - Change only the difficulty (Auth Required)")
+	fmt.Println("  PUT    /api/heroes/{id}/tags/{tag} - Tag hero (Auth Required)")
+	fmt.Println("  DELETE /api/heroes/{id}/tags/{tag} - Untag hero (Auth Required)")
+	fmt.Println("  GET    /api/heroes/{id}/counters - Heroes that counter this hero")
+	fmt.Println("  POST   /api/heroes/{id}/counters - Add a counter (Auth Required)")
+	fmt.Println("  DELETE /api/heroes/{id}/counters/{counterId} - Remove a counter (Auth Required)")
+	fmt.Println("  POST   /api/heroes/{id}/clone - Duplicate hero (Auth Required)")
+	fmt.Println("  POST   /api/heroes/{id}/archive - Archive hero (Auth Required)")
+	fmt.Println("  POST   /api/heroes/{id}/unarchive - Unarchive hero (Auth Required)")
+	fmt.Println("  POST   /api/heroes/{id}/soft-delete - Soft-delete hero (Admin)")
+	fmt.Println("  POST   /api/heroes/{id}/restore - Restore soft-deleted hero (Admin)")
+	fmt.Println("  GET    /api/tierlist?patch= - Heroes grouped by tier")
+	fmt.Println("  GET    /api/roles      - Get roles with hero counts (ETag)")
+	fmt.Println("  GET    /api/difficulties - Get difficulties with hero counts (ETag)")
+	fmt.Println("  GET    /api/schema/hero - JSON Schema of heroes")
+	fmt.Println("  POST   /api/me/password - Change own password (Auth Required)")
+	fmt.Println("  GET    /api/me/favorites - Own favorite heroes (Auth Required)")
+	fmt.Println("  POST   /api/heroes/{id}/favorite - Favorite hero (Auth Required)")
+	fmt.Println("  DELETE /api/heroes/{id}/favorite - Unfavorite hero (Auth Required)")
+	fmt.Println("  GET    /api/users      - List users (Admin)")
+	fmt.Println("  POST   /api/users      - Create user (Admin)")
+	fmt.Println("  PUT    /api/users/{id} - Update user (Admin)")
+	fmt.Println("  DELETE /api/users/{id} - Delete user (Admin)")
+	fmt.Println("  POST   /api/users/{id}/password - Reset user password (Admin)")
+	fmt.Println("  GET    /api/sessions   - List active sessions (Admin)")
+	fmt.Println("  DELETE /api/sessions/{id} - Revoke a session (Admin)")
+	fmt.Println("  DELETE /api/sessions?user= - Revoke all sessions of a user (Admin)")
+	fmt.Println("  GET    /api/admin/storage - Storage report (Admin)")
+	fmt.Println("  PUT    /api/admin/display-order - Change role/difficulty display order (Admin)")
+	fmt.Println("  POST   /api/admin/storage/prune - Prune managed tables (Admin)")
+	fmt.Println("  GET    /api/admin/export - Export heroes, tags, counters and tiers (Admin)")
+	fmt.Println("  POST   /api/admin/import?mode=replace|merge - Restore an export (Admin)")
+	fmt.Println("  GET    /api/admin/db-pool - Database pool statistics (Admin)")
+	fmt.Println("  GET    /api/admin/queries - Registered SQL queries and call counts (Admin)")
+	fmt.Println("  GET    /api/admin/sessions - Active token count and sessions (Admin)")
+	fmt.Println("  GET    /api/admin/cache - Hero cache hits and misses (Admin)")
+	fmt.Println("  POST   /api/admin/logout-all - Revoke every session, or ?user= only (Admin)")
+	fmt.Println("  GET    /api/admin/maintenance - Maintenance mode status (Admin)")
+	fmt.Println("  POST   /api/admin/maintenance - Turn maintenance mode on or off (Admin)")
+	fmt.Println("  GET    /health/live    - Liveness probe")
+	fmt.Println("  GET    /health/ready   - Readiness probe")
+	fmt.Println("  GET    /version        - Build information")
+	fmt.Printf("  Swagger UI: %s://localhost:%s%s/swagger/\n", scheme, port, config.Server.BasePath)
+	if config.Debug.DevEndpoints {
+		fmt.Println("  ENABLE_DEV_ENDPOINTS is on:")
+		fmt.Println("  POST   /api/admin/reset?seed=true - Empty heroes and reseed the starter roster (Admin)")
+	}
+	if config.Debug.Pprof {
+		fmt.Println("  DEBUG_PPROF is on:")
+		fmt.Println("  GET    /debug/pprof/   - Go profiles (heap, goroutine, profile, trace, ...) (Admin)")
+		fmt.Println("  GET    /debug/vars     - Goroutines, heap, GC pauses and database pool stats (Admin)")
+	}
+
+	slog.Info("Server starting", "port", port, "tls", tlsConfig.Enabled(), "log_level", config.Logging.Level)
+	if err := startServer(ctx, config.Server, requestLogMiddleware(router), tlsConfig); err != nil && err != http.ErrServerClosed {
+		fatal("Server failed", err)
+	}
 
-	log.Fatal(http.ListenAndServe(":"+port, router))
+	// Keep buffered hero views
+	stop()
+	<-viewsFlushed
+	slog.Info("Server stopped")
 }
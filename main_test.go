@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+)
+
+var checkCoverage = flag.Bool("query-coverage", false, "fail unless the tests ran enough of the registered queries")
+
+// TestMain runs the tests, then reports which registered queries they ran
+// against the fake database
+func TestMain(m *testing.M) {
+	flag.Parse()
+	code := m.Run()
+
+	report := queryCoverage()
+	if testing.Verbose() || *checkCoverage {
+		fmt.Printf("query coverage: %d of %d registered queries executed (%.0f%%)\n", report.Executed, report.Registered, report.Coverage*100)
+	}
+	if code == 0 && *checkCoverage {
+		if err := checkQueryCoverage(queryCoverageThreshold); err != nil {
+			fmt.Println(err)
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// Maintenance mode defaults
+const (
+	defaultMaintenanceMessage    = "The API is in maintenance mode, changes are temporarily disabled"
+	defaultMaintenanceRetryAfter = 60
+)
+
+// Queries on the single maintenance_mode row
+var (
+	queryGetMaintenance = registerQuery("maintenance_mode.get",
+		"SELECT enabled, message, retry_after_seconds, changed_by, changed_at FROM maintenance_mode WHERE id")
+	querySetMaintenance = registerQuery("maintenance_mode.set", `INSERT INTO maintenance_mode (id, enabled, message, retry_after_seconds, changed_by, changed_at)
+		VALUES (TRUE, $1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (id) DO UPDATE SET enabled = EXCLUDED.enabled, message = EXCLUDED.message,
+			retry_after_seconds = EXCLUDED.retry_after_seconds, changed_by = EXCLUDED.changed_by, changed_at = EXCLUDED.changed_at
+		RETURNING changed_at`, paramBool, paramText, paramInt, paramText)
+)
+
+// Current maintenance mode, read on every write request
+var maintenance atomic.Pointer[MaintenanceStatus]
+
+// Writes still accepted in maintenance mode, so admins can sign in and turn
+// it off; paths are relative to the API root
+var maintenanceExemptPaths = map[string]bool{
+	"/login":             true,
+	"/logout":            true,
+	"/admin/maintenance": true,
+}
+
+// maintenanceStatus returns the current maintenance mode
+func maintenanceStatus() MaintenanceStatus {
+	if status := maintenance.Load(); status != nil {
+		return *status
+	}
+	return MaintenanceStatus{}
+}
+
+// loadMaintenanceMode restores the maintenance mode saved before a restart.
+// Another instance's changes are only picked up at its next start.
+func loadMaintenanceMode() error {
+	var status MaintenanceStatus
+	var changedAt sql.NullTime
+	err := queryGetMaintenance.QueryRow().Scan(&status.Enabled, &status.Message, &status.RetryAfterSeconds, &status.ChangedBy, &changedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if changedAt.Valid {
+		status.ChangedAt = &changedAt.Time
+	}
+	maintenance.Store(&status)
+	return nil
+}
+
+// maintenanceMiddleware rejects requests that change data with a 503 while
+// maintenance mode is on; reads keep being served. The message is the one
+// the admin set, so it isn't replaced by a translation.
+func maintenanceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		status := maintenanceStatus()
+		if !status.Enabled || maintenanceExemptPaths[apiRoutePath(r)] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", strconv.Itoa(status.RetryAfterSeconds))
+		respondWithError(w, r, http.StatusServiceUnavailable, ErrCodeMaintenance, status.Message)
+	})
+}
+
+// GET /api/admin/maintenance - Maintenance mode status
+// @Summary Maintenance mode status
+// @Description Whether writes are rejected for maintenance, and who last changed it
+// @Tags admin
+// @Produce json,application/xml
+// @Success 200 {object} MaintenanceStatus
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/maintenance [get]
+func getMaintenance(w http.ResponseWriter, r *http.Request) {
+	respondWith(w, r, http.StatusOK, maintenanceStatus())
+}
+
+// POST /api/admin/maintenance - Turn maintenance mode on or off
+// @Summary Set maintenance mode
+// @Description While enabled, every request that changes data gets 503 MAINTENANCE with Retry-After and
+// @Description the given message; reads, login, logout and this endpoint keep working. The setting is
+// @Description saved and survives restarts.
+// @Tags admin
+// @Accept json
+// @Produce json,application/xml
+// @Param maintenance body MaintenanceRequest true "New maintenance mode"
+// @Success 200 {object} MaintenanceStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/maintenance [post]
+func setMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req MaintenanceRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.RetryAfterSeconds < 0 {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, "retry_after_seconds must not be negative")
+		return
+	}
+
+	status := MaintenanceStatus{Enabled: req.Enabled}
+	if req.Enabled {
+		status.Message = req.Message
+		if status.Message == "" {
+			status.Message = defaultMaintenanceMessage
+		}
+		status.RetryAfterSeconds = req.RetryAfterSeconds
+		if status.RetryAfterSeconds == 0 {
+			status.RetryAfterSeconds = defaultMaintenanceRetryAfter
+		}
+	}
+
+	session, _ := sessionFromRequest(r)
+	status.ChangedBy = session.Username
+
+	var changedAt sql.NullTime
+	if err := querySetMaintenance.WithContext(r.Context()).QueryRow(status.Enabled, status.Message, status.RetryAfterSeconds, status.ChangedBy).Scan(&changedAt); err != nil {
+		respondWithInternalError(w, r, err, "Failed to save maintenance mode")
+		return
+	}
+	if changedAt.Valid {
+		status.ChangedAt = &changedAt.Time
+	}
+	maintenance.Store(&status)
+
+	requestLogger(r).Info("AUDIT maintenance mode changed", "enabled", status.Enabled, "by", session.Username)
+	respondWith(w, r, http.StatusOK, status)
+}
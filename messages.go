@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Language used when Accept-Language names nothing in the catalog
+const defaultLanguage = "en"
+
+// Translated error messages keyed by language, then by error code. English is
+// the message passed to respondWithError, so only other languages live here.
+// A translation is generic per code, so the English message, which may name a
+// column or a limit, is kept as the detail. To add a language, add a map with
+// an entry per ErrCode constant.
+var errorMessages = map[string]map[string]string{
+	"id": {
+		ErrCodeInvalidPayload:       "Payload permintaan tidak valid",
+		ErrCodeBodyRequired:         "Body permintaan wajib diisi",
+		ErrCodeValidationFailed:     "Validasi gagal",
+		ErrCodeInvalidQuery:         "Parameter query tidak valid",
+		ErrCodeInvalidHeroID:        "ID hero tidak valid",
+		ErrCodeInvalidRole:          "Role tidak valid",
+		ErrCodeInvalidDifficulty:    "Tingkat kesulitan tidak valid",
+		ErrCodeHeroNotFound:         "Hero tidak ditemukan",
+		ErrCodeHeroNameTaken:        "Nama hero sudah dipakai",
+		ErrCodeUnauthorized:         "Autentikasi diperlukan",
+		ErrCodeInvalidToken:         "Token tidak valid atau sudah kedaluwarsa",
+		ErrCodeInvalidCredentials:   "Username atau password salah",
+		ErrCodeAccountLocked:        "Terlalu banyak percobaan password yang salah, coba lagi nanti",
+		ErrCodeSessionNotFound:      "Sesi tidak ditemukan",
+		ErrCodeInvalidUserID:        "ID user tidak valid",
+		ErrCodeUserNotFound:         "User tidak ditemukan",
+		ErrCodeUsernameTaken:        "Username sudah dipakai",
+		ErrCodeForbidden:            "Akses ditolak",
+		ErrCodeConfirmationRequired: "Konfirmasi diperlukan",
+		ErrCodeInvalidConfirmation:  "Token konfirmasi tidak valid atau sudah kedaluwarsa",
+		ErrCodeRateLimited:          "Terlalu banyak permintaan, coba lagi nanti",
+		ErrCodeServiceUnavailable:   "Layanan sedang tidak tersedia",
+		ErrCodeInternal:             "Terjadi kesalahan pada server",
+		ErrCodeNotAcceptable:        "Format response yang diminta tidak didukung",
+		ErrCodeIdempotencyConflict:  "Idempotency-Key sudah dipakai untuk permintaan lain atau masih diproses",
+		ErrCodeUnsupportedMediaType: "Content-Type tidak didukung",
+		ErrCodeMethodNotAllowed:     "Metode HTTP tidak diizinkan untuk resource ini",
+		ErrCodeNotFound:             "Resource tidak ditemukan",
+		ErrCodeConflict:             "Data bentrok dengan resource yang sudah ada",
+		ErrCodeValueTooLong:         "Nilai melebihi panjang maksimum",
+		ErrCodeMaintenance:          "Layanan sedang dalam pemeliharaan, coba lagi nanti",
+		ErrCodePayloadTooLarge:      "Body request terlalu besar",
+		ErrCodeRequestTimeout:       "Permintaan melebihi batas waktu",
+	},
+}
+
+// negotiateLanguage picks the supported language with the highest q-value in
+// Accept-Language, matching on the primary subtag ("id-ID" selects "id")
+func negotiateLanguage(r *http.Request) string {
+	best, bestQ := defaultLanguage, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		primary, _, _ := strings.Cut(tag, "-")
+		_, supported := errorMessages[primary]
+		if primary != defaultLanguage && !supported {
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = primary, q
+		}
+	}
+	return best
+}
+
+// localizeError returns the message for code in the request's language and
+// the English message as its detail, or the English message alone when there
+// is no translation
+func localizeError(r *http.Request, code, message string) (string, string, string) {
+	language := negotiateLanguage(r)
+	if translated, ok := errorMessages[language][code]; ok {
+		return translated, message, language
+	}
+	return message, "", defaultLanguage
+}
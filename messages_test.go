@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Every error code respondWithError can send
+var allErrorCodes = []string{
+	ErrCodeInvalidPayload, ErrCodeBodyRequired, ErrCodeValidationFailed, ErrCodeInvalidQuery,
+	ErrCodeInvalidHeroID, ErrCodeInvalidRole, ErrCodeInvalidDifficulty, ErrCodeHeroNotFound,
+	ErrCodeHeroNameTaken, ErrCodeUnauthorized, ErrCodeInvalidToken, ErrCodeInvalidCredentials,
+	ErrCodeAccountLocked, ErrCodeSessionNotFound, ErrCodeInvalidUserID, ErrCodeUserNotFound,
+	ErrCodeUsernameTaken, ErrCodeForbidden, ErrCodeConfirmationRequired, ErrCodeInvalidConfirmation,
+	ErrCodeRateLimited, ErrCodeServiceUnavailable, ErrCodeInternal, ErrCodeNotAcceptable,
+	ErrCodeIdempotencyConflict, ErrCodeUnsupportedMediaType, ErrCodeMethodNotAllowed, ErrCodeNotFound,
+	ErrCodeConflict, ErrCodeValueTooLong, ErrCodeMaintenance, ErrCodePayloadTooLarge,
+	ErrCodeRequestTimeout,
+}
+
+func TestErrorMessageCatalogsAreComplete(t *testing.T) {
+	for language, messages := range errorMessages {
+		for _, code := range allErrorCodes {
+			if messages[code] == "" {
+				t.Errorf("%s catalog has no message for %s", language, code)
+			}
+		}
+		if len(messages) != len(allErrorCodes) {
+			t.Errorf("%s catalog has %d messages, want %d", language, len(messages), len(allErrorCodes))
+		}
+	}
+}
+
+func TestRespondWithErrorKeepsDetailWhenTranslating(t *testing.T) {
+	useTestConfig(t)
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		wantError      string
+		wantDetail     string
+		wantLanguage   string
+	}{
+		{"english", "", "role is required", "", "en"},
+		{"indonesian", "id-ID,id;q=0.9", "Validasi gagal", "role is required", "id"},
+		{"unsupported language", "fr", "role is required", "", "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/heroes", nil)
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			rec := httptest.NewRecorder()
+			respondWithHeroWriteError(rec, r, errNotNullColumn, "Failed to create hero")
+
+			body := decodeError(t, rec)
+			if body.Code != ErrCodeValidationFailed || body.Error != tt.wantError || body.Message != tt.wantDetail {
+				t.Errorf("error = %+v, want %s %q with detail %q", body, ErrCodeValidationFailed, tt.wantError, tt.wantDetail)
+			}
+			if got := rec.Header().Get("Content-Language"); got != tt.wantLanguage {
+				t.Errorf("Content-Language = %q, want %q", got, tt.wantLanguage)
+			}
+		})
+	}
+}
+
+func TestMaintenanceErrorIsTranslated(t *testing.T) {
+	useTestConfig(t)
+	previous := maintenance.Load()
+	t.Cleanup(func() { maintenance.Store(previous) })
+	maintenance.Store(&MaintenanceStatus{Enabled: true, Message: "Upgrading the database until 14:00 UTC", RetryAfterSeconds: 60})
+
+	handler := maintenanceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("write reached the handler during maintenance")
+	}))
+	r := httptest.NewRequest(http.MethodPost, "/api/heroes", nil)
+	r.Header.Set("Accept-Language", "id")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	body := decodeError(t, rec)
+	if body.Code != ErrCodeMaintenance || body.Error != errorMessages["id"][ErrCodeMaintenance] {
+		t.Errorf("error = %s %q, want the Indonesian maintenance message", body.Code, body.Error)
+	}
+	if body.Message != "Upgrading the database until 14:00 UTC" {
+		t.Errorf("detail = %q, want the operator's message", body.Message)
+	}
+	if got := rec.Header().Get("Content-Language"); got != "id" {
+		t.Errorf("Content-Language = %q, want id", got)
+	}
+}
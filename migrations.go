@@ -0,0 +1,187 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema migrations embedded in the binary, named NNNN_description.sql
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Schema gate state, set once at startup before the server accepts requests
+var (
+	schemaReady   bool
+	schemaMessage = "schema version not checked"
+)
+
+// loadMigrations reads the embedded migrations sorted by version
+func loadMigrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, _, found := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if !found || err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		if other, exists := seen[version]; exists {
+			return nil, fmt.Errorf("migrations %q and %q share version %d", other, name, version)
+		}
+		seen[version] = name
+
+		data, err := migrationFiles.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(data)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// requiredSchemaVersion is the newest migration embedded in this binary
+func requiredSchemaVersion() (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// Queries maintaining schema_migrations; migration files run as built queries
+var (
+	querySchemaVersion   = registerQuery("migrations.version", "SELECT COALESCE(MAX(version), 0) FROM schema_migrations")
+	queryMigrationsTable = registerQuery("migrations.create_table", `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	queryApplyMigration  = registerBuiltQuery("migrations.apply")
+	queryRecordMigration = registerQuery("migrations.record", "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", paramInt, paramText)
+)
+
+// currentSchemaVersion reads the highest applied version from schema_migrations
+func currentSchemaVersion() (int, error) {
+	var version int
+	err := querySchemaVersion.QueryRow().Scan(&version)
+	return version, err
+}
+
+// ensureMigrationsTable creates the schema_migrations bookkeeping table
+func ensureMigrationsTable() error {
+	_, err := queryMigrationsTable.Exec()
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+	return nil
+}
+
+// runMigrations applies every embedded migration newer than the database, each in its own transaction
+func runMigrations() error {
+	if err := ensureMigrationsTable(); err != nil {
+		return err
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	current, err := currentSchemaVersion()
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %v", err)
+	}
+
+	// A newer build already migrated this database; leave it for the version gate
+	if len(migrations) > 0 && current > migrations[len(migrations)-1].Version {
+		return nil
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			continue
+		}
+
+		tx, err := DB.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := queryApplyMigration.Build(migration.SQL).In(tx).Exec(); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %s failed: %v", migration.Name, err)
+		}
+		if _, err := queryRecordMigration.In(tx).Exec(migration.Version, migration.Name); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %s: %v", migration.Name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+
+		slog.Info("Applied migration", "migration", migration.Name)
+	}
+
+	return nil
+}
+
+// checkSchemaVersion compares the database schema with what this binary expects
+// and records the result for the readiness check and the schema gate middleware.
+func checkSchemaVersion(override bool) error {
+	required, err := requiredSchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if err := ensureMigrationsTable(); err != nil {
+		return err
+	}
+
+	current, err := currentSchemaVersion()
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %v", err)
+	}
+
+	switch {
+	case current < required:
+		schemaMessage = fmt.Sprintf("database schema version %d is older than required version %d", current, required)
+	case current > required:
+		schemaMessage = fmt.Sprintf("database schema version %d is newer than this build knows (%d)", current, required)
+	default:
+		schemaReady = true
+		schemaMessage = fmt.Sprintf("schema version %d", current)
+		return nil
+	}
+
+	if override {
+		slog.Warn("Schema version mismatch, serving anyway because SCHEMA_VERSION_OVERRIDE is set", "schema", schemaMessage)
+		schemaReady = true
+		return nil
+	}
+
+	slog.Error("Refusing to serve API requests", "schema", schemaMessage)
+	return nil
+}
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS heroes (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	role VARCHAR(100) NOT NULL,
+	difficulty VARCHAR(100) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create trigger to update updated_at column
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+	NEW.updated_at = CURRENT_TIMESTAMP;
+	RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS update_heroes_updated_at ON heroes;
+CREATE TRIGGER update_heroes_updated_at
+	BEFORE UPDATE ON heroes
+	FOR EACH ROW
+	EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,3 @@
+-- Lifecycle markers used by the visibility policy; NULL means active
+ALTER TABLE heroes ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP NULL;
+ALTER TABLE heroes ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
@@ -0,0 +1,2 @@
+-- Hero names are unique so upserts can target them with ON CONFLICT (name)
+ALTER TABLE heroes ADD CONSTRAINT heroes_name_key UNIQUE (name);
@@ -0,0 +1,2 @@
+-- Widen hero IDs so application-generated snowflake IDs fit; existing values are unchanged
+ALTER TABLE heroes ALTER COLUMN id TYPE BIGINT;
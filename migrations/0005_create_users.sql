@@ -0,0 +1,9 @@
+-- API users; populated from config.yaml on first boot
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL PRIMARY KEY,
+	username VARCHAR(100) NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL,
+	role VARCHAR(20) NOT NULL DEFAULT 'user',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	last_login_at TIMESTAMP NULL
+);
@@ -0,0 +1,10 @@
+-- Hero detail views, counted in memory and flushed into hourly buckets.
+-- hero_id is text so it matches heroes.id under every ID strategy.
+CREATE TABLE IF NOT EXISTS hero_views (
+	hero_id TEXT NOT NULL,
+	bucket TIMESTAMP NOT NULL,
+	views BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (hero_id, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS idx_hero_views_bucket ON hero_views (bucket);
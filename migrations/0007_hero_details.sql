@@ -0,0 +1,5 @@
+-- Optional hero details; NULL when unknown
+ALTER TABLE heroes ADD COLUMN IF NOT EXISTS lore TEXT NULL;
+ALTER TABLE heroes ADD COLUMN IF NOT EXISTS specialty VARCHAR(100) NULL;
+ALTER TABLE heroes ADD COLUMN IF NOT EXISTS lane VARCHAR(50) NULL;
+ALTER TABLE heroes ADD COLUMN IF NOT EXISTS release_date DATE NULL;
@@ -0,0 +1,12 @@
+-- Numeric difficulty (1-10) next to the label, backfilled from the known labels
+ALTER TABLE heroes ADD COLUMN IF NOT EXISTS difficulty_score SMALLINT NULL
+	CHECK (difficulty_score BETWEEN 1 AND 10);
+
+UPDATE heroes SET difficulty_score = CASE difficulty
+	WHEN 'Mudah' THEN 2
+	WHEN 'Sedang' THEN 5
+	WHEN 'Sulit' THEN 8
+END
+WHERE difficulty_score IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_heroes_difficulty_score ON heroes (difficulty_score);
@@ -0,0 +1,11 @@
+-- Tier placement of each hero per balance patch; older patches are kept as history.
+-- hero_id is text so it matches heroes.id under every ID strategy.
+CREATE TABLE IF NOT EXISTS hero_tiers (
+	patch TEXT NOT NULL,
+	hero_id TEXT NOT NULL,
+	tier TEXT NOT NULL,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (patch, hero_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_hero_tiers_updated_at ON hero_tiers (updated_at);
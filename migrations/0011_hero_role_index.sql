@@ -0,0 +1,2 @@
+-- Lookups by role, e.g. one random hero per role for drafts
+CREATE INDEX IF NOT EXISTS idx_heroes_role_difficulty ON heroes (role, difficulty);
@@ -0,0 +1,15 @@
+-- Responses to requests sent with an Idempotency-Key, replayed when the
+-- client retries. status is 0 while the first request is still running.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	username TEXT NOT NULL,
+	key TEXT NOT NULL,
+	request_hash TEXT NOT NULL,
+	status INTEGER NOT NULL DEFAULT 0,
+	content_type TEXT NOT NULL DEFAULT '',
+	location TEXT NOT NULL DEFAULT '',
+	body BYTEA,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (username, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys (created_at);
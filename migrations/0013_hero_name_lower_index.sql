@@ -0,0 +1,2 @@
+-- Case-insensitive exact name lookups (GET /api/heroes?name=)
+CREATE INDEX IF NOT EXISTS idx_heroes_name_lower ON heroes (LOWER(name));
@@ -0,0 +1,10 @@
+-- Maintenance mode, a single row so the setting survives restarts. Writes are
+-- rejected with 503 while enabled is true.
+CREATE TABLE IF NOT EXISTS maintenance_mode (
+	id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+	enabled BOOLEAN NOT NULL DEFAULT FALSE,
+	message TEXT NOT NULL DEFAULT '',
+	retry_after_seconds INTEGER NOT NULL DEFAULT 0,
+	changed_by TEXT NOT NULL DEFAULT '',
+	changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
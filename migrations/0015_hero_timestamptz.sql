@@ -0,0 +1,9 @@
+-- Hero timestamps become TIMESTAMPTZ so they no longer depend on the server's
+-- TimeZone. Existing values were written by CURRENT_TIMESTAMP in the session
+-- time zone, which is how the conversion below interprets them, so this must
+-- run on a connection that uses the server's default TimeZone.
+ALTER TABLE heroes
+	ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+	ALTER COLUMN updated_at TYPE TIMESTAMPTZ,
+	ALTER COLUMN archived_at TYPE TIMESTAMPTZ,
+	ALTER COLUMN deleted_at TYPE TIMESTAMPTZ;
@@ -0,0 +1,14 @@
+-- Free-form hero labels such as "meta" or "beginner-friendly". hero_id is text
+-- so it matches heroes.id under every ID strategy.
+CREATE TABLE IF NOT EXISTS tags (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(50) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS hero_tags (
+	hero_id TEXT NOT NULL,
+	tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+	PRIMARY KEY (hero_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_hero_tags_tag_id ON hero_tags (tag_id);
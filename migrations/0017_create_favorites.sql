@@ -0,0 +1,8 @@
+-- Heroes each user has favorited. Favorites follow a renamed user and go away
+-- with a deleted one; hero_id is text to match every ID strategy.
+CREATE TABLE IF NOT EXISTS favorites (
+	username VARCHAR(100) NOT NULL REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+	hero_id TEXT NOT NULL,
+	favorited_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (username, hero_id)
+);
@@ -0,0 +1,10 @@
+-- Heroes that counter each hero: countered_by_id is strong against hero_id.
+-- IDs are text so they match heroes.id under every ID strategy.
+CREATE TABLE IF NOT EXISTS hero_counters (
+	hero_id TEXT NOT NULL,
+	countered_by_id TEXT NOT NULL,
+	PRIMARY KEY (hero_id, countered_by_id),
+	CHECK (hero_id <> countered_by_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_hero_counters_countered_by_id ON hero_counters (countered_by_id);
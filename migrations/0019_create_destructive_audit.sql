@@ -0,0 +1,13 @@
+-- Every step of a destructive operation: the 428 request, its confirmation or
+-- rejection, or its run while the interlock is off. Pruned after a year.
+CREATE TABLE IF NOT EXISTS destructive_audit (
+	id BIGSERIAL PRIMARY KEY,
+	operation TEXT NOT NULL,
+	username TEXT NOT NULL,
+	request_id TEXT NOT NULL DEFAULT '',
+	outcome TEXT NOT NULL,
+	affected JSONB,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_destructive_audit_created_at ON destructive_audit (created_at);
@@ -0,0 +1,18 @@
+-- Trigram similarity for GET /api/heroes/search. pg_trgm is optional: without
+-- it (not installed, or no permission to create it) the migration still
+-- applies and search ranks ILIKE matches instead.
+DO $$
+BEGIN
+	CREATE EXTENSION IF NOT EXISTS pg_trgm;
+EXCEPTION WHEN OTHERS THEN
+	RAISE NOTICE 'pg_trgm not available, hero search falls back to ILIKE: %', SQLERRM;
+END
+$$;
+
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm') THEN
+		CREATE INDEX IF NOT EXISTS idx_heroes_name_trgm ON heroes USING GIN (name gin_trgm_ops);
+	END IF;
+END
+$$;
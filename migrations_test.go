@@ -0,0 +1,115 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// trigramMigration returns the migration that installs pg_trgm
+func trigramMigration(t *testing.T) Migration {
+	t.Helper()
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, migration := range migrations {
+		if strings.Contains(migration.SQL, "CREATE EXTENSION IF NOT EXISTS pg_trgm") {
+			return migration
+		}
+	}
+	t.Fatal("no migration installs pg_trgm")
+	return Migration{}
+}
+
+func TestTrigramMigration(t *testing.T) {
+	migration := trigramMigration(t)
+	sql := strings.Join(strings.Fields(migration.SQL), " ")
+
+	// A missing extension must not fail the migration, and the index needs the extension
+	if !strings.Contains(sql, "CREATE EXTENSION IF NOT EXISTS pg_trgm; EXCEPTION WHEN OTHERS THEN") {
+		t.Errorf("%s doesn't tolerate a missing pg_trgm", migration.Name)
+	}
+	guard := strings.Index(sql, "IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm') THEN")
+	index := strings.Index(sql, "CREATE INDEX IF NOT EXISTS idx_heroes_name_trgm ON heroes USING GIN (name gin_trgm_ops)")
+	if guard < 0 || index < guard {
+		t.Errorf("%s doesn't create the trigram index only when pg_trgm is installed", migration.Name)
+	}
+
+	// The schema version covers the index, and nothing outside migrations changes the schema
+	required, err := requiredSchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if required < migration.Version {
+		t.Errorf("required schema version %d predates %s", required, migration.Name)
+	}
+	for name, query := range queryRegistry {
+		if strings.Contains(query.SQL, "CREATE EXTENSION") || strings.Contains(query.SQL, "CREATE INDEX") {
+			t.Errorf("query %s changes the schema outside a migration: %s", name, query.SQL)
+		}
+	}
+}
+
+func TestCreateTablesDetectsTrigramSearch(t *testing.T) {
+	migration := trigramMigration(t)
+
+	for _, installed := range []bool{true, false} {
+		name := "without pg_trgm"
+		if installed {
+			name = "with pg_trgm"
+		}
+		t.Run(name, func(t *testing.T) {
+			cfg := useTestConfig(t)
+			captureLogs(t)
+			previous := trigramEnabled
+			t.Cleanup(func() { trigramEnabled = previous })
+
+			connector := useFakeDB(t)
+			connector.respond = func(query string, args []interface{}) *fakeResult {
+				switch query {
+				case querySchemaVersion.SQL:
+					return &fakeResult{columns: []string{"version"}, rows: [][]driver.Value{{int64(migration.Version - 1)}}}
+				case queryTrigramInstalled.SQL:
+					return &fakeResult{columns: []string{"exists"}, rows: [][]driver.Value{{installed}}}
+				}
+				return nil
+			}
+
+			if err := CreateTables(DatabaseConfig{AutoMigrate: true}); err != nil {
+				t.Fatal(err)
+			}
+			if trigramEnabled != installed {
+				t.Errorf("trigramEnabled = %v, want %v", trigramEnabled, installed)
+			}
+
+			var applied, recorded bool
+			for _, exec := range connector.executed() {
+				switch {
+				case exec.query == migration.SQL:
+					applied = true
+				case exec.query == queryRecordMigration.SQL && exec.args[0] == int64(migration.Version):
+					recorded = true
+				case strings.Contains(exec.query, "pg_trgm") && exec.query != queryTrigramInstalled.SQL:
+					t.Errorf("pg_trgm set up outside the migration: %s", exec.query)
+				}
+			}
+			if !applied || !recorded {
+				t.Errorf("%s applied = %v, recorded = %v; want both", migration.Name, applied, recorded)
+			}
+
+			connector.respond = nil
+			rec := serve(cfg, httptest.NewRequest(http.MethodGet, "/api/heroes/search?q=alu", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("search status = %d, want 200; body %s", rec.Code, rec.Body.String())
+			}
+			for _, exec := range connector.executed() {
+				if strings.Contains(exec.query, "AS score") && strings.Contains(exec.query, "similarity(") != installed {
+					t.Errorf("search with pg_trgm installed = %v ran %s", installed, exec.query)
+				}
+			}
+		})
+	}
+}
@@ -1,42 +1,363 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"sort"
 	"time"
 )
 
 // Hero represents a Mobile Legends hero with database fields
 type Hero struct {
-	ID         int       `json:"id" db:"id"`
-	Name       string    `json:"name" db:"name"`
-	Role       string    `json:"role" db:"role"`
-	Difficulty string    `json:"difficulty" db:"difficulty"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+	XMLName    xml.Name `json:"-" xml:"hero"`
+	ID         HeroID   `json:"id" xml:"id" db:"id" swaggertype:"string" example:"1"`
+	Name       string   `json:"name" xml:"name" db:"name"`
+	Role       string   `json:"role" xml:"role" db:"role"`
+	Difficulty string   `json:"difficulty" xml:"difficulty" db:"difficulty"`
+	// DifficultyScore is 1-10, null for labels without a known score
+	DifficultyScore *int      `json:"difficulty_score" xml:"difficulty_score,omitempty" db:"difficulty_score" example:"8"`
+	CreatedAt       time.Time `json:"created_at" xml:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" xml:"updated_at" db:"updated_at"`
+	// Tags are sorted by name
+	Tags []string `json:"tags" xml:"tags>tag" example:"meta"`
+
+	HeroDetails
+
+	// Lifecycle markers, only shown to callers who can see such heroes
+	ArchivedAt *time.Time `json:"archived_at,omitempty" xml:"archived_at,omitempty" db:"archived_at" audience:"authenticated"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" xml:"deleted_at,omitempty" db:"deleted_at" audience:"admin"`
+}
+
+// HeroDetails are the optional hero fields; nil is stored as NULL
+type HeroDetails struct {
+	Lore        *string `json:"lore,omitempty" xml:"lore,omitempty" db:"lore"`
+	Specialty   *string `json:"specialty,omitempty" xml:"specialty,omitempty" db:"specialty" example:"Charge/Burst"`
+	Lane        *string `json:"lane,omitempty" xml:"lane,omitempty" db:"lane" example:"EXP Lane"`
+	ReleaseDate *Date   `json:"release_date,omitempty" xml:"release_date,omitempty" db:"release_date" swaggertype:"string" example:"2016-07-14"`
+}
+
+// HeroList wraps a list of heroes in a <heroes> root element for XML output
+type HeroList struct {
+	XMLName xml.Name `xml:"heroes"`
+	Heroes  []Hero   `xml:"hero"`
+}
+
+// HeroSearchResult represents a hero matched by search with its relevance score
+type HeroSearchResult struct {
+	Hero
+	Score float64 `json:"score" xml:"score"`
+}
+
+// HeroSearchResultList wraps search results in a <heroes> root element for XML output
+type HeroSearchResultList struct {
+	XMLName xml.Name           `xml:"heroes"`
+	Results []HeroSearchResult `xml:"hero"`
+}
+
+// HeroExistence answers GET /api/heroes/{id}/exists
+type HeroExistence struct {
+	XMLName xml.Name `json:"-" xml:"hero"`
+	Exists  bool     `json:"exists" xml:"exists"`
+}
+
+// CreatedHero is a newly created hero with any data quality warnings about it
+type CreatedHero struct {
+	Hero
+	Warnings []string `json:"warnings,omitempty" xml:"warnings>warning,omitempty" audience:"authenticated"`
+}
+
+// TrendingHero is a hero with its detail views over the trending window
+type TrendingHero struct {
+	Hero
+	Views int64 `json:"views,omitempty" xml:"views,omitempty" audience:"admin"`
+}
+
+// TrendingHeroList wraps trending heroes in a <heroes> root element for XML output
+type TrendingHeroList struct {
+	XMLName xml.Name       `xml:"heroes"`
+	Heroes  []TrendingHero `xml:"hero"`
+}
+
+// HeroDraft is a suggested team with at most one hero per role
+type HeroDraft struct {
+	XMLName       xml.Name `json:"-" xml:"draft"`
+	Heroes        []Hero   `json:"heroes" xml:"heroes>hero"`
+	UnfilledRoles []string `json:"unfilled_roles" xml:"unfilled_roles>role"`
+}
+
+// TierAssignmentRequest places a hero in a tier for a balance patch
+type TierAssignmentRequest struct {
+	Tier  string `json:"tier" validate:"required" example:"S"`
+	Patch string `json:"patch" validate:"required" example:"1.8.42"`
+}
+
+// TierAssignment is a hero's tier in a balance patch
+type TierAssignment struct {
+	XMLName xml.Name `json:"-" xml:"tier_assignment"`
+	Patch   string   `json:"patch" xml:"patch"`
+	Tier    string   `json:"tier" xml:"tier"`
+	Hero    Hero     `json:"hero" xml:"hero"`
+}
+
+// TierList groups the heroes of a balance patch by tier. Order lists the
+// tier names best first; both encodings write the tiers in that order.
+type TierList struct {
+	Patch string            `json:"patch"`
+	Order []string          `json:"tier_order" example:"S,A,B,C,D"`
+	Tiers map[string][]Hero `json:"tiers"`
+}
+
+// names returns the tier names in Order, with tiers missing from Order last
+func (t TierList) names() []string {
+	names := make([]string, 0, len(t.Tiers))
+	for name := range t.Tiers {
+		names = append(names, name)
+	}
+	ordered := make([]string, 0, len(names))
+	for _, tier := range orderValues(names, t.Order) {
+		ordered = append(ordered, tier.Value)
+	}
+	return ordered
+}
+
+// MarshalJSON writes the tiers object with its keys in Order rather than
+// encoding/json's alphabetical map order
+func (t TierList) MarshalJSON() ([]byte, error) {
+	names := t.names()
+	var tiers bytes.Buffer
+	tiers.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			tiers.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		heroes, err := json.Marshal(t.Tiers[name])
+		if err != nil {
+			return nil, err
+		}
+		tiers.Write(key)
+		tiers.WriteByte(':')
+		tiers.Write(heroes)
+	}
+	tiers.WriteByte('}')
+
+	return json.Marshal(struct {
+		Patch string          `json:"patch"`
+		Order []string        `json:"tier_order"`
+		Tiers json.RawMessage `json:"tiers"`
+	}{t.Patch, names, tiers.Bytes()})
+}
+
+// MarshalXML writes the tiers as <tier name="S"> elements in Order,
+// since encoding/xml can't marshal maps
+func (t TierList) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type tier struct {
+		Name   string `xml:"name,attr"`
+		Heroes []Hero `xml:"hero"`
+	}
+	doc := struct {
+		XMLName xml.Name `xml:"tier_list"`
+		Patch   string   `xml:"patch"`
+		Tiers   []tier   `xml:"tier"`
+	}{Patch: t.Patch}
+
+	for _, name := range t.names() {
+		doc.Tiers = append(doc.Tiers, tier{Name: name, Heroes: t.Tiers[name]})
+	}
+	return e.Encode(doc)
+}
+
+// ValueList wraps a list of plain values in a <values> root element for XML output
+type ValueList struct {
+	XMLName xml.Name `xml:"values"`
+	Values  []string `xml:"value"`
+}
+
+// ReferenceValue is an enum value with its position in the display order
+type ReferenceValue struct {
+	XMLName xml.Name `json:"-" xml:"value"`
+	Value   string   `json:"value" xml:",chardata"`
+	Order   int      `json:"order" xml:"order,attr"`
+	// Count is the number of heroes visible to the caller with this value
+	Count int `json:"count" xml:"count,attr"`
+	// Canonical is false for values found on heroes that aren't configured
+	Canonical bool `json:"canonical" xml:"canonical,attr"`
+}
+
+// ReferenceValueList wraps reference values in a <values> root element for XML output
+type ReferenceValueList struct {
+	XMLName xml.Name         `xml:"values"`
+	Values  []ReferenceValue `xml:"value"`
+}
+
+// MaintenanceRequest turns maintenance mode on or off
+type MaintenanceRequest struct {
+	Enabled bool `json:"enabled"`
+	// Message is returned with every rejected write, a default is used when empty
+	Message string `json:"message,omitempty" example:"Database migration in progress"`
+	// RetryAfterSeconds is sent as Retry-After, 60 when not given
+	RetryAfterSeconds int `json:"retry_after_seconds,omitempty" example:"120"`
+}
+
+// MaintenanceStatus is the current maintenance mode and who last changed it
+type MaintenanceStatus struct {
+	XMLName           xml.Name   `json:"-" xml:"maintenance"`
+	Enabled           bool       `json:"enabled" xml:"enabled"`
+	Message           string     `json:"message,omitempty" xml:"message,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds,omitempty" xml:"retry_after_seconds,omitempty"`
+	ChangedBy         string     `json:"changed_by,omitempty" xml:"changed_by,omitempty"`
+	ChangedAt         *time.Time `json:"changed_at,omitempty" xml:"changed_at,omitempty"`
 }
 
 // HeroCreateRequest represents request for creating a new hero
 type HeroCreateRequest struct {
-	Name       string `json:"name" validate:"required"`
-	Role       string `json:"role" validate:"required"`
-	Difficulty string `json:"difficulty" validate:"required"`
+	Name       string          `json:"name" validate:"required"`
+	Role       string          `json:"role" validate:"required"`
+	Difficulty DifficultyField `json:"difficulty" validate:"required" swaggertype:"string" example:"Sulit"`
+	// DifficultyScore is optional and must fall within the label's range
+	DifficultyScore *int `json:"difficulty_score,omitempty" example:"8"`
+	// Tags are created as needed and attached to the new hero
+	Tags []string `json:"tags,omitempty" example:"meta"`
+	HeroDetails
+}
+
+// HeroCounterRequest names a hero that counters another
+type HeroCounterRequest struct {
+	CounteredByID HeroID `json:"countered_by_id" validate:"required" swaggertype:"string" example:"3"`
 }
 
 // HeroUpdateRequest represents request for updating a hero
 type HeroUpdateRequest struct {
-	Name       string `json:"name" validate:"required"`
-	Role       string `json:"role" validate:"required"`
-	Difficulty string `json:"difficulty" validate:"required"`
+	Name       string          `json:"name" validate:"required"`
+	Role       string          `json:"role" validate:"required"`
+	Difficulty DifficultyField `json:"difficulty" validate:"required" swaggertype:"string" example:"Sulit"`
+	// DifficultyScore is optional and must fall within the label's range
+	DifficultyScore *int `json:"difficulty_score,omitempty" example:"8"`
+	HeroDetails
+}
+
+// HeroDifficultyRequest represents request for changing only a hero's difficulty
+type HeroDifficultyRequest struct {
+	Difficulty DifficultyField `json:"difficulty" validate:"required" swaggertype:"string" example:"Sulit"`
+	// DifficultyScore is optional and must fall within the label's range
+	DifficultyScore *int `json:"difficulty_score,omitempty" example:"8"`
+}
+
+// HeroRoleAssignment is one entry of PATCH /api/heroes/roles
+type HeroRoleAssignment struct {
+	ID   HeroID `json:"id" validate:"required" swaggertype:"string" example:"1"`
+	Role string `json:"role" validate:"required" example:"Mage"`
+}
+
+// RoleReassignmentResult reports the outcome of PATCH /api/heroes/roles
+type RoleReassignmentResult struct {
+	XMLName  xml.Name `json:"-" xml:"reassignment"`
+	Updated  int      `json:"updated" xml:"updated" example:"2"`
+	NotFound []HeroID `json:"not_found" xml:"not_found>id" swaggertype:"array,string"`
 }
 
-// User represents a user for authentication
+// HeroUpsertRequest represents request for creating or updating a hero by name
+type HeroUpsertRequest struct {
+	Role       string          `json:"role" validate:"required"`
+	Difficulty DifficultyField `json:"difficulty" validate:"required" swaggertype:"string" example:"Sulit"`
+	// DifficultyScore is optional and must fall within the label's range
+	DifficultyScore *int `json:"difficulty_score,omitempty" example:"8"`
+	HeroDetails
+}
+
+// User represents a user listed in config.yaml, imported into the users table on first boot
 type User struct {
 	Username string `yaml:"username"`
 	Password string `yaml:"password"`
+	Role     string `yaml:"role"`
+}
+
+// UserAccount represents a stored API user; the password hash is never exposed
+type UserAccount struct {
+	XMLName     xml.Name   `json:"-" xml:"user"`
+	ID          int        `json:"id" xml:"id"`
+	Username    string     `json:"username" xml:"username"`
+	Role        string     `json:"role" xml:"role"`
+	CreatedAt   time.Time  `json:"created_at" xml:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty" xml:"last_login_at,omitempty"`
 }
 
-// Config represents the configuration file structure
-type Config struct {
-	Users []User `yaml:"users"`
+// UserAccountList wraps users in a <users> root element for XML output
+type UserAccountList struct {
+	XMLName xml.Name      `xml:"users"`
+	Users   []UserAccount `xml:"user"`
+}
+
+// UserCreateRequest represents request for creating a user
+type UserCreateRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+	Role     string `json:"role"`
+}
+
+// UserUpdateRequest represents request for updating a user's name and role
+type UserUpdateRequest struct {
+	Username string `json:"username" validate:"required"`
+	Role     string `json:"role" validate:"required"`
+}
+
+// PasswordChangeRequest represents request for changing the caller's own password
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+// PasswordResetRequest represents request for setting a user's password
+type PasswordResetRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// Session represents an issued bearer token and who it belongs to.
+// ID identifies the session to admins without revealing the token.
+type Session struct {
+	ID         string
+	Username   string
+	Role       string
+	IssuedAt   time.Time
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+}
+
+// SessionInfo describes an active session to admins
+type SessionInfo struct {
+	XMLName    xml.Name  `json:"-" xml:"session"`
+	ID         string    `json:"id" xml:"id"`
+	Token      string    `json:"token" xml:"token"`
+	Username   string    `json:"username" xml:"username"`
+	Role       string    `json:"role" xml:"role"`
+	IssuedAt   time.Time `json:"issued_at" xml:"issued_at"`
+	ExpiresAt  time.Time `json:"expires_at" xml:"expires_at"`
+	LastUsedAt time.Time `json:"last_used_at" xml:"last_used_at"`
+}
+
+// SessionInfoList wraps sessions in a <sessions> root element for XML output
+type SessionInfoList struct {
+	XMLName  xml.Name      `xml:"sessions"`
+	Sessions []SessionInfo `xml:"session"`
+}
+
+// ActiveSessionsReport counts the currently valid tokens
+type ActiveSessionsReport struct {
+	XMLName  xml.Name      `json:"-" xml:"active_sessions"`
+	Count    int           `json:"count" xml:"count"`
+	Sessions []SessionInfo `json:"sessions" xml:"session"`
+}
+
+// RevokedSessionsResponse reports how many sessions were revoked
+type RevokedSessionsResponse struct {
+	XMLName xml.Name `json:"-" xml:"revoked_sessions"`
+	// User is empty when every user's sessions were revoked
+	User    string `json:"user,omitempty" xml:"user,omitempty"`
+	Revoked int    `json:"revoked" xml:"revoked"`
 }
 
 // LoginRequest represents login request
@@ -47,17 +368,227 @@ type LoginRequest struct {
 
 // LoginResponse represents login response
 type LoginResponse struct {
-	Token string `json:"token"`
+	XMLName xml.Name `json:"-" xml:"login"`
+	Token   string   `json:"token" xml:"token"`
 }
 
 // ErrorResponse represents error response
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
+	XMLName xml.Name `json:"-" xml:"error"`
+	Code    string   `json:"code" xml:"code,attr"`
+	Error   string   `json:"error" xml:"message"`
+	Message string   `json:"message,omitempty" xml:"detail,omitempty"`
+}
+
+// Violation is a single semantic validation failure located by a JSON pointer
+type Violation struct {
+	Path    string `json:"path" xml:"path,attr"`
+	Message string `json:"message" xml:",chardata"`
+}
+
+// ValidationErrorResponse lists every violation found in a request body
+type ValidationErrorResponse struct {
+	XMLName    xml.Name    `json:"-" xml:"error"`
+	Code       string      `json:"code" xml:"code,attr"`
+	Error      string      `json:"error" xml:"message"`
+	Violations []Violation `json:"violations" xml:"violation"`
+}
+
+// AffectedRows maps table names to the number of rows an operation touches
+type AffectedRows map[string]int
+
+// MarshalXML writes one <table name="..."> element per entry in sorted order
+func (a AffectedRows) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	tables := make([]string, 0, len(a))
+	for table := range a {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, table := range tables {
+		element := xml.StartElement{
+			Name: xml.Name{Local: "table"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: table}},
+		}
+		if err := e.EncodeElement(a[table], element); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// ConfirmationRequiredResponse describes a destructive operation awaiting confirmation
+type ConfirmationRequiredResponse struct {
+	XMLName           xml.Name     `json:"-" xml:"confirmation_required"`
+	Code              string       `json:"code" xml:"code,attr"`
+	Error             string       `json:"error" xml:"error"`
+	Operation         string       `json:"operation" xml:"operation"`
+	Affected          AffectedRows `json:"affected" xml:"affected"`
+	ConfirmationToken string       `json:"confirmation_token" xml:"confirmation_token"`
+	ExpiresAt         time.Time    `json:"expires_at" xml:"expires_at"`
+}
+
+// HealthResponse represents a liveness or readiness probe result
+type HealthResponse struct {
+	XMLName xml.Name          `json:"-" xml:"health"`
+	Status  string            `json:"status" xml:"status"`
+	Checks  map[string]string `json:"checks,omitempty" xml:"-"`
+}
+
+// VersionInfo describes the running build
+type VersionInfo struct {
+	XMLName   xml.Name `json:"-" xml:"version"`
+	Version   string   `json:"version" xml:"version"`
+	Commit    string   `json:"commit" xml:"commit"`
+	BuildTime string   `json:"build_time" xml:"build_time"`
+	GoVersion string   `json:"go_version" xml:"go_version"`
+}
+
+// DBPoolStats reports connection pool usage and recycles after connection errors
+type DBPoolStats struct {
+	XMLName xml.Name `json:"-" xml:"db_pool"`
+	// Pool is "primary" or "replica"
+	Pool string `json:"pool" xml:"pool" example:"primary"`
+	// Circuit is "open" while queries fail fast after repeated connection errors
+	Circuit             string     `json:"circuit" xml:"circuit" example:"closed"`
+	ConsecutiveFailures int64      `json:"consecutive_failures" xml:"consecutive_failures"`
+	FailureThreshold    int64      `json:"failure_threshold" xml:"failure_threshold"`
+	Recycling           bool       `json:"recycling" xml:"recycling"`
+	Recycles            int64      `json:"recycles" xml:"recycles"`
+	Recoveries          int64      `json:"recoveries" xml:"recoveries"`
+	ProbeFailures       int64      `json:"probe_failures" xml:"probe_failures"`
+	LastRecycleAt       *time.Time `json:"last_recycle_at,omitempty" xml:"last_recycle_at,omitempty"`
+	LastRecoveryAt      *time.Time `json:"last_recovery_at,omitempty" xml:"last_recovery_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty" xml:"last_error,omitempty"`
+	OpenConnections     int        `json:"open_connections" xml:"open_connections"`
+	InUse               int        `json:"in_use" xml:"in_use"`
+	Idle                int        `json:"idle" xml:"idle"`
+	WaitCount           int64      `json:"wait_count" xml:"wait_count"`
+	MaxIdleClosed       int64      `json:"max_idle_closed" xml:"max_idle_closed"`
+	MaxLifetimeClosed   int64      `json:"max_lifetime_closed" xml:"max_lifetime_closed"`
+	// Replica is the read replica pool, when DB_REPLICA_DSN is set
+	Replica *DBPoolStats `json:"replica,omitempty" xml:",omitempty"`
+}
+
+// RuntimeStats describes the process for debugging memory and goroutine growth
+type RuntimeStats struct {
+	XMLName        xml.Name `json:"-" xml:"runtime"`
+	Goroutines     int      `json:"goroutines" xml:"goroutines"`
+	HeapAllocBytes uint64   `json:"heap_alloc_bytes" xml:"heap_alloc_bytes"`
+	HeapSysBytes   uint64   `json:"heap_sys_bytes" xml:"heap_sys_bytes"`
+	HeapObjects    uint64   `json:"heap_objects" xml:"heap_objects"`
+	NumGC          uint32   `json:"num_gc" xml:"num_gc"`
+	GCPauseTotalMs float64  `json:"gc_pause_total_ms" xml:"gc_pause_total_ms"`
+	// RecentGCPauses are the latest pauses in milliseconds, newest first
+	RecentGCPauses []float64   `json:"recent_gc_pauses_ms" xml:"recent_gc_pauses_ms>pause"`
+	Sessions       int         `json:"sessions" xml:"sessions"`
+	Database       DBPoolStats `json:"database" xml:"database"`
+}
+
+// QueryStats counts executions of one registered query
+type QueryStats struct {
+	Name     string `json:"name" xml:"name"`
+	Calls    int64  `json:"calls" xml:"calls"`
+	Failures int64  `json:"failures" xml:"failures"`
+	// SlowCalls took at least DB_SLOW_QUERY_THRESHOLD
+	SlowCalls       int64   `json:"slow_calls" xml:"slow_calls"`
+	TotalDurationMs float64 `json:"total_duration_ms" xml:"total_duration_ms"`
+	MaxDurationMs   float64 `json:"max_duration_ms" xml:"max_duration_ms"`
+}
+
+// CacheStats describes the hero response cache
+type CacheStats struct {
+	XMLName    xml.Name `json:"-" xml:"cache"`
+	Enabled    bool     `json:"enabled" xml:"enabled"`
+	TTLSeconds float64  `json:"ttl_seconds" xml:"ttl_seconds"`
+	Entries    int      `json:"entries" xml:"entries"`
+	Hits       int64    `json:"hits" xml:"hits"`
+	Misses     int64    `json:"misses" xml:"misses"`
+	// HitRate is hits / (hits + misses), 0 before the first lookup
+	HitRate float64 `json:"hit_rate" xml:"hit_rate"`
+}
+
+// QueryCoverageReport lists registered queries and which ones have never run
+type QueryCoverageReport struct {
+	XMLName    xml.Name     `json:"-" xml:"queries"`
+	Registered int          `json:"registered" xml:"registered"`
+	Executed   int          `json:"executed" xml:"executed"`
+	Coverage   float64      `json:"coverage" xml:"coverage"`
+	Threshold  float64      `json:"threshold" xml:"threshold"`
+	Unused     []string     `json:"unused" xml:"unused>query"`
+	Queries    []QueryStats `json:"queries" xml:"query"`
+}
+
+// ResetResult reports the heroes inserted by POST /api/admin/reset
+type ResetResult struct {
+	XMLName xml.Name `json:"-" xml:"reset"`
+	Seeded  int64    `json:"seeded" xml:"seeded" example:"3"`
+}
+
+// Snapshot is the document written by GET /api/admin/export and read by POST /api/admin/import
+type Snapshot struct {
+	SchemaVersion int             `json:"schema_version" example:"1"`
+	ExportedAt    time.Time       `json:"exported_at"`
+	Heroes        []Hero          `json:"heroes"`
+	Counters      []HeroCounter   `json:"counters"`
+	Tiers         []TierPlacement `json:"tiers"`
+}
+
+// HeroCounter records that the hero CounteredByID is strong against HeroID
+type HeroCounter struct {
+	HeroID        HeroID `json:"hero_id" swaggertype:"string" example:"1"`
+	CounteredByID HeroID `json:"countered_by_id" swaggertype:"string" example:"2"`
+}
+
+// TierPlacement places a hero in a tier for one patch
+type TierPlacement struct {
+	Patch  string `json:"patch" example:"1.8.20"`
+	HeroID HeroID `json:"hero_id" swaggertype:"string" example:"1"`
+	Tier   string `json:"tier" example:"S"`
+}
+
+// ImportCounts counts the records of one kind written by an import
+type ImportCounts struct {
+	Inserted int `json:"inserted" xml:"inserted,attr"`
+	Updated  int `json:"updated" xml:"updated,attr"`
+	Skipped  int `json:"skipped" xml:"skipped,attr"`
+}
+
+// ImportSummary reports what POST /api/admin/import wrote
+type ImportSummary struct {
+	XMLName  xml.Name     `json:"-" xml:"import"`
+	Mode     string       `json:"mode" xml:"mode,attr" example:"replace"`
+	Heroes   ImportCounts `json:"heroes" xml:"heroes"`
+	Counters ImportCounts `json:"counters" xml:"counters"`
+	Tiers    ImportCounts `json:"tiers" xml:"tiers"`
+}
+
+// PruneResult reports the outcome of pruning one managed table
+type PruneResult struct {
+	Table   string    `json:"table" xml:"table"`
+	Deleted int64     `json:"deleted" xml:"deleted"`
+	Skipped bool      `json:"skipped,omitempty" xml:"skipped,omitempty"`
+	Error   string    `json:"error,omitempty" xml:"error,omitempty"`
+	RanAt   time.Time `json:"ran_at" xml:"ran_at"`
+}
+
+// TableStorageReport describes the storage used by one managed table
+type TableStorageReport struct {
+	XMLName             xml.Name     `json:"-" xml:"table"`
+	Table               string       `json:"table" xml:"name"`
+	RowCount            int64        `json:"row_count" xml:"row_count"`
+	SizeBytes           int64        `json:"size_bytes" xml:"size_bytes"`
+	OldestRowAgeSeconds int64        `json:"oldest_row_age_seconds" xml:"oldest_row_age_seconds"`
+	Retention           string       `json:"retention" xml:"retention"`
+	LastPrune           *PruneResult `json:"last_prune,omitempty" xml:"last_prune,omitempty"`
 }
 
 // SuccessResponse represents success response
 type SuccessResponse struct {
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
\ No newline at end of file
+	XMLName xml.Name    `json:"-" xml:"success"`
+	Message string      `json:"message" xml:"message"`
+	Data    interface{} `json:"data,omitempty" xml:"data,omitempty"`
+}
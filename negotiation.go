@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Response formats supported by respondWith
+const (
+	formatJSON = "json"
+	formatXML  = "xml"
+	// formatNDJSON is only streamed by list endpoints; respondWith treats it as JSON
+	formatNDJSON = "ndjson"
+)
+
+// Media types a client may ask for, listed in 406 responses. text/event-stream
+// is only served by /api/heroes/events.
+var supportedMediaTypes = []string{"application/json", "application/xml", "application/x-ndjson", "text/event-stream", "application/schema+json"}
+
+// Media types served for each format, most specific first. text/event-stream and
+// application/schema+json are JSON to respondWith; NDJSON is never picked by a wildcard.
+var formatMediaTypes = []struct {
+	format     string
+	mediaTypes []string
+	wildcards  []string
+}{
+	{formatJSON, []string{"application/json", "text/event-stream", "application/schema+json"}, []string{"application/*", "*/*"}},
+	{formatXML, []string{"application/xml", "text/xml"}, []string{"application/*", "text/*", "*/*"}},
+	{formatNDJSON, []string{"application/x-ndjson", "application/jsonl"}, nil},
+}
+
+// acceptRange is one media range of an Accept header
+type acceptRange struct {
+	mediaType string
+	q         float64
+	index     int
+}
+
+// parseAccept splits an Accept header into its media ranges, skipping
+// malformed ones. A missing q counts as 1.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for i, mediaRange := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(value, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q, index: i})
+	}
+	return ranges
+}
+
+// preferredFormat picks the format the Accept header rates highest. Each
+// format takes the q of its most specific matching range, so
+// "application/json;q=0, */*" refuses JSON but accepts XML; q=0 means refused.
+// Ties go to the range listed first, then to JSON over XML. ok is false when
+// every format is refused or unmatched.
+func preferredFormat(header string) (format string, ok bool) {
+	ranges := parseAccept(header)
+
+	var best acceptRange
+	for _, candidate := range formatMediaTypes {
+		match, found := matchRange(ranges, candidate.mediaTypes)
+		if !found {
+			match, found = matchRange(ranges, candidate.wildcards)
+		}
+		if !found || match.q == 0 {
+			continue
+		}
+		if !ok || match.q > best.q || (match.q == best.q && match.index < best.index) {
+			format, best, ok = candidate.format, match, true
+		}
+	}
+	return format, ok
+}
+
+// matchRange returns the range naming the first of mediaTypes that the header lists
+func matchRange(ranges []acceptRange, mediaTypes []string) (acceptRange, bool) {
+	for _, mediaType := range mediaTypes {
+		for _, candidate := range ranges {
+			if candidate.mediaType == mediaType {
+				return candidate, true
+			}
+		}
+	}
+	return acceptRange{}, false
+}
+
+// negotiateFormat picks the response format from ?format= or the Accept header.
+// JSON is used when the header is absent or names nothing we support.
+func negotiateFormat(r *http.Request) string {
+	if r == nil {
+		return formatJSON
+	}
+	switch format := r.URL.Query().Get("format"); format {
+	case formatJSON, formatXML, formatNDJSON:
+		return format
+	}
+
+	if format, ok := preferredFormat(r.Header.Get("Accept")); ok {
+		return format
+	}
+	return formatJSON
+}
+
+// acceptable reports whether the request's ?format= or Accept header allows
+// at least one format we can produce
+func acceptable(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == formatJSON || format == formatXML || format == formatNDJSON
+	}
+
+	accept := strings.TrimSpace(r.Header.Get("Accept"))
+	if accept == "" {
+		return true
+	}
+	_, ok := preferredFormat(accept)
+	return ok
+}
+
+// negotiationMiddleware rejects requests for formats we can't produce with a
+// 406, written as JSON since nothing the client asked for is available
+func negotiationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptable(r) {
+			respondWithError(w, r, http.StatusNotAcceptable, ErrCodeNotAcceptable,
+				"Supported response types are "+strings.Join(supportedMediaTypes, ", ")+" (or ?format=json, xml or ndjson)")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Marshal payload as XML, wrapping top-level lists in a root element
+func marshalXML(r *http.Request, payload interface{}) ([]byte, error) {
+	switch list := payload.(type) {
+	case []Hero:
+		payload = HeroList{Heroes: list}
+	case []HeroSearchResult:
+		payload = HeroSearchResultList{Results: list}
+	case []TrendingHero:
+		payload = TrendingHeroList{Heroes: list}
+	case []string:
+		payload = ValueList{Values: list}
+	case []ReferenceValue:
+		payload = ReferenceValueList{Values: list}
+	case []UserAccount:
+		payload = UserAccountList{Users: list}
+	case []SessionInfo:
+		payload = SessionInfoList{Sessions: list}
+	}
+
+	var body []byte
+	var err error
+	if wantsPretty(r) {
+		body, err = xml.MarshalIndent(payload, "", "  ")
+	} else {
+		body, err = xml.Marshal(payload)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		accept         string
+		wantFormat     string
+		wantAcceptable bool
+	}{
+		{"no header", "", "", formatJSON, true},
+		{"any", "", "*/*", formatJSON, true},
+		{"json", "", "application/json", formatJSON, true},
+		{"xml", "", "application/xml", formatXML, true},
+		{"text xml", "", "text/xml", formatXML, true},
+		{"ndjson", "", "application/x-ndjson", formatNDJSON, true},
+		{"first listed wins a tie", "", "application/xml, application/json", formatXML, true},
+		{"higher q wins", "", "application/xml;q=0.5, application/json", formatJSON, true},
+		{"higher q wins regardless of order", "", "application/json;q=0.4, application/xml;q=0.9", formatXML, true},
+		{"browser style", "", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", formatXML, true},
+		{"wildcard below explicit", "", "*/*;q=0.1, application/xml", formatXML, true},
+		{"application wildcard", "", "application/*", formatJSON, true},
+		{"text wildcard", "", "text/*", formatXML, true},
+		{"q=0 refuses json", "", "application/json;q=0, */*", formatXML, true},
+		{"q=0 refuses xml", "", "application/xml;q=0, application/*", formatJSON, true},
+		{"q=0.0 refuses", "", "application/xml;q=0.0", formatJSON, false},
+		{"everything refused", "", "application/json;q=0, application/xml;q=0, */*;q=0", formatJSON, false},
+		{"wildcards never pick ndjson", "", "application/json;q=0, application/xml;q=0, */*", formatJSON, false},
+		{"unsupported", "", "text/html", formatJSON, false},
+		{"invalid q is ignored", "", "application/xml;q=abc, application/json", formatJSON, true},
+		{"q above 1 is ignored", "", "application/xml;q=2, application/json;q=0.5", formatJSON, true},
+		{"malformed range is skipped", "", "/;;, application/xml", formatXML, true},
+		{"format param wins", "format=xml", "application/json", formatXML, true},
+		{"format param ignores q=0", "format=json", "application/json;q=0", formatJSON, true},
+		{"unknown format param", "format=csv", "", formatJSON, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/heroes?"+tt.query, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			if got := negotiateFormat(r); got != tt.wantFormat {
+				t.Errorf("negotiateFormat() = %s, want %s", got, tt.wantFormat)
+			}
+			if got := acceptable(r); got != tt.wantAcceptable {
+				t.Errorf("acceptable() = %v, want %v", got, tt.wantAcceptable)
+			}
+		})
+	}
+}
+
+func TestRespondWithXMLContentType(t *testing.T) {
+	useTestConfig(t)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/version", nil)
+	r.Header.Set("Accept", "text/xml")
+	rec := httptest.NewRecorder()
+	respondWith(rec, r, http.StatusOK, SuccessResponse{Message: "ok"})
+
+	// The media type documented for XML responses in the Swagger spec
+	if got := rec.Header().Get("Content-Type"); got != "application/xml" {
+		t.Errorf("Content-Type = %q, want application/xml", got)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import "strings"
+
+// Normalize cleans up the fields of a create request before validation, so a
+// whitespace-only name fails the required check
+func (req *HeroCreateRequest) Normalize() {
+	normalizeHeroInput(&req.Name, &req.Role, &req.Difficulty, &req.HeroDetails)
+}
+
+// Normalize cleans up the fields of an update request before validation
+func (req *HeroUpdateRequest) Normalize() {
+	normalizeHeroInput(&req.Name, &req.Role, &req.Difficulty, &req.HeroDetails)
+}
+
+// Normalize cleans up the fields of an upsert request; the name comes from the
+// path and goes through normalizeHeroName separately
+func (req *HeroUpsertRequest) Normalize() {
+	var name string
+	normalizeHeroInput(&name, &req.Role, &req.Difficulty, &req.HeroDetails)
+}
+
+// normalizeHeroInput applies the rules shared by every hero write, including
+// imports: names lose surrounding and repeated whitespace, and roles and
+// difficulty labels take the casing of the configured ones they match.
+// Unknown roles and labels are only trimmed.
+func normalizeHeroInput(name, role *string, difficulty *DifficultyField, details *HeroDetails) {
+	*name = normalizeHeroName(*name)
+	*role, _ = canonicalRole(*role, currentDisplayOrder().Roles)
+	*difficulty = canonicalDifficulty(*difficulty)
+	for _, field := range []*string{details.Lore, details.Specialty, details.Lane} {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+		}
+	}
+}
+
+// normalizeHeroName trims name and collapses runs of whitespace inside it to one space
+func normalizeHeroName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// canonicalDifficulty trims a difficulty and gives a known label its configured casing
+func canonicalDifficulty(value DifficultyField) DifficultyField {
+	label := strings.TrimSpace(string(value))
+	for _, known := range knownDifficulties() {
+		if strings.EqualFold(known, label) {
+			return DifficultyField(known)
+		}
+	}
+	return DifficultyField(label)
+}
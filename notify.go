@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PostgreSQL channel carrying hero events between instances
+const heroChangesChannel = "hero_changes"
+
+// Hero event listener settings
+const (
+	// NOTIFY payloads must be shorter than 8000 bytes
+	maxNotifyPayload        = 7999
+	listenerMinReconnect    = time.Second
+	listenerMaxReconnect    = time.Minute
+	listenerKeepAlivePeriod = 90 * time.Second
+)
+
+var queryNotifyHeroChange = registerQuery("hero_events.notify", "SELECT pg_notify('"+heroChangesChannel+"', $1)", paramText)
+
+// Set while the listener is subscribed to hero_changes. Until then events are
+// only delivered to this instance's subscribers.
+var heroEventsShared atomic.Bool
+
+// notifyHeroEvent sends event to every instance through NOTIFY, including
+// this one, whose listener passes it on to local subscribers. Lore is left
+// out when the payload would exceed the NOTIFY limit. The hero is sent in full:
+// receivers need its lifecycle markers to apply visibility, and redact it per
+// subscriber with eventAudience before anything leaves the process.
+func notifyHeroEvent(ctx context.Context, event HeroEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		event.Hero.Lore = nil
+		if payload, err = json.Marshal(event); err != nil {
+			return err
+		}
+	}
+
+	_, err = queryNotifyHeroChange.WithContext(ctx).Exec(string(payload))
+	return err
+}
+
+// initHeroEventListener subscribes to hero_changes so changes made by other
+// instances reach this instance's event streams. pq.Listener reconnects on its
+// own after the connection drops; events sent while disconnected are lost.
+func initHeroEventListener(ctx context.Context, cfg DatabaseConfig) {
+	listener := pq.NewListener(dataSourceName(cfg), listenerMinReconnect, listenerMaxReconnect,
+		func(event pq.ListenerEventType, err error) {
+			switch event {
+			case pq.ListenerEventDisconnected:
+				heroEventsShared.Store(false)
+				slog.Warn("Hero event listener disconnected", "error", err)
+			case pq.ListenerEventReconnected:
+				heroEventsShared.Store(true)
+				slog.Info("Hero event listener reconnected")
+			case pq.ListenerEventConnectionAttemptFailed:
+				slog.Warn("Hero event listener failed to reconnect", "error", err)
+			}
+		})
+
+	if err := listener.Listen(heroChangesChannel); err != nil {
+		slog.Warn("Failed to listen for hero changes, events stay on this instance", "error", err)
+		listener.Close()
+		return
+	}
+	heroEventsShared.Store(true)
+
+	go func() {
+		defer listener.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				heroEventsShared.Store(false)
+				return
+			case notification := <-listener.Notify:
+				// nil follows a reconnect
+				if notification == nil {
+					continue
+				}
+				var event HeroEvent
+				if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+					slog.Error("Failed to decode hero change notification", "error", err)
+					continue
+				}
+				heroEvents.publish(event)
+			case <-time.After(listenerKeepAlivePeriod):
+				// Detect a dead connection while no notifications arrive
+				go listener.Ping()
+			}
+		}
+	}()
+}
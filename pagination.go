@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Pagination defaults
+const (
+	defaultPageLimit    = 20
+	defaultMaxPageLimit = 100
+)
+
+// Sent as the total when exact counts are disabled
+const unknownTotal = -1
+
+// PaginationConfig caps page sizes and controls total counting
+type PaginationConfig struct {
+	MaxLimit int `yaml:"max_limit"`
+	// ExactCount counts every matching row for X-Total-Count; turning it off
+	// keeps pages fast on large tables at the cost of the total and last link
+	ExactCount bool `yaml:"exact_count"`
+}
+
+// maxPageLimit returns the largest page size a client may request
+func maxPageLimit() int {
+	if config.Pagination.MaxLimit > 0 {
+		return config.Pagination.MaxLimit
+	}
+	return defaultMaxPageLimit
+}
+
+// totalColumn returns the select expression for the total of a paginated
+// query: a window count, or unknownTotal when exact counts are disabled
+func totalColumn() string {
+	if config.Pagination.ExactCount {
+		return "COUNT(*) OVER()"
+	}
+	return strconv.Itoa(unknownTotal)
+}
+
+// initialTotal is the total of a page before its rows are read: 0 when the
+// rows carry a window count, matching totalColumn, or unknownTotal
+func initialTotal() int {
+	if config.Pagination.ExactCount {
+		return 0
+	}
+	return unknownTotal
+}
+
+// needsCount reports whether a page must be counted separately: past the
+// last page no row carries the window count, so exact totals need a COUNT
+func needsCount(p Pagination, returned int) bool {
+	return config.Pagination.ExactCount && returned == 0 && p.Page > 1
+}
+
+// Pagination holds the page and limit requested by the client
+type Pagination struct {
+	Page  int
+	Limit int
+}
+
+// Offset returns the number of rows to skip for the requested page
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// Parse page and limit query parameters
+func parsePagination(r *http.Request) (Pagination, error) {
+	p := Pagination{Page: 1, Limit: defaultPageLimit}
+	query := r.URL.Query()
+
+	if value := query.Get("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return p, errors.New("page must be a positive integer")
+		}
+		p.Page = page
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return p, errors.New("limit must be a positive integer")
+		}
+		if limit > maxPageLimit() {
+			limit = maxPageLimit()
+		}
+		p.Limit = limit
+	}
+
+	return p, nil
+}
+
+// Check whether the client asked for a paginated result
+func isPaginated(r *http.Request) bool {
+	query := r.URL.Query()
+	return query.Get("page") != "" || query.Get("limit") != ""
+}
+
+// Set X-Total-Count and RFC 5988 Link headers for a paginated response.
+// returned is the number of rows on this page. When total is unknownTotal,
+// X-Total-Count and the last link are left out and next is only offered
+// after a full page.
+func setPaginationHeaders(w http.ResponseWriter, r *http.Request, p Pagination, total, returned int) {
+	if total == unknownTotal {
+		links := []string{pageLink(r, p, 1, "first")}
+		if p.Page > 1 {
+			links = append(links, pageLink(r, p, p.Page-1, "prev"))
+		}
+		if returned == p.Limit {
+			links = append(links, pageLink(r, p, p.Page+1, "next"))
+		}
+		w.Header().Set("Link", strings.Join(links, ", "))
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	lastPage := (total + p.Limit - 1) / p.Limit
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	links := []string{pageLink(r, p, 1, "first")}
+	if p.Page > 1 {
+		prev := p.Page - 1
+		if prev > lastPage {
+			prev = lastPage
+		}
+		links = append(links, pageLink(r, p, prev, "prev"))
+	}
+	if p.Page < lastPage {
+		links = append(links, pageLink(r, p, p.Page+1, "next"))
+	}
+	links = append(links, pageLink(r, p, lastPage, "last"))
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// Build a Link entry for page, keeping the caller's other query parameters
+func pageLink(r *http.Request, p Pagination, page int, rel string) string {
+	query := r.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	query.Set("limit", strconv.Itoa(p.Limit))
+
+	target := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf("<%s>; rel=\"%s\"", target.String(), rel)
+}
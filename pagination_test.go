@@ -0,0 +1,66 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPaginationPastTheLastPage(t *testing.T) {
+	tests := []struct {
+		name       string
+		exactCount bool
+		wantCount  bool
+		wantTotal  string
+		wantLast   bool
+	}{
+		{"exact counts", true, true, "23", true},
+		{"exact counts disabled", false, false, "", false},
+	}
+	for _, endpoint := range []string{"/api/heroes?page=5&limit=10", "/api/heroes/search?q=al&page=5&limit=10"} {
+		for _, tt := range tests {
+			t.Run(endpoint+" with "+tt.name, func(t *testing.T) {
+				cfg := useTestConfig(t)
+				captureLogs(t)
+				config.Pagination.ExactCount = tt.exactCount
+				connector := useFakeDB(t)
+				connector.respond = func(query string, _ []interface{}) *fakeResult {
+					if strings.HasPrefix(query, "SELECT COUNT(*)") {
+						return &fakeResult{columns: []string{"count"}, rows: [][]driver.Value{{int64(23)}}}
+					}
+					return nil
+				}
+
+				rec := serve(cfg, httptest.NewRequest(http.MethodGet, endpoint, nil))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body.String())
+				}
+
+				var counted bool
+				for _, exec := range connector.executed() {
+					counted = counted || strings.HasPrefix(exec.query, "SELECT COUNT(*)")
+				}
+				if counted != tt.wantCount {
+					t.Errorf("separate count ran = %v, want %v", counted, tt.wantCount)
+				}
+				if got := rec.Header().Get("X-Total-Count"); got != tt.wantTotal {
+					t.Errorf("X-Total-Count = %q, want %q", got, tt.wantTotal)
+				}
+				link := rec.Header().Get("Link")
+				if hasLast := strings.Contains(link, `rel="last"`); hasLast != tt.wantLast {
+					t.Errorf("Link %q: last link present = %v, want %v", link, hasLast, tt.wantLast)
+				}
+				for _, part := range strings.Split(link, ", ") {
+					if strings.HasSuffix(part, `rel="last"`) && !strings.Contains(part, "page=3") {
+						t.Errorf("last link %q, want page 3 of 23 heroes", part)
+					}
+				}
+				if strings.Contains(link, `rel="next"`) {
+					t.Errorf("Link %q offers a next page past the end", link)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Media type of RFC 7386 JSON Merge Patch bodies
+const mergePatchMediaType = "application/merge-patch+json"
+
+// mergePatch applies an RFC 7386 merge patch to target: objects are merged
+// recursively, null removes a member and any other value replaces it
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// PATCH /api/heroes/{id} - Partially update a hero
+// @Summary Patch hero by ID
+// @Description Apply a JSON Merge Patch (RFC 7386) to a hero. Absent fields are kept and null clears
+// @Description optional fields. Changing difficulty without difficulty_score resets the score to the
+// @Description label's default. The patched hero is validated like a full update.
+// @Tags heroes
+// @Accept application/merge-patch+json
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Param patch body HeroUpdateRequest true "Fields to change"
+// @Success 200 {object} Hero
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/{id} [patch]
+func patchHero(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != mergePatchMediaType {
+		respondWithError(w, r, http.StatusUnsupportedMediaType, ErrCodeUnsupportedMediaType, "Content-Type must be "+mergePatchMediaType)
+		return
+	}
+
+	id, err := heroIDs.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
+		return
+	}
+
+	var patch map[string]interface{}
+	if !decodeJSONBody(w, r, &patch) {
+		return
+	}
+
+	filter := &heroFilter{}
+	filter.add("id = $%d", id)
+	filter.restrictVisibility(r)
+
+	var current Hero
+	err = queryGetHero.WithContext(r.Context()).Build("SELECT " + heroColumns + " FROM heroes" + filter.where()).QueryRow(filter.args...).
+		Scan(heroScanDest(&current)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, r, http.StatusNotFound, ErrCodeHeroNotFound, "Hero not found")
+		} else {
+			respondWithInternalError(w, r, err, "Failed to fetch hero")
+		}
+		return
+	}
+
+	req, violations := applyHeroPatch(current, patch)
+	if len(violations) > 0 {
+		respondWithViolations(w, r, violations)
+		return
+	}
+
+	difficulty, err := resolveDifficulty(req.Difficulty, req.DifficultyScore)
+	if err != nil {
+		respondWithViolations(w, r, []Violation{{Path: jsonPointer("difficulty_score"), Message: err.Error()}})
+		return
+	}
+
+	var hero Hero
+	err = queryUpdateHero.WithContext(r.Context()).QueryRow(req.Name, req.Role, difficulty.Label, difficulty.Score,
+		req.Lore, req.Specialty, req.Lane, req.ReleaseDate, id).
+		Scan(heroScanDest(&hero)...)
+	if err != nil {
+		respondWithHeroWriteError(w, r, err, "Failed to update hero")
+		return
+	}
+
+	invalidateHeroCache()
+	publishHeroEvent(r, eventUpdated, hero)
+
+	respondWith(w, r, http.StatusOK, hero)
+}
+
+// applyHeroPatch merges patch into the editable fields of hero and returns
+// the result as a full update, or the violations that make it invalid
+func applyHeroPatch(hero Hero, patch map[string]interface{}) (HeroUpdateRequest, []Violation) {
+	current := HeroUpdateRequest{
+		Name:            hero.Name,
+		Role:            hero.Role,
+		Difficulty:      DifficultyField(hero.Difficulty),
+		DifficultyScore: hero.DifficultyScore,
+		HeroDetails:     hero.HeroDetails,
+	}
+
+	var document map[string]interface{}
+	data, _ := json.Marshal(current)
+	json.Unmarshal(data, &document)
+
+	// A new label without a score takes the label's default rather than
+	// failing against the old score
+	if _, changesLabel := patch["difficulty"]; changesLabel {
+		if _, changesScore := patch["difficulty_score"]; !changesScore {
+			delete(document, "difficulty_score")
+		}
+	}
+
+	data, _ = json.Marshal(mergePatch(document, patch))
+
+	var req HeroUpdateRequest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return req, []Violation{{Path: "", Message: "patch sets an unknown or read-only field, or a field of the wrong type: " + err.Error()}}
+	}
+	req.Normalize()
+
+	var violations []Violation
+	for _, field := range []struct{ name, value string }{
+		{"name", req.Name},
+		{"role", req.Role},
+		{"difficulty", string(req.Difficulty)},
+	} {
+		if field.value == "" {
+			violations = append(violations, Violation{Path: jsonPointer(field.name), Message: field.name + " is required"})
+		}
+	}
+	return req, violations
+}
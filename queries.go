@@ -0,0 +1,412 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Share of registered queries a test suite must execute, for checkQueryCoverage
+const queryCoverageThreshold = 0.8
+
+// paramType is the expected Go type of a query argument
+type paramType string
+
+// Parameter types accepted by registered queries
+const (
+	paramText     paramType = "text"
+	paramNullText paramType = "nullable text"
+	paramInt      paramType = "int"
+	paramNullInt  paramType = "nullable int"
+	paramHeroID   paramType = "hero_id"
+	paramTime     paramType = "timestamp"
+	paramDate     paramType = "nullable date"
+	paramBytes    paramType = "bytes"
+	paramBool     paramType = "bool"
+)
+
+// accepts reports whether arg can be passed for a parameter of type p
+func (p paramType) accepts(arg interface{}) bool {
+	switch arg.(type) {
+	case string:
+		return p == paramText
+	case *string:
+		return p == paramNullText
+	case *Date:
+		return p == paramDate
+	case int, int64:
+		return p == paramInt
+	case *int:
+		return p == paramNullInt
+	case HeroID:
+		return p == paramHeroID
+	case time.Time:
+		return p == paramTime
+	case []byte:
+		return p == paramBytes
+	case bool:
+		return p == paramBool
+	}
+	return false
+}
+
+// namedQuery is a registered SQL statement. Every query runs through the
+// registry so the full set is auditable and each one is counted.
+type namedQuery struct {
+	Name string
+	// SQL is empty for queries assembled at runtime, which must go through Build
+	SQL string
+	// Params are checked against the arguments of fixed statements
+	Params []paramType
+
+	calls    atomic.Int64
+	failures atomic.Int64
+	slow     atomic.Int64
+	// Nanoseconds spent in the query, in total and for its slowest call
+	totalNanos atomic.Int64
+	maxNanos   atomic.Int64
+}
+
+// Queries taking longer than this are logged, set from DB_SLOW_QUERY_THRESHOLD
+var slowQueryThreshold atomic.Int64
+
+// setSlowQueryThreshold changes the slow query threshold; 0 disables logging
+func setSlowQueryThreshold(threshold time.Duration) {
+	slowQueryThreshold.Store(int64(threshold))
+}
+
+// Every registered query by name
+var (
+	queryRegistry      = make(map[string]*namedQuery)
+	queryRegistryMutex sync.Mutex
+)
+
+// registerQuery adds a fixed statement to the registry. Names must be unique.
+func registerQuery(name, statement string, params ...paramType) *namedQuery {
+	queryRegistryMutex.Lock()
+	defer queryRegistryMutex.Unlock()
+
+	if _, exists := queryRegistry[name]; exists {
+		panic(fmt.Sprintf("query %s registered twice", name))
+	}
+
+	query := &namedQuery{Name: name, SQL: statement, Params: params}
+	queryRegistry[name] = query
+	return query
+}
+
+// registerBuiltQuery adds a query whose SQL is assembled at runtime, such as
+// filtered lists. Its arguments vary and are not type-checked.
+func registerBuiltQuery(name string) *namedQuery {
+	return registerQuery(name, "")
+}
+
+// queryer is implemented by *sql.DB and *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// boundQuery is a registered query ready to run with a statement, connection and context
+type boundQuery struct {
+	query     *namedQuery
+	statement string
+	conn      queryer
+	// pool is the circuit breaker of the pool behind conn
+	pool *poolRecycler
+	ctx  context.Context
+}
+
+// bind prepares the registered statement on the shared pool
+func (q *namedQuery) bind() boundQuery {
+	return boundQuery{query: q, statement: q.SQL, conn: DB, pool: dbPool, ctx: context.Background()}
+}
+
+// Build runs the query with a statement assembled by the caller
+func (q *namedQuery) Build(statement string) boundQuery {
+	return q.bind().Build(statement)
+}
+
+// Build runs the query with a statement assembled by the caller
+func (b boundQuery) Build(statement string) boundQuery {
+	b.statement = statement
+	return b
+}
+
+// WithContext runs the registered statement under ctx
+func (q *namedQuery) WithContext(ctx context.Context) boundQuery {
+	return q.bind().WithContext(ctx)
+}
+
+// WithContext runs the query under ctx; handlers pass r.Context() so a
+// dropped connection or an expired request deadline cancels the query
+func (b boundQuery) WithContext(ctx context.Context) boundQuery {
+	b.ctx = ctx
+	return b
+}
+
+// Replica runs the registered statement on the read pool
+func (q *namedQuery) Replica() boundQuery {
+	return q.bind().Replica()
+}
+
+// Replica runs the query on the read pool. Replicas can lag, so reads that
+// must see the caller's own writes stay on the primary.
+func (b boundQuery) Replica() boundQuery {
+	b.conn = ReadDB
+	if ReadDB != DB {
+		b.pool = replicaPool
+	}
+	return b
+}
+
+// In runs the query inside tx
+func (q *namedQuery) In(tx *sql.Tx) boundQuery {
+	return q.bind().In(tx)
+}
+
+// In runs the query inside tx
+func (b boundQuery) In(tx *sql.Tx) boundQuery {
+	b.conn = tx
+	b.pool = dbPool
+	return b
+}
+
+// Exec runs the registered statement on the pool
+func (q *namedQuery) Exec(args ...interface{}) (sql.Result, error) {
+	return q.bind().Exec(args...)
+}
+
+// Query runs the registered statement on the pool
+func (q *namedQuery) Query(args ...interface{}) (*queryRows, error) {
+	return q.bind().Query(args...)
+}
+
+// QueryRow runs the registered statement on the pool
+func (q *namedQuery) QueryRow(args ...interface{}) queryRow {
+	return q.bind().QueryRow(args...)
+}
+
+// check validates the statement and arguments before anything is sent, and
+// fails fast while the circuit is open
+func (b boundQuery) check(args []interface{}) error {
+	if err := b.pool.allow(); err != nil {
+		return err
+	}
+	if b.statement == "" {
+		return fmt.Errorf("query %s has no statement", b.query.Name)
+	}
+	if b.query.SQL == "" {
+		return nil
+	}
+
+	if len(args) != len(b.query.Params) {
+		return fmt.Errorf("query %s expects %d arguments, got %d", b.query.Name, len(b.query.Params), len(args))
+	}
+	for i, param := range b.query.Params {
+		if !param.accepts(args[i]) {
+			return fmt.Errorf("query %s argument $%d must be %s, got %T", b.query.Name, i+1, param, args[i])
+		}
+	}
+	return nil
+}
+
+// record counts an execution and whether it failed, and feeds the circuit breaker of pool
+func (q *namedQuery) record(pool *poolRecycler, err error) {
+	q.calls.Add(1)
+	if err != nil && err != sql.ErrNoRows {
+		q.failures.Add(1)
+	}
+	pool.record(err)
+}
+
+// observe adds the duration of an execution and logs it when it was slow.
+// rows is the number of rows read, or -1 for statements that return none;
+// ctx carries the request ID for the log line.
+func (q *namedQuery) observe(ctx context.Context, elapsed time.Duration, rows int) {
+	q.totalNanos.Add(int64(elapsed))
+	for {
+		max := q.maxNanos.Load()
+		if int64(elapsed) <= max || q.maxNanos.CompareAndSwap(max, int64(elapsed)) {
+			break
+		}
+	}
+
+	threshold := time.Duration(slowQueryThreshold.Load())
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+	q.slow.Add(1)
+	if rows >= 0 {
+		contextLogger(ctx).Warn("Slow query", "query", q.Name, "duration_ms", durationMs(elapsed), "rows", rows)
+	} else {
+		contextLogger(ctx).Warn("Slow query", "query", q.Name, "duration_ms", durationMs(elapsed))
+	}
+}
+
+// Exec runs the statement
+func (b boundQuery) Exec(args ...interface{}) (sql.Result, error) {
+	if err := b.check(args); err != nil {
+		b.query.record(b.pool, err)
+		return nil, err
+	}
+
+	started := time.Now()
+	result, err := b.conn.ExecContext(b.ctx, b.statement, args...)
+	b.query.observe(b.ctx, time.Since(started), -1)
+	b.query.record(b.pool, err)
+	return result, err
+}
+
+// Query runs the statement and returns its rows. The execution is timed
+// until the rows are exhausted or closed.
+func (b boundQuery) Query(args ...interface{}) (*queryRows, error) {
+	if err := b.check(args); err != nil {
+		b.query.record(b.pool, err)
+		return nil, err
+	}
+
+	started := time.Now()
+	rows, err := b.conn.QueryContext(b.ctx, b.statement, args...)
+	b.query.record(b.pool, err)
+	if err != nil {
+		b.query.observe(b.ctx, time.Since(started), 0)
+		return nil, err
+	}
+	return &queryRows{Rows: rows, ctx: b.ctx, query: b.query, started: started}, nil
+}
+
+// QueryRow runs the statement; errors surface from Scan like *sql.Row
+func (b boundQuery) QueryRow(args ...interface{}) queryRow {
+	if err := b.check(args); err != nil {
+		return queryRow{query: b.query, pool: b.pool, err: err}
+	}
+	started := time.Now()
+	row := b.conn.QueryRowContext(b.ctx, b.statement, args...)
+	return queryRow{ctx: b.ctx, query: b.query, pool: b.pool, row: row, started: started}
+}
+
+// queryRows wraps *sql.Rows to count the rows read and time the query
+type queryRows struct {
+	*sql.Rows
+	ctx      context.Context
+	query    *namedQuery
+	started  time.Time
+	count    int
+	finished bool
+}
+
+// Next advances to the next row
+func (r *queryRows) Next() bool {
+	if r.Rows.Next() {
+		r.count++
+		return true
+	}
+	r.finish()
+	return false
+}
+
+// Close releases the rows
+func (r *queryRows) Close() error {
+	err := r.Rows.Close()
+	r.finish()
+	return err
+}
+
+// finish records the execution once
+func (r *queryRows) finish() {
+	if r.finished {
+		return
+	}
+	r.finished = true
+	r.query.observe(r.ctx, time.Since(r.started), r.count)
+}
+
+// queryRow wraps *sql.Row so the execution is recorded once it is scanned
+type queryRow struct {
+	ctx     context.Context
+	query   *namedQuery
+	pool    *poolRecycler
+	row     *sql.Row
+	err     error
+	started time.Time
+}
+
+// Scan copies the row into dest
+func (r queryRow) Scan(dest ...interface{}) error {
+	err := r.err
+	if err == nil {
+		err = r.row.Scan(dest...)
+		r.query.observe(r.ctx, time.Since(r.started), -1)
+	}
+	r.query.record(r.pool, err)
+	return err
+}
+
+// queryCoverage reports invocation counts and the registered queries never executed
+func queryCoverage() QueryCoverageReport {
+	queryRegistryMutex.Lock()
+	defer queryRegistryMutex.Unlock()
+
+	report := QueryCoverageReport{
+		Registered: len(queryRegistry),
+		Threshold:  queryCoverageThreshold,
+		Queries:    make([]QueryStats, 0, len(queryRegistry)),
+		Unused:     []string{},
+	}
+
+	for name, query := range queryRegistry {
+		calls := query.calls.Load()
+		report.Queries = append(report.Queries, QueryStats{
+			Name:            name,
+			Calls:           calls,
+			Failures:        query.failures.Load(),
+			SlowCalls:       query.slow.Load(),
+			TotalDurationMs: float64(query.totalNanos.Load()) / float64(time.Millisecond),
+			MaxDurationMs:   float64(query.maxNanos.Load()) / float64(time.Millisecond),
+		})
+		if calls == 0 {
+			report.Unused = append(report.Unused, name)
+		} else {
+			report.Executed++
+		}
+	}
+
+	sort.Slice(report.Queries, func(i, j int) bool { return report.Queries[i].Name < report.Queries[j].Name })
+	sort.Strings(report.Unused)
+
+	if report.Registered > 0 {
+		report.Coverage = float64(report.Executed) / float64(report.Registered)
+	}
+	return report
+}
+
+// checkQueryCoverage fails when fewer than threshold of the registered queries
+// have run. Test suites call it after all tests, e.g. from TestMain.
+func checkQueryCoverage(threshold float64) error {
+	report := queryCoverage()
+	if report.Coverage >= threshold {
+		return nil
+	}
+	return fmt.Errorf("query coverage %.0f%% is below %.0f%%; never executed: %v",
+		report.Coverage*100, threshold*100, report.Unused)
+}
+
+// GET /api/admin/queries - Named query inventory
+// @Summary Query inventory
+// @Description Every registered SQL query with invocation, failure and slow call counts and time spent, and those never executed
+// @Tags admin
+// @Produce json,application/xml
+// @Success 200 {object} QueryCoverageReport
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/queries [get]
+func getQueryInventory(w http.ResponseWriter, r *http.Request) {
+	respondWith(w, r, http.StatusOK, queryCoverage())
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+func TestSlowQueryLogging(t *testing.T) {
+	useTestConfig(t)
+	connector := useFakeDB(t)
+	connector.delay = 20 * time.Millisecond
+	connector.respond = func(string, []interface{}) *fakeResult {
+		return &fakeResult{columns: []string{"exists"}, rows: [][]driver.Value{{true}}}
+	}
+	previous := time.Duration(slowQueryThreshold.Load())
+	t.Cleanup(func() { setSlowQueryThreshold(previous) })
+	setSlowQueryThreshold(10 * time.Millisecond)
+
+	ctx := context.WithValue(context.Background(), requestLogContextKey, &requestLog{ID: "req-slow", User: "admin"})
+
+	tests := []struct {
+		name  string
+		query *namedQuery
+		run   func() error
+	}{
+		{"single row", queryTokenRevoked, func() error {
+			var revoked bool
+			return queryTokenRevoked.WithContext(ctx).QueryRow("jti").Scan(&revoked)
+		}},
+		{"statement", queryPurgeRevokedTokens, func() error {
+			_, err := queryPurgeRevokedTokens.WithContext(ctx).Exec(time.Now())
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			slowBefore := tt.query.slow.Load()
+
+			if err := tt.run(); err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.query.slow.Load() - slowBefore; got != 1 {
+				t.Errorf("slow calls grew by %d, want 1", got)
+			}
+			if max := time.Duration(tt.query.maxNanos.Load()); max < connector.delay {
+				t.Errorf("slowest call %s, want at least the %s the query took", max, connector.delay)
+			}
+
+			record := findLog(t, logs, "Slow query")
+			for key, want := range map[string]interface{}{
+				"level":      "WARN",
+				"query":      tt.query.Name,
+				"request_id": "req-slow",
+				"user":       "admin",
+			} {
+				if record[key] != want {
+					t.Errorf("log %s = %v, want %v", key, record[key], want)
+				}
+			}
+			if ms, _ := record["duration_ms"].(float64); ms < 20 {
+				t.Errorf("duration_ms = %v, want at least 20", record["duration_ms"])
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Route groups with separate rate limits
+const (
+	rateGroupReads  = "reads"
+	rateGroupWrites = "writes"
+	rateGroupLogin  = "login"
+)
+
+// RateLimit is a token bucket setting for one route group
+type RateLimit struct {
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	Burst             int `yaml:"burst"`
+}
+
+// RateLimitConfig holds the rate limits from config.yaml
+type RateLimitConfig struct {
+	Enabled        *bool     `yaml:"enabled"`
+	TrustedProxies []string  `yaml:"trusted_proxies"`
+	Reads          RateLimit `yaml:"reads"`
+	Writes         RateLimit `yaml:"writes"`
+	Login          RateLimit `yaml:"login"`
+}
+
+// withDefaults fills unset limits with conservative defaults
+func (c RateLimitConfig) withDefaults() RateLimitConfig {
+	if c.Enabled == nil {
+		enabled := true
+		c.Enabled = &enabled
+	}
+	if c.Reads.RequestsPerMinute == 0 {
+		c.Reads = RateLimit{RequestsPerMinute: 300, Burst: 60}
+	}
+	if c.Writes.RequestsPerMinute == 0 {
+		c.Writes = RateLimit{RequestsPerMinute: 60, Burst: 20}
+	}
+	if c.Login.RequestsPerMinute == 0 {
+		c.Login = RateLimit{RequestsPerMinute: 10, Burst: 5}
+	}
+	return c
+}
+
+// bucket is the token bucket state for one client
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a set of token buckets sharing one limit
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   RateLimit
+	perSec  float64
+	buckets map[string]*bucket
+}
+
+// newRateLimiter creates a limiter refilling limit.RequestsPerMinute tokens per minute
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &rateLimiter{
+		limit:   limit,
+		perSec:  float64(limit.RequestsPerMinute) / 60,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// allow takes a token for key and reports the remaining tokens, how long until
+// the next token and how long until the bucket is full again
+func (l *rateLimiter) allow(key string, now time.Time) (bool, int, time.Duration, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	burst := float64(l.limit.Burst)
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*l.perSec)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	retryAfter := time.Duration(0)
+	if b.tokens < 1 {
+		retryAfter = time.Duration((1 - b.tokens) / l.perSec * float64(time.Second))
+	}
+	resetIn := time.Duration((burst - b.tokens) / l.perSec * float64(time.Second))
+
+	return allowed, int(b.tokens), retryAfter, resetIn
+}
+
+// sweep removes buckets that have refilled completely, since they hold no state
+func (l *rateLimiter) sweep(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	burst := float64(l.limit.Burst)
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.perSec >= burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Rate limiters per route group, built from config in initRateLimits
+var (
+	rateLimiters   map[string]*rateLimiter
+	trustedProxies []*net.IPNet
+)
+
+// initRateLimits builds the limiters and starts the stale bucket sweep
+func initRateLimits(cfg RateLimitConfig) error {
+	trustedProxies = nil
+	for _, entry := range cfg.TrustedProxies {
+		network, err := parseTrustedProxy(entry)
+		if err != nil {
+			return err
+		}
+		trustedProxies = append(trustedProxies, network)
+	}
+
+	if !*cfg.Enabled {
+		rateLimiters = nil
+		slog.Info("Rate limiting disabled")
+		return nil
+	}
+
+	rateLimiters = map[string]*rateLimiter{
+		rateGroupReads:  newRateLimiter(cfg.Reads),
+		rateGroupWrites: newRateLimiter(cfg.Writes),
+		rateGroupLogin:  newRateLimiter(cfg.Login),
+	}
+
+	go func() {
+		for {
+			time.Sleep(time.Minute)
+			now := time.Now()
+			for _, limiter := range rateLimiters {
+				limiter.sweep(now)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// parseTrustedProxy parses a CIDR range or a bare IP address
+func parseTrustedProxy(entry string) (*net.IPNet, error) {
+	if !strings.Contains(entry, "/") {
+		if strings.Contains(entry, ":") {
+			entry += "/128"
+		} else {
+			entry += "/32"
+		}
+	}
+	_, network, err := net.ParseCIDR(entry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxy %q: %v", entry, err)
+	}
+	return network, nil
+}
+
+// Classify a request into a rate limit group
+func rateGroup(r *http.Request) string {
+	if strings.HasSuffix(r.URL.Path, "/login") {
+		return rateGroupLogin
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return rateGroupReads
+	}
+	return rateGroupWrites
+}
+
+// Check whether ip belongs to a configured trusted proxy
+func isTrustedProxy(ip net.IP) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// fromTrustedProxy reports whether r was sent by a trusted proxy, whose
+// forwarding headers may then be believed
+func fromTrustedProxy(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && isTrustedProxy(ip)
+}
+
+// Determine the client IP. X-Forwarded-For is only honoured when the request
+// comes from a trusted proxy, and is walked right to left past trusted hops.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !isTrustedProxy(ip) {
+		return host
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		if !isTrustedProxy(hop) {
+			return hop.String()
+		}
+	}
+	return host
+}
+
+// Key requests by a valid bearer token, falling back to the client IP
+func rateLimitKey(r *http.Request) string {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if _, exists := lookupSession(token); exists {
+		return "token:" + token
+	}
+	return "ip:" + clientIP(r)
+}
+
+// Rate limit middleware returning 429 with Retry-After when a client exceeds its group's limit
+func rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter, exists := rateLimiters[rateGroup(r)]
+		if !exists || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := time.Now()
+		allowed, remaining, retryAfter, resetIn := limiter.allow(rateLimitKey(r), now)
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limiter.limit.RequestsPerMinute))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(resetIn).Unix(), 10))
+
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			respondWithError(w, r, http.StatusTooManyRequests, ErrCodeRateLimited, "Rate limit exceeded")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// audience is how much of a resource a caller may see. Fields tagged
+// `audience:"authenticated"` or `audience:"admin"` are zeroed for lower audiences,
+// so they must also be omitempty (pointers, strings, numbers) to disappear from
+// the output. Untagged fields are public.
+type audience int
+
+// Audiences, from least to most privileged
+const (
+	audiencePublic audience = iota
+	audienceAuthenticated
+	audienceAdmin
+)
+
+// Tag values accepted in `audience:"..."`
+var audienceNames = map[string]audience{
+	"public":        audiencePublic,
+	"authenticated": audienceAuthenticated,
+	"admin":         audienceAdmin,
+}
+
+// String returns the tag name of the audience
+func (a audience) String() string {
+	for name, level := range audienceNames {
+		if level == a {
+			return name
+		}
+	}
+	return "public"
+}
+
+// parseAudience returns the audience named by a tag value or setting
+func parseAudience(name string) (audience, error) {
+	level, ok := audienceNames[name]
+	if !ok {
+		return audiencePublic, fmt.Errorf("unknown audience %q, must be public, authenticated or admin", name)
+	}
+	return level, nil
+}
+
+// audienceFor derives the caller's audience from their token and role
+func audienceFor(r *http.Request) audience {
+	if r == nil {
+		return audiencePublic
+	}
+
+	session, ok := sessionFromRequest(r)
+	if !ok {
+		session, ok = lookupSession(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	}
+	switch {
+	case !ok:
+		return audiencePublic
+	case session.Role == roleAdmin:
+		return audienceAdmin
+	default:
+		return audienceAuthenticated
+	}
+}
+
+// redact returns a copy of payload with every field above level zeroed.
+// It walks pointers, slices, maps, interfaces and embedded structs, so single
+// resources, lists and wrapped payloads are all covered.
+func redact(payload interface{}, level audience) interface{} {
+	if payload == nil || level == audienceAdmin {
+		return payload
+	}
+
+	value := reflect.ValueOf(payload)
+	if !hasRestrictedFields(value.Type()) {
+		return payload
+	}
+	return redactValue(value, level).Interface()
+}
+
+// redactValue copies v, zeroing restricted fields on the way down
+func redactValue(v reflect.Value, level audience) reflect.Value {
+	if !hasRestrictedFields(v.Type()) {
+		return v
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Elem().Type())
+		copied.Elem().Set(redactValue(v.Elem(), level))
+		return copied
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(redactValue(v.Elem(), level))
+		return copied
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return v
+		}
+		var copied reflect.Value
+		if v.Kind() == reflect.Slice {
+			copied = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		} else {
+			copied = reflect.New(v.Type()).Elem()
+		}
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(redactValue(v.Index(i), level))
+		}
+		return copied
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			copied.SetMapIndex(iter.Key(), redactValue(iter.Value(), level))
+		}
+		return copied
+
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if required, ok := audienceNames[field.Tag.Get("audience")]; ok && required > level {
+				copied.Field(i).SetZero()
+				continue
+			}
+			copied.Field(i).Set(redactValue(v.Field(i), level))
+		}
+		return copied
+	}
+
+	return v
+}
+
+// Cache of whether a type contains any audience tag, by reflect.Type
+var restrictedTypes sync.Map
+
+// hasRestrictedFields reports whether values of t can hold tagged fields.
+// Interfaces are always walked since their dynamic type is unknown.
+func hasRestrictedFields(t reflect.Type) bool {
+	if cached, ok := restrictedTypes.Load(t); ok {
+		return cached.(bool)
+	}
+
+	restricted := containsRestricted(t, map[reflect.Type]bool{})
+	restrictedTypes.Store(t, restricted)
+	return restricted
+}
+
+// containsRestricted walks t, skipping types already being visited so recursive types terminate
+func containsRestricted(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return containsRestricted(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if _, tagged := audienceNames[field.Tag.Get("audience")]; tagged || containsRestricted(field.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// taggedFields collects the JSON names of every field of t with an audience
+// tag, through pointers, slices, maps and embedded structs
+func taggedFields(t reflect.Type, found map[string]audience) {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		taggedFields(t.Elem(), found)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if tag, ok := field.Tag.Lookup("audience"); ok {
+				name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+				found[name] = audienceNames[tag]
+			}
+			taggedFields(field.Type, found)
+		}
+	}
+}
+
+// jsonKeys collects every object key in a decoded JSON document
+func jsonKeys(value interface{}, keys map[string]bool) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range value {
+			keys[key] = true
+			jsonKeys(nested, keys)
+		}
+	case []interface{}:
+		for _, nested := range value {
+			jsonKeys(nested, keys)
+		}
+	}
+}
+
+// assertAudience checks body shows exactly the tagged fields of payload at or below level
+func assertAudience(t *testing.T, body []byte, payload interface{}, level audience) {
+	t.Helper()
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		t.Fatalf("body %s is not JSON: %v", body, err)
+	}
+	keys := make(map[string]bool)
+	jsonKeys(document, keys)
+
+	tagged := make(map[string]audience)
+	taggedFields(reflect.TypeOf(payload), tagged)
+	for name, minimum := range tagged {
+		if minimum > level && keys[name] {
+			t.Errorf("%s field %s is shown to %s callers: %s", minimum, name, level, body)
+		}
+		if minimum <= level && !keys[name] {
+			t.Errorf("%s field %s is missing for %s callers: %s", minimum, name, level, body)
+		}
+	}
+}
+
+func TestFieldAudienceMatrix(t *testing.T) {
+	useTestConfig(t)
+
+	archivedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	deletedAt := archivedAt.Add(time.Hour)
+	hero := Hero{ID: "1", Name: "Alucard", Role: "Fighter", Tags: []string{}, ArchivedAt: &archivedAt, DeletedAt: &deletedAt}
+
+	// Every payload shape embedding heroes that the respond helpers send
+	payloads := []struct {
+		name    string
+		payload interface{}
+	}{
+		{"single", hero},
+		{"list", []Hero{hero, hero}},
+		{"created", CreatedHero{Hero: hero, Warnings: []string{"no lore"}}},
+		{"trending", []TrendingHero{{Hero: hero, Views: 42}}},
+		{"draft", HeroDraft{Heroes: []Hero{hero}, UnfilledRoles: []string{}}},
+		{"tier assignment", TierAssignment{Patch: "1.8", Tier: "S", Hero: hero}},
+		{"tier list", TierList{Patch: "1.8", Order: []string{"S"}, Tiers: map[string][]Hero{"S": {hero}}}},
+		{"export", []interface{}{hero, &hero}},
+	}
+
+	callers := []struct {
+		role  string
+		level audience
+	}{
+		{"", audiencePublic},
+		{roleUser, audienceAuthenticated},
+		{roleAdmin, audienceAdmin},
+	}
+
+	tagged := make(map[string]audience)
+	for _, payload := range payloads {
+		taggedFields(reflect.TypeOf(payload.payload), tagged)
+	}
+	for _, name := range []string{"archived_at", "deleted_at", "views", "warnings"} {
+		if _, ok := tagged[name]; !ok {
+			t.Errorf("%s has no audience tag", name)
+		}
+	}
+
+	for _, caller := range callers {
+		token := ""
+		if caller.role != "" {
+			token = addTestSession(t, caller.role)
+		}
+		for _, payload := range payloads {
+			t.Run(payload.name+" as "+caller.level.String(), func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, "/api/heroes", nil)
+				if token != "" {
+					r.Header.Set("Authorization", "Bearer "+token)
+				}
+				if got := audienceFor(r); got != caller.level {
+					t.Fatalf("audienceFor() = %s, want %s", got, caller.level)
+				}
+
+				rec := httptest.NewRecorder()
+				respondWith(rec, r, http.StatusOK, payload.payload)
+				assertAudience(t, rec.Body.Bytes(), payload.payload, caller.level)
+			})
+		}
+	}
+}
+
+func TestRedactLeavesPayloadUntouched(t *testing.T) {
+	archivedAt := time.Now()
+	heroes := []TrendingHero{{Hero: Hero{ID: "1", ArchivedAt: &archivedAt}, Views: 7}}
+
+	redacted := redact(heroes, audiencePublic).([]TrendingHero)
+	if redacted[0].Views != 0 || redacted[0].ArchivedAt != nil {
+		t.Errorf("redacted = %+v, want views and archived_at cleared", redacted[0])
+	}
+	if heroes[0].Views != 7 || heroes[0].ArchivedAt == nil {
+		t.Errorf("redact modified the caller's payload: %+v", heroes[0])
+	}
+}
+
+func TestEventAudience(t *testing.T) {
+	useTestConfig(t)
+	userToken, adminToken := addTestSession(t, roleUser), addTestSession(t, roleAdmin)
+
+	tests := []struct {
+		setting string
+		token   string
+		want    audience
+	}{
+		{"admin", "", audiencePublic},
+		{"admin", userToken, audienceAuthenticated},
+		{"admin", adminToken, audienceAdmin},
+		{"authenticated", adminToken, audienceAuthenticated},
+		{"authenticated", "", audiencePublic},
+		{"public", adminToken, audiencePublic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.setting, func(t *testing.T) {
+			config.Events.Audience = tt.setting
+			r := httptest.NewRequest(http.MethodGet, "/api/heroes/events", nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			if got := eventAudience(r); got != tt.want {
+				t.Errorf("eventAudience() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// The stream redacts every event with eventAudience, so EVENTS_AUDIENCE=public
+// keeps admin-only fields off it even for admins
+func TestStreamAppliesEventAudience(t *testing.T) {
+	useTestConfig(t)
+	config.Events.Audience = "authenticated"
+	adminToken := addTestSession(t, roleAdmin)
+
+	archivedAt := time.Now().UTC()
+	deletedAt := archivedAt
+	hero := Hero{ID: "5", Name: "Miya", ArchivedAt: &archivedAt, DeletedAt: &deletedAt}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := httptest.NewRequest(http.MethodGet, "/api/heroes/events?include=all", nil).WithContext(ctx)
+	r.Header.Set("Authorization", "Bearer "+adminToken)
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		streamHeroEvents(rec, r)
+	}()
+
+	if !waitFor(t, time.Second, func() bool {
+		heroEvents.mu.RLock()
+		defer heroEvents.mu.RUnlock()
+		return len(heroEvents.subscribers) > 0
+	}) {
+		t.Fatal("stream never subscribed")
+	}
+	heroEvents.publish(HeroEvent{Type: eventUpdated, Hero: hero})
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	_, data, found := strings.Cut(rec.Body.String(), "data: ")
+	if !found {
+		t.Fatalf("no event in %q", rec.Body.String())
+	}
+	data, _, _ = strings.Cut(data, "\n")
+	assertAudience(t, []byte(data), hero, audienceAuthenticated)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+var queryDeleteUserByName = registerQuery("users.delete_by_name", "DELETE FROM users WHERE username = $1", paramText)
+
+// watchUserReload re-imports the config.yaml users from path on every SIGHUP
+// until ctx is cancelled. users is the list loaded at startup.
+func watchUserReload(ctx context.Context, path string, users []User) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			next, err := reloadUsers(ctx, path, users)
+			if err != nil {
+				slog.Error("User reload failed, keeping previous users", "file", path, "error", err)
+				continue
+			}
+			users = next
+		}
+	}
+}
+
+// reloadUsers re-reads path and applies its user list to the users table:
+// newly listed users are imported and users dropped since previous are
+// deleted. Users that stay listed keep their stored password and role, and
+// accounts created through /api/users are left alone. On any configuration
+// error nothing changes. Other settings still need a restart.
+func reloadUsers(ctx context.Context, path string, previous []User) ([]User, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	imported := 0
+	for _, user := range cfg.Users {
+		inserted, err := importUser(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if inserted {
+			imported++
+			slog.Info("AUDIT user imported from config", "user", user.Username)
+		}
+	}
+
+	removed := removedUsers(previous, cfg.Users)
+	for _, username := range removed {
+		if _, err := queryDeleteUserByName.WithContext(ctx).Exec(username); err != nil {
+			return nil, fmt.Errorf("failed to delete user %s: %v", username, err)
+		}
+		if cfg.RevokeRemovedUsers {
+			revoked := revokeUserTokens(username, "")
+			slog.Info("AUDIT user removed from config", "user", username, "revoked", revoked)
+		} else {
+			slog.Info("AUDIT user removed from config, existing tokens stay valid until expiry", "user", username)
+		}
+	}
+
+	slog.Info("Reloaded users", "file", path, "users", len(cfg.Users), "imported", imported, "removed", len(removed))
+	return cfg.Users, nil
+}
+
+// removedUsers returns the usernames of previous that next no longer lists
+func removedUsers(previous, next []User) []string {
+	kept := make(map[string]bool, len(next))
+	for _, user := range next {
+		kept[user.Username] = true
+	}
+
+	var removed []string
+	for _, user := range previous {
+		if !kept[user.Username] {
+			removed = append(removed, user.Username)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRemovedUsers(t *testing.T) {
+	users := func(names ...string) []User {
+		var list []User
+		for _, name := range names {
+			list = append(list, User{Username: name, Password: "secret"})
+		}
+		return list
+	}
+
+	tests := []struct {
+		name           string
+		previous, next []User
+		want           []string
+	}{
+		{"unchanged", users("alice", "bob"), users("alice", "bob"), nil},
+		{"user added", users("alice"), users("alice", "bob"), nil},
+		{"user removed", users("alice", "bob"), users("bob"), []string{"alice"}},
+		{"all removed", users("alice", "bob"), nil, []string{"alice", "bob"}},
+		{"renamed", users("alice"), users("alicia"), []string{"alice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := removedUsers(tt.previous, tt.next); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("removedUsers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReloadUsersKeepsPreviousUsersOnInvalidConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("users: [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := reloadUsers(context.Background(), path, []User{{Username: "alice"}}); err == nil {
+		t.Fatal("reloadUsers() accepted an invalid config.yaml")
+	}
+}
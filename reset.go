@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Tables emptied by POST /api/admin/reset: heroes and every table keyed by a hero
+var resetTables = []string{"heroes", "hero_tags", "tags", "hero_tiers", "hero_views", "favorites", "hero_counters"}
+
+// Queries of the development reset; table names come from resetTables only
+var (
+	queryResetTruncate = registerBuiltQuery("dev.reset_truncate")
+)
+
+// registerDevRoutes mounts the development endpoints. They only exist when
+// ENABLE_DEV_ENDPOINTS is set, so otherwise they answer 404 like any unknown path.
+func registerDevRoutes(api *mux.Router) {
+	api.Handle("/admin/reset", adminMiddleware(destructiveMiddleware(describeReset, http.HandlerFunc(resetHeroes)))).Methods("POST")
+}
+
+// POST /api/admin/reset - Restore the starter roster
+// @Summary Reset heroes (development only)
+// @Description Empty the heroes table and every table keyed by a hero, then insert the starter roster (SEED_FILE or the built-in one) in the same transaction.
+// @Description With seed=false the tables are only emptied. Only available when ENABLE_DEV_ENDPOINTS is set; otherwise 404.
+// @Tags admin
+// @Produce json,application/xml
+// @Param seed query bool false "Insert the starter roster after emptying the tables (default true)"
+// @Success 200 {object} ResetResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 428 {object} ConfirmationRequiredResponse
+// @Security BearerAuth
+// @Router /api/admin/reset [post]
+func resetHeroes(w http.ResponseWriter, r *http.Request) {
+	seed := true
+	if value := r.URL.Query().Get("seed"); value != "" {
+		var err error
+		if seed, err = strconv.ParseBool(value); err != nil {
+			respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, "seed must be true or false")
+			return
+		}
+	}
+
+	var heroes []seedHero
+	if seed {
+		var err error
+		if heroes, err = seedRoster(config.Database); err != nil {
+			respondWithInternalError(w, r, err, "Failed to load seed heroes")
+			return
+		}
+	}
+
+	tx, err := beginTx(r.Context(), nil)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to reset heroes")
+		return
+	}
+	defer tx.Rollback()
+
+	// RESTART IDENTITY gives the reseeded heroes serial IDs from 1 again
+	if _, err := queryResetTruncate.WithContext(r.Context()).Build("TRUNCATE " + strings.Join(resetTables, ", ") + " RESTART IDENTITY").In(tx).Exec(); err != nil {
+		respondWithInternalError(w, r, err, "Failed to reset heroes")
+		return
+	}
+
+	seeded, err := insertSeedHeroes(tx, heroes)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to seed heroes")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithInternalError(w, r, err, "Failed to reset heroes")
+		return
+	}
+
+	// Buffered views belong to heroes that no longer exist
+	heroViews.mu.Lock()
+	heroViews.pending = make(map[HeroID]int64)
+	heroViews.mu.Unlock()
+	invalidateHeroCache()
+
+	session, _ := sessionFromRequest(r)
+	requestLogger(r).Info("AUDIT heroes reset", "seeded", seeded, "by", session.Username)
+
+	respondWith(w, r, http.StatusOK, ResetResult{Seeded: seeded})
+}
+
+// Describe the rows removed by POST /api/admin/reset
+func describeReset(r *http.Request) (AffectedRows, error) {
+	return countTableRows(r.Context(), resetTables)
+}
+
+// Counts rows of the named tables for confirmation prompts
+var queryCountTableRows = registerBuiltQuery("admin.count_table_rows")
+
+// countTableRows counts the rows of each table; names must be constants, never input
+func countTableRows(ctx context.Context, tables []string) (AffectedRows, error) {
+	affected := AffectedRows{}
+	for _, table := range tables {
+		var count int
+		if err := queryCountTableRows.WithContext(ctx).Build("SELECT COUNT(*) FROM " + table).QueryRow().Scan(&count); err != nil {
+			return nil, err
+		}
+		affected[table] = count
+	}
+	return affected, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Queries on revoked_tokens
+var (
+	queryRevokeToken        = registerQuery("revoked_tokens.revoke", "INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING", paramText, paramTime)
+	queryTokenRevoked       = registerQuery("revoked_tokens.check", "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)", paramText)
+	queryPurgeRevokedTokens = registerQuery("revoked_tokens.purge", "DELETE FROM revoked_tokens WHERE expires_at < $1", paramTime)
+)
+
+// persistRevocations records revoked sessions by ID (the token's jti) so they
+// stay revoked across restarts, which matters once tokens are self-contained
+// JWTs instead of entries in validTokens
+func persistRevocations(sessions ...Session) {
+	for _, session := range sessions {
+		if session.ID == "" {
+			continue
+		}
+		if _, err := queryRevokeToken.Exec(session.ID, session.ExpiresAt); err != nil {
+			slog.Error("Failed to persist session revocation", "session", session.ID, "user", session.Username, "error", err)
+		}
+	}
+}
+
+// tokenRevoked reports whether the token with this jti has been revoked
+func tokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	err := queryTokenRevoked.WithContext(ctx).QueryRow(jti).Scan(&revoked)
+	return revoked, err
+}
+
+// purgeRevokedTokens forgets revocations of tokens that have expired anyway
+func purgeRevokedTokens() {
+	result, err := queryPurgeRevokedTokens.Exec(time.Now())
+	if err != nil {
+		slog.Error("Token cleanup failed to purge revoked tokens", "error", err)
+		return
+	}
+	if purged, _ := result.RowsAffected(); purged > 0 {
+		slog.Info("Token cleanup purged expired revocations", "purged", purged)
+	}
+}
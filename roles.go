@@ -0,0 +1,122 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Changes the role of one hero during a batch reassignment
+var queryReassignRole = registerQuery("heroes.reassign_role", "UPDATE heroes SET role = $1 WHERE id = $2 RETURNING "+heroColumns,
+	paramText, paramHeroID)
+
+// PATCH /api/heroes/roles - Reassign the roles of many heroes
+// @Summary Reassign hero roles
+// @Description Change the role of several heroes in one transaction, e.g. after a meta shift. Every role must be one of the
+// @Description roles in display_order (matched case-insensitively); any invalid entry rejects the whole batch with 422 and nothing is changed.
+// @Description IDs that match no hero are listed in not_found while the other heroes are still updated.
+// @Tags heroes
+// @Accept json
+// @Produce json,application/xml
+// @Param assignments body []HeroRoleAssignment true "New role per hero"
+// @Success 200 {object} RoleReassignmentResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/roles [patch]
+func reassignHeroRoles(w http.ResponseWriter, r *http.Request) {
+	var assignments []HeroRoleAssignment
+	if !decodeJSONBody(w, r, &assignments) {
+		return
+	}
+	if violations := validateRoleAssignments(assignments, currentDisplayOrder().Roles); len(violations) > 0 {
+		respondWithViolations(w, r, violations)
+		return
+	}
+
+	tx, err := beginTx(r.Context(), nil)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to reassign roles")
+		return
+	}
+	defer tx.Rollback()
+
+	result := RoleReassignmentResult{NotFound: []HeroID{}}
+	var updated []Hero
+	for _, assignment := range assignments {
+		var hero Hero
+		err := queryReassignRole.WithContext(r.Context()).In(tx).QueryRow(assignment.Role, assignment.ID).Scan(heroScanDest(&hero)...)
+		if err == sql.ErrNoRows {
+			result.NotFound = append(result.NotFound, assignment.ID)
+			continue
+		}
+		if err != nil {
+			respondWithInternalError(w, r, err, "Failed to reassign roles")
+			return
+		}
+		updated = append(updated, hero)
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithInternalError(w, r, err, "Failed to reassign roles")
+		return
+	}
+	result.Updated = len(updated)
+
+	if len(updated) > 0 {
+		invalidateHeroCache()
+		for _, hero := range updated {
+			publishHeroEvent(r, eventUpdated, hero)
+		}
+		session, _ := sessionFromRequest(r)
+		requestLogger(r).Info("AUDIT hero roles reassigned", "updated", result.Updated, "not_found", len(result.NotFound), "by", session.Username)
+	}
+
+	respondWith(w, r, http.StatusOK, result)
+}
+
+// validateRoleAssignments checks every ID and role, normalizing both in place;
+// roles are rewritten in their configured spelling
+func validateRoleAssignments(assignments []HeroRoleAssignment, roles []string) []Violation {
+	var violations []Violation
+	if len(assignments) == 0 {
+		return []Violation{{Path: jsonPointer(), Message: "at least one assignment is required"}}
+	}
+
+	seen := make(map[HeroID]bool, len(assignments))
+	for i := range assignments {
+		assignment := &assignments[i]
+		index := strconv.Itoa(i)
+
+		id, err := heroIDs.Parse(string(assignment.ID))
+		if err != nil {
+			violations = append(violations, Violation{Path: jsonPointer(index, "id"), Message: "invalid hero id"})
+		} else if seen[id] {
+			violations = append(violations, Violation{Path: jsonPointer(index, "id"), Message: fmt.Sprintf("hero %s appears more than once", id)})
+		}
+		assignment.ID = id
+		seen[id] = true
+
+		role, known := canonicalRole(assignment.Role, roles)
+		if !known {
+			violations = append(violations, Violation{
+				Path:    jsonPointer(index, "role"),
+				Message: fmt.Sprintf("unknown role %q, allowed roles are %s", assignment.Role, strings.Join(roles, ", ")),
+			})
+		}
+		assignment.Role = role
+	}
+	return violations
+}
+
+// canonicalRole returns the configured spelling of role, matched case-insensitively
+func canonicalRole(role string, roles []string) (string, bool) {
+	role = strings.TrimSpace(role)
+	for _, candidate := range roles {
+		if strings.EqualFold(candidate, role) {
+			return candidate, true
+		}
+	}
+	return role, false
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"mobile-legends-api/docs"
+
+	"github.com/gorilla/mux"
+	httpSwagger "github.com/swaggo/http-swagger"
+)
+
+// apiPath returns the public path of an API route under BASE_PATH, e.g.
+// apiPath("/heroes/1") is /mlbb/api/heroes/1 with BASE_PATH=/mlbb
+func apiPath(path string) string {
+	return config.Server.BasePath + "/api" + path
+}
+
+// apiURL returns the absolute URL of an API route as the client reached it,
+// e.g. for Location headers. Behind a trusted proxy X-Forwarded-Proto and
+// X-Forwarded-Host name the public scheme and host.
+func apiURL(r *http.Request, path string) string {
+	target := url.URL{Scheme: "http", Host: r.Host, Path: apiPath(path)}
+	if r.TLS != nil {
+		target.Scheme = "https"
+	}
+	if fromTrustedProxy(r) {
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			target.Scheme = proto
+		}
+		if host := r.Header.Get("X-Forwarded-Host"); host != "" {
+			target.Host = strings.TrimSpace(strings.Split(host, ",")[0])
+		}
+	}
+	return target.String()
+}
+
+// apiRoutePath returns the path of r relative to the API root, e.g. /login
+func apiRoutePath(r *http.Request) string {
+	return strings.TrimPrefix(r.URL.Path, config.Server.BasePath+"/api")
+}
+
+// NewRouter registers every route and middleware. Handlers read the active
+// configuration and database, so both must be set up before serving; tests
+// can mount the result on an httptest.Server.
+func NewRouter(cfg AppConfig) *mux.Router {
+	router := mux.NewRouter()
+
+	// Apply CORS and security header middleware. mux only runs middleware for
+	// matched routes, so the 404 and 405 handlers are wrapped in it as well.
+	securityHeaders := securityHeadersMiddleware(cfg.TLS.Enabled())
+	router.Use(corsMiddleware)
+	router.Use(securityHeaders)
+	router.MethodNotAllowedHandler = corsMiddleware(securityHeaders(methodNotAllowedHandler(router)))
+	router.NotFoundHandler = corsMiddleware(securityHeaders(http.HandlerFunc(notFoundHandler)))
+
+	// Every route lives under BASE_PATH; anything outside it is a 404
+	root := router
+	if cfg.Server.BasePath != "" {
+		root = router.PathPrefix(cfg.Server.BasePath).Subrouter()
+	}
+
+	// Swagger documentation; "Try it out" calls the routes under BASE_PATH
+	docs.SwaggerInfo.BasePath = cfg.Server.BasePath + "/"
+	root.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+
+	// Health checks
+	root.HandleFunc("/health/live", liveness).Methods("GET")
+	root.HandleFunc("/health/ready", readiness).Methods("GET")
+	root.HandleFunc("/version", getVersion).Methods("GET")
+
+	// Profiling, admin only and off unless DEBUG_PPROF is set
+	if cfg.Debug.Pprof {
+		registerDebugRoutes(root, cfg.Server.BasePath)
+	}
+
+	// API routes
+	api := root.PathPrefix("/api").Subrouter()
+	api.Use(bodyLimitMiddleware(int64(cfg.Server.MaxBodyBytes), map[string]int64{
+		cfg.Server.BasePath + "/api/admin/import": int64(cfg.Server.MaxImportBytes),
+	}))
+	api.Use(timeoutMiddleware(cfg.Server.HandlerTimeouts))
+	api.Use(schemaGateMiddleware)
+	api.Use(maintenanceMiddleware)
+	api.Use(rateLimitMiddleware)
+	api.Use(noStoreMiddleware)
+	api.Use(negotiationMiddleware)
+
+	// Authentication routes (no auth required)
+	api.HandleFunc("/login", login).Methods("POST")
+	api.HandleFunc("/logout", logout).Methods("POST")
+
+	// Heroes routes
+	api.Handle("/heroes", cacheMiddleware(heroCache, http.HandlerFunc(getHeroes))).Methods("GET", "HEAD")
+	api.HandleFunc("/heroes/search", searchHeroes).Methods("GET")
+	api.HandleFunc("/heroes/trending", getTrendingHeroes).Methods("GET")
+	api.HandleFunc("/heroes/draft", getHeroDraft).Methods("GET")
+	api.HandleFunc("/heroes/events", streamHeroEvents).Methods("GET")
+	api.Handle("/heroes/{id}", heroViewMiddleware(cacheMiddleware(heroCache, http.HandlerFunc(getHeroByID)))).Methods("GET", "HEAD")
+	api.HandleFunc("/heroes/{id}/exists", heroExists).Methods("GET")
+	api.HandleFunc("/heroes", authMiddleware(idempotencyMiddleware(http.HandlerFunc(createHero))).ServeHTTP).Methods("POST")
+	api.HandleFunc("/heroes/{id}", authMiddleware(http.HandlerFunc(updateHero)).ServeHTTP).Methods("PUT")
+	// Registered before PATCH /heroes/{id}, which would otherwise take "roles" as an ID
+	api.HandleFunc("/heroes/roles", authMiddleware(http.HandlerFunc(reassignHeroRoles)).ServeHTTP).Methods("PATCH")
+	api.HandleFunc("/heroes/{id}", authMiddleware(http.HandlerFunc(patchHero)).ServeHTTP).Methods("PATCH")
+	api.HandleFunc("/heroes/by-name/{name}", authMiddleware(http.HandlerFunc(upsertHeroByName)).ServeHTTP).Methods("PUT")
+	api.HandleFunc("/heroes/{id}", authMiddleware(destructiveMiddleware(describeHeroDelete, http.HandlerFunc(deleteHero))).ServeHTTP).Methods("DELETE")
+	api.HandleFunc("/heroes/{id}/tier", authMiddleware(http.HandlerFunc(assignHeroTier)).ServeHTTP).Methods("PUT")
+	api.HandleFunc("/heroes/{id}/difficulty", authMiddleware(http.HandlerFunc(setHeroDifficulty)).ServeHTTP).Methods("PUT")
+	api.HandleFunc("/heroes/{id}/tags/{tag}", authMiddleware(http.HandlerFunc(addHeroTag)).ServeHTTP).Methods("PUT")
+	api.HandleFunc("/heroes/{id}/counters", getHeroCounters).Methods("GET")
+	api.HandleFunc("/heroes/{id}/counters", authMiddleware(http.HandlerFunc(addHeroCounter)).ServeHTTP).Methods("POST")
+	api.HandleFunc("/heroes/{id}/counters/{counterId}", authMiddleware(http.HandlerFunc(removeHeroCounter)).ServeHTTP).Methods("DELETE")
+	api.HandleFunc("/heroes/{id}/tags/{tag}", authMiddleware(http.HandlerFunc(removeHeroTag)).ServeHTTP).Methods("DELETE")
+	api.HandleFunc("/heroes/{id}/clone", authMiddleware(http.HandlerFunc(cloneHero)).ServeHTTP).Methods("POST")
+	api.Handle("/heroes/{id}/archive", authMiddleware(http.HandlerFunc(archiveHero))).Methods("POST")
+	api.Handle("/heroes/{id}/unarchive", authMiddleware(http.HandlerFunc(unarchiveHero))).Methods("POST")
+	api.Handle("/heroes/{id}/soft-delete", adminMiddleware(http.HandlerFunc(softDeleteHero))).Methods("POST")
+	api.Handle("/heroes/{id}/restore", adminMiddleware(http.HandlerFunc(restoreHero))).Methods("POST")
+
+	// Tier list routes
+	api.HandleFunc("/tierlist", getTierList).Methods("GET")
+
+	// Reference data routes
+	api.HandleFunc("/roles", getRoles).Methods("GET")
+	api.HandleFunc("/difficulties", getDifficulties).Methods("GET")
+	api.HandleFunc("/schema/hero", getHeroSchema).Methods("GET")
+
+	// Self-service routes
+	api.Handle("/me/password", authMiddleware(http.HandlerFunc(changeOwnPassword))).Methods("POST")
+	api.Handle("/me/favorites", authMiddleware(http.HandlerFunc(getFavorites))).Methods("GET")
+	api.Handle("/heroes/{id}/favorite", authMiddleware(http.HandlerFunc(addFavorite))).Methods("POST")
+	api.Handle("/heroes/{id}/favorite", authMiddleware(http.HandlerFunc(removeFavorite))).Methods("DELETE")
+
+	// User management routes (admin only)
+	api.Handle("/users", adminMiddleware(http.HandlerFunc(getUsers))).Methods("GET")
+	api.Handle("/users", adminMiddleware(http.HandlerFunc(createUser))).Methods("POST")
+	api.Handle("/users/{id}", adminMiddleware(http.HandlerFunc(updateUser))).Methods("PUT")
+	api.Handle("/users/{id}", adminMiddleware(destructiveMiddleware(describeUserDelete, http.HandlerFunc(deleteUser)))).Methods("DELETE")
+	api.Handle("/users/{id}/password", adminMiddleware(http.HandlerFunc(resetUserPassword))).Methods("POST")
+
+	// Session management routes (admin only)
+	api.Handle("/sessions", adminMiddleware(http.HandlerFunc(getSessions))).Methods("GET")
+	api.Handle("/sessions", adminMiddleware(http.HandlerFunc(revokeSessionsOfUser))).Methods("DELETE")
+	api.Handle("/sessions/{id}", adminMiddleware(http.HandlerFunc(revokeSession))).Methods("DELETE")
+
+	// Admin routes
+	api.Handle("/admin/storage", adminMiddleware(http.HandlerFunc(getStorageReport))).Methods("GET")
+	api.Handle("/admin/display-order", adminMiddleware(http.HandlerFunc(getDisplayOrder))).Methods("GET")
+	api.Handle("/admin/display-order", adminMiddleware(http.HandlerFunc(updateDisplayOrder))).Methods("PUT")
+	api.Handle("/admin/db-pool", adminMiddleware(http.HandlerFunc(getDBPoolStats))).Methods("GET")
+	api.Handle("/admin/queries", adminMiddleware(http.HandlerFunc(getQueryInventory))).Methods("GET")
+	api.Handle("/admin/sessions", adminMiddleware(http.HandlerFunc(getActiveSessionsReport))).Methods("GET")
+	api.Handle("/admin/cache", adminMiddleware(http.HandlerFunc(getCacheStats))).Methods("GET")
+	api.Handle("/admin/logout-all", adminMiddleware(http.HandlerFunc(logoutAll))).Methods("POST")
+	api.Handle("/admin/storage/prune", adminMiddleware(http.HandlerFunc(pruneStorage))).Methods("POST")
+	api.Handle("/admin/export", adminMiddleware(http.HandlerFunc(exportHeroes))).Methods("GET")
+	api.Handle("/admin/import", adminMiddleware(destructiveMiddleware(describeImport, http.HandlerFunc(importHeroes)))).Methods("POST")
+	if cfg.Debug.DevEndpoints {
+		registerDevRoutes(api)
+	}
+	api.Handle("/admin/maintenance", adminMiddleware(http.HandlerFunc(getMaintenance))).Methods("GET")
+	api.Handle("/admin/maintenance", adminMiddleware(http.HandlerFunc(setMaintenance))).Methods("POST")
+
+	// Handle OPTIONS requests for all routes
+	api.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}).Methods("OPTIONS")
+
+	api.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}).Methods("OPTIONS")
+
+	api.HandleFunc("/heroes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}).Methods("OPTIONS")
+
+	api.HandleFunc("/heroes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}).Methods("OPTIONS")
+
+	return router
+}
+
+// Methods probed when listing what a path allows
+var routeMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// allowedMethods returns the methods router has a route for at r's path
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	var allowed []string
+	for _, method := range routeMethods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+
+		var match mux.RouteMatch
+		if router.Match(probe, &match) && match.MatchErr == nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// methodNotAllowedHandler answers requests to a known path with a method it
+// doesn't support, listing the supported ones in Allow
+func methodNotAllowedHandler(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allow := strings.Join(allowedMethods(router, r), ", ")
+		w.Header().Set("Allow", allow)
+		respondWithError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed,
+			"Method "+r.Method+" is not allowed here, use one of: "+allow)
+	})
+}
+
+// notFoundHandler answers unmatched paths with the usual JSON error
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithError(w, r, http.StatusNotFound, ErrCodeNotFound, "Resource not found: "+r.URL.Path)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serve sends r through the router and request logging, as main does
+func serve(cfg AppConfig, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	requestLogMiddleware(NewRouter(cfg)).ServeHTTP(rec, r)
+	return rec
+}
+
+func TestRouterCORSPreflight(t *testing.T) {
+	cfg := useTestConfig(t)
+
+	r := httptest.NewRequest(http.MethodOptions, "/api/heroes/1", nil)
+	r.Header.Set("Origin", "https://example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	rec := serve(cfg, r)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	for header, want := range map[string]string{
+		"Access-Control-Allow-Origin":   "*",
+		"Access-Control-Allow-Methods":  http.MethodDelete,
+		"Access-Control-Allow-Headers":  "Authorization",
+		"Access-Control-Expose-Headers": requestIDHeader,
+	} {
+		if got := rec.Header().Get(header); !strings.Contains(got, want) {
+			t.Errorf("%s = %q, want it to contain %q", header, got, want)
+		}
+	}
+}
+
+func TestRouterRejectsUnauthenticatedRequests(t *testing.T) {
+	cfg := useTestConfig(t)
+
+	tests := []struct {
+		name          string
+		method, path  string
+		authorization string
+		wantCode      string
+	}{
+		{"create without header", http.MethodPost, "/api/heroes", "", ErrCodeUnauthorized},
+		{"update with basic auth", http.MethodPut, "/api/heroes/1", "Basic YWRtaW46YWRtaW4=", ErrCodeUnauthorized},
+		{"delete with empty bearer", http.MethodDelete, "/api/heroes/1", "Bearer ", ErrCodeUnauthorized},
+		{"patch with unknown token", http.MethodPatch, "/api/heroes/1", "Bearer not-a-session", ErrCodeInvalidToken},
+		{"admin route without header", http.MethodGet, "/api/users", "", ErrCodeUnauthorized},
+		{"admin route with unknown token", http.MethodGet, "/api/admin/export", "Bearer not-a-session", ErrCodeInvalidToken},
+	}
+	forEachIDStrategy(t, func(t *testing.T, _ idStrategy) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{}`))
+				r.Header.Set("Content-Type", "application/json")
+				if tt.authorization != "" {
+					r.Header.Set("Authorization", tt.authorization)
+				}
+				rec := serve(cfg, r)
+
+				if rec.Code != http.StatusUnauthorized {
+					t.Fatalf("status = %d, want 401; body %s", rec.Code, rec.Body.String())
+				}
+				if body := decodeError(t, rec); body.Code != tt.wantCode || body.Error == "" {
+					t.Errorf("error = %+v, want code %s with a message", body, tt.wantCode)
+				}
+				if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+					t.Errorf("Access-Control-Allow-Origin = %q, want * on errors too", got)
+				}
+				if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+					t.Errorf("Cache-Control = %q, want no-store", got)
+				}
+			})
+		}
+	})
+}
+
+func TestRouterErrorEnvelope(t *testing.T) {
+	cfg := useTestConfig(t)
+
+	tests := []struct {
+		name         string
+		method, path string
+		wantStatus   int
+		wantCode     string
+		wantAllow    string
+	}{
+		{"unknown path", http.MethodGet, "/api/villains", http.StatusNotFound, ErrCodeNotFound, ""},
+		{"unsupported method", http.MethodDelete, "/api/roles", http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "GET"},
+		{"invalid hero id", http.MethodGet, "/api/heroes/abc/exists", http.StatusBadRequest, ErrCodeInvalidHeroID, ""},
+	}
+	forEachIDStrategy(t, func(t *testing.T, _ idStrategy) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				r := httptest.NewRequest(tt.method, tt.path, nil)
+				r.Header.Set(requestIDHeader, "test-request")
+				rec := serve(cfg, r)
+
+				if rec.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body.String())
+				}
+				if body := decodeError(t, rec); body.Code != tt.wantCode || body.Error == "" {
+					t.Errorf("error = %+v, want code %s with a message", body, tt.wantCode)
+				}
+				if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+					t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+				}
+				if got := rec.Header().Get(requestIDHeader); got != "test-request" {
+					t.Errorf("%s = %q, want the client's ID echoed", requestIDHeader, got)
+				}
+				if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+					t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+				}
+				if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+					t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+				}
+			})
+		}
+	})
+}
+
+func TestRouterSchemaGate(t *testing.T) {
+	cfg := useTestConfig(t)
+	schemaReady = false
+
+	rec := serve(cfg, httptest.NewRequest(http.MethodPost, "/api/heroes", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	if body := decodeError(t, rec); body.Code != ErrCodeServiceUnavailable {
+		t.Errorf("code = %s, want %s", body.Code, ErrCodeServiceUnavailable)
+	}
+}
+
+func TestRouterBasePath(t *testing.T) {
+	cfg := useTestConfig(t)
+	cfg.Server.BasePath = "/mlbb"
+	config = cfg
+
+	rec := serve(cfg, httptest.NewRequest(http.MethodPost, "/api/heroes", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("path outside BASE_PATH: status = %d, want 404", rec.Code)
+	}
+
+	rec = serve(cfg, httptest.NewRequest(http.MethodPost, "/mlbb/api/heroes", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("path under BASE_PATH: status = %d, want 401", rec.Code)
+	}
+	if body := decodeError(t, rec); body.Code != ErrCodeUnauthorized {
+		t.Errorf("code = %s, want %s", body.Code, ErrCodeUnauthorized)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+)
+
+// jsonSchema is a JSON Schema document or subschema
+type jsonSchema map[string]interface{}
+
+// JSON Schema dialect of the documents served under /api/schema
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// heroIDSchema describes hero IDs under the active ID strategy
+func heroIDSchema() jsonSchema {
+	switch heroIDs.Name() {
+	case idStrategySerial:
+		return jsonSchema{"type": "integer", "minimum": 1}
+	case idStrategyUUIDv7:
+		return jsonSchema{"type": "string", "format": "uuid"}
+	}
+	return jsonSchema{"type": "string", "pattern": "^[0-9]+$"}
+}
+
+// heroSchema builds the schema of Hero and HeroCreateRequest. It mirrors the
+// checks in createHero and resolveDifficulty: roles and difficulty labels are
+// free text, so the configured values are listed as examples rather than as
+// an enum that would reject heroes the API accepts.
+func heroSchema() jsonSchema {
+	order := currentDisplayOrder()
+	score := jsonSchema{"type": "integer", "minimum": minDifficultyScore, "maximum": maxDifficultyScore}
+	nullableText := jsonSchema{"type": "string"}
+	tag := jsonSchema{"type": "string", "maxLength": maxTagLength, "pattern": tagPattern.String()}
+
+	details := jsonSchema{
+		"lore":         nullableText,
+		"specialty":    nullableText,
+		"lane":         nullableText,
+		"release_date": jsonSchema{"type": "string", "format": "date"},
+	}
+	withDetails := func(properties jsonSchema) jsonSchema {
+		for name, property := range details {
+			properties[name] = property
+		}
+		return properties
+	}
+
+	return jsonSchema{
+		"$schema": jsonSchemaDialect,
+		"$id":     apiPath("/schema/hero"),
+		"$ref":    "#/$defs/Hero",
+		"$defs": jsonSchema{
+			"Hero": jsonSchema{
+				"type":     "object",
+				"required": []string{"id", "name", "role", "difficulty", "difficulty_score", "created_at", "updated_at", "tags"},
+				"properties": withDetails(jsonSchema{
+					"id":         heroIDSchema(),
+					"name":       jsonSchema{"type": "string"},
+					"role":       jsonSchema{"type": "string", "examples": order.Roles},
+					"difficulty": jsonSchema{"type": "string", "examples": knownDifficulties()},
+					"difficulty_score": jsonSchema{
+						"anyOf": []jsonSchema{score, {"type": "null"}},
+					},
+					"created_at":  jsonSchema{"type": "string", "format": "date-time"},
+					"updated_at":  jsonSchema{"type": "string", "format": "date-time"},
+					"archived_at": jsonSchema{"type": "string", "format": "date-time"},
+					"deleted_at":  jsonSchema{"type": "string", "format": "date-time"},
+					"tags":        jsonSchema{"type": "array", "items": tag},
+				}),
+			},
+			"HeroCreateRequest": jsonSchema{
+				"type":     "object",
+				"required": []string{"name", "role", "difficulty"},
+				"properties": withDetails(jsonSchema{
+					"name": jsonSchema{"type": "string", "minLength": 1},
+					"role": jsonSchema{"type": "string", "minLength": 1, "examples": order.Roles},
+					"difficulty": jsonSchema{
+						"description": "A difficulty label, or a score that selects the label of its band",
+						"anyOf": []jsonSchema{
+							{"type": "string", "minLength": 1, "examples": knownDifficulties()},
+							score,
+						},
+					},
+					"difficulty_score": jsonSchema{
+						"description": "Must fall within the score range of a known difficulty label",
+						"type":        "integer",
+						"minimum":     minDifficultyScore,
+						"maximum":     maxDifficultyScore,
+					},
+					"tags": jsonSchema{
+						"description": "Trimmed and lowercased before being checked against the tag pattern; duplicates are dropped",
+						"type":        "array",
+						"items":       jsonSchema{"type": "string", "minLength": 1},
+					},
+				}),
+			},
+		},
+	}
+}
+
+// GET /api/schema/hero - JSON Schema of heroes
+// @Summary Hero JSON Schema
+// @Description JSON Schema (draft 2020-12) of Hero and HeroCreateRequest under $defs, with the configured roles and difficulties as examples
+// @Tags reference
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/schema/hero [get]
+func getHeroSchema(w http.ResponseWriter, r *http.Request) {
+	body, err := marshalJSON(r, heroSchema())
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	writeBody(w, r, http.StatusOK, "application/schema+json", body)
+}
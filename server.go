@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TLSConfig holds the certificate files used to serve HTTPS
+type TLSConfig struct {
+	CertFile     string `yaml:"cert_file"`
+	KeyFile      string `yaml:"key_file"`
+	RedirectPort string `yaml:"redirect_port"`
+}
+
+// Enabled reports whether HTTPS should be served
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// How long in-flight requests get to finish after a shutdown signal
+const shutdownTimeout = 10 * time.Second
+
+// newHTTPServer builds a server for handler on port with the configured
+// timeouts. A zero timeout means none, as in net/http.
+func newHTTPServer(cfg ServerConfig, port string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              ":" + port,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// startServer listens on cfg.Port with plain HTTP, or HTTPS when TLS is
+// configured, until ctx is cancelled and in-flight requests have finished
+func startServer(ctx context.Context, cfg ServerConfig, handler http.Handler, tlsConfig TLSConfig) error {
+	server := newHTTPServer(cfg, cfg.Port, handler)
+	slog.Info("HTTP server limits", "read_timeout", cfg.ReadTimeout, "read_header_timeout", cfg.ReadHeaderTimeout,
+		"write_timeout", cfg.WriteTimeout, "idle_timeout", cfg.IdleTimeout, "max_header_bytes", cfg.MaxHeaderBytes, "max_body_bytes", cfg.MaxBodyBytes, "max_import_bytes", cfg.MaxImportBytes)
+
+	if tlsConfig.Enabled() {
+		server.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+
+		if tlsConfig.RedirectPort != "" {
+			go func() {
+				slog.Info("Redirecting HTTP to HTTPS", "port", tlsConfig.RedirectPort)
+				redirect := newHTTPServer(cfg, tlsConfig.RedirectPort, redirectToHTTPS(cfg.Port))
+				if err := redirect.ListenAndServe(); err != nil {
+					slog.Error("HTTP redirect listener stopped", "error", err)
+				}
+			}()
+		}
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		if tlsConfig.Enabled() {
+			errs <- server.ListenAndServeTLS(tlsConfig.CertFile, tlsConfig.KeyFile)
+		} else {
+			errs <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, waiting for in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// Security headers middleware; HSTS is only sent when serving HTTPS
+func securityHeadersMiddleware(tlsEnabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("X-Frame-Options", "DENY")
+			if tlsEnabled {
+				w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// redirectToHTTPS sends every request to the same URL on the HTTPS port
+func redirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Number of token characters shown to admins
+const tokenPrefixLength = 8
+
+// touchSession looks up token like lookupSession and records it as used now
+func touchSession(token string) (Session, bool) {
+	if token == "" {
+		return Session{}, false
+	}
+
+	tokenMutex.Lock()
+	defer tokenMutex.Unlock()
+
+	session, exists := validTokens[token]
+	now := time.Now()
+	if !exists || now.After(session.ExpiresAt) {
+		return Session{}, false
+	}
+
+	session.LastUsedAt = now
+	validTokens[token] = session
+	return session, true
+}
+
+// truncateToken keeps only a prefix of token so listings never leak usable credentials
+func truncateToken(token string) string {
+	if len(token) <= tokenPrefixLength {
+		return token
+	}
+	return token[:tokenPrefixLength] + "…"
+}
+
+// activeSessions lists unexpired sessions with a truncated token, most
+// recently used first
+func activeSessions() []SessionInfo {
+	now := time.Now()
+	sessions := []SessionInfo{}
+
+	tokenMutex.RLock()
+	for token, session := range validTokens {
+		if now.After(session.ExpiresAt) {
+			continue
+		}
+		sessions = append(sessions, SessionInfo{
+			ID:         session.ID,
+			Token:      truncateToken(token),
+			Username:   session.Username,
+			Role:       session.Role,
+			IssuedAt:   session.IssuedAt,
+			ExpiresAt:  session.ExpiresAt,
+			LastUsedAt: session.LastUsedAt,
+		})
+	}
+	tokenMutex.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions
+}
+
+// GET /api/sessions - List active sessions
+// @Summary List active sessions
+// @Description Active sessions with a truncated token, most recently used first
+// @Tags sessions
+// @Produce json,application/xml
+// @Success 200 {array} SessionInfo
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/sessions [get]
+func getSessions(w http.ResponseWriter, r *http.Request) {
+	respondWith(w, r, http.StatusOK, activeSessions())
+}
+
+// GET /api/admin/sessions - Active session summary
+// @Summary Active session summary
+// @Description Number of currently valid tokens and their sessions, for spotting unusual activity.
+// @Description Tokens are truncated to a prefix.
+// @Tags admin
+// @Produce json,application/xml
+// @Success 200 {object} ActiveSessionsReport
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/sessions [get]
+func getActiveSessionsReport(w http.ResponseWriter, r *http.Request) {
+	sessions := activeSessions()
+	respondWith(w, r, http.StatusOK, ActiveSessionsReport{Count: len(sessions), Sessions: sessions})
+}
+
+// DELETE /api/sessions/{id} - Revoke a session
+// @Summary Revoke session
+// @Description Revoke one session by its ID; its token stops working immediately
+// @Tags sessions
+// @Produce json,application/xml
+// @Param id path string true "Session ID"
+// @Success 204 "No Content"
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/sessions/{id} [delete]
+func revokeSession(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var revoked Session
+	tokenMutex.Lock()
+	for token, session := range validTokens {
+		if session.ID == id {
+			revoked = session
+			delete(validTokens, token)
+			break
+		}
+	}
+	tokenMutex.Unlock()
+
+	if revoked.ID == "" {
+		respondWithError(w, r, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		return
+	}
+	persistRevocations(revoked)
+
+	admin, _ := sessionFromRequest(r)
+	requestLogger(r).Info("AUDIT session revoked", "session", revoked.ID, "owner", revoked.Username, "by", admin.Username)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /api/sessions?user= - Revoke every session of a user
+// @Summary Revoke user sessions
+// @Description Revoke all sessions belonging to a user
+// @Tags sessions
+// @Produce json,application/xml
+// @Param user query string true "Username"
+// @Success 200 {object} RevokedSessionsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/sessions [delete]
+func revokeSessionsOfUser(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("user")
+	if username == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, "Query parameter 'user' is required")
+		return
+	}
+
+	revoked := revokeUserTokens(username, "")
+
+	admin, _ := sessionFromRequest(r)
+	requestLogger(r).Info("AUDIT sessions revoked", "owner", username, "revoked", revoked, "by", admin.Username)
+
+	respondWith(w, r, http.StatusOK, RevokedSessionsResponse{User: username, Revoked: revoked})
+}
+
+// revokeAllTokens drops every session and returns how many were removed
+func revokeAllTokens() int {
+	var revoked []Session
+
+	tokenMutex.Lock()
+	for token, session := range validTokens {
+		delete(validTokens, token)
+		revoked = append(revoked, session)
+	}
+	tokenMutex.Unlock()
+
+	persistRevocations(revoked...)
+	return len(revoked)
+}
+
+// POST /api/admin/logout-all - Revoke every session
+// @Summary Force logout everyone
+// @Description Revoke every active session, including the caller's, so all users must log in again.
+// @Description With ?user= only that user's sessions are revoked.
+// @Tags admin
+// @Produce json,application/xml
+// @Param user query string false "Only revoke sessions of this username"
+// @Success 200 {object} RevokedSessionsResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/logout-all [post]
+func logoutAll(w http.ResponseWriter, r *http.Request) {
+	admin, _ := sessionFromRequest(r)
+	username := r.URL.Query().Get("user")
+
+	var revoked int
+	if username != "" {
+		revoked = revokeUserTokens(username, "")
+		requestLogger(r).Info("AUDIT force logout", "owner", username, "revoked", revoked, "by", admin.Username)
+	} else {
+		revoked = revokeAllTokens()
+		requestLogger(r).Info("AUDIT force logout of all users", "revoked", revoked, "by", admin.Username)
+	}
+
+	respondWith(w, r, http.StatusOK, RevokedSessionsResponse{User: username, Revoked: revoked})
+}
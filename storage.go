@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// managedTable is an append-only table whose old rows are pruned by retention
+type managedTable struct {
+	Name            string
+	TimestampColumn string
+	Retention       time.Duration
+	LastPrune       *PruneResult
+}
+
+// Registry of append-only tables. Features that add such a table register it
+// here so it is pruned by the background sweep and shown in the storage report.
+var (
+	managedTables     = make(map[string]*managedTable)
+	managedTableMutex sync.Mutex
+)
+
+// registerManagedTable adds a table whose rows older than retention (by column) are pruned
+func registerManagedTable(name, timestampColumn string, retention time.Duration) {
+	managedTableMutex.Lock()
+	defer managedTableMutex.Unlock()
+
+	managedTables[name] = &managedTable{
+		Name:            name,
+		TimestampColumn: timestampColumn,
+		Retention:       retention,
+	}
+}
+
+// sortedManagedTables returns a snapshot of registered tables ordered by name
+func sortedManagedTables() []managedTable {
+	managedTableMutex.Lock()
+	defer managedTableMutex.Unlock()
+
+	tables := make([]managedTable, 0, len(managedTables))
+	for _, table := range managedTables {
+		tables = append(tables, *table)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables
+}
+
+// Queries on managed tables; table names come from the registry
+var (
+	queryPruneTable = registerBuiltQuery("storage.prune")
+	queryTableSize  = registerQuery("storage.size", "SELECT pg_total_relation_size($1::regclass)", paramText)
+	queryTableAge   = registerBuiltQuery("storage.age")
+)
+
+// pruneTable deletes rows older than the table's retention
+func pruneTable(table managedTable) PruneResult {
+	result := PruneResult{Table: table.Name, RanAt: dbClock.Now()}
+
+	if table.Retention <= 0 {
+		result.Skipped = true
+		return result
+	}
+
+	// Table and column names come from the registry, never from user input
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s < $1", table.Name, table.TimestampColumn)
+	res, err := queryPruneTable.Build(query).Exec(dbClock.Now().Add(-table.Retention))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Deleted, _ = res.RowsAffected()
+	return result
+}
+
+// pruneManagedTables runs every registered pruner and records the results
+func pruneManagedTables() []PruneResult {
+	var results []PruneResult
+	for _, table := range sortedManagedTables() {
+		result := pruneTable(table)
+		if result.Error != "" {
+			slog.Error("Failed to prune table", "table", table.Name, "error", result.Error)
+		}
+
+		managedTableMutex.Lock()
+		if registered, exists := managedTables[table.Name]; exists {
+			registered.LastPrune = &result
+		}
+		managedTableMutex.Unlock()
+
+		results = append(results, result)
+	}
+	return results
+}
+
+// describeManagedTable gathers size and age statistics for one table
+func describeManagedTable(ctx context.Context, table managedTable) (TableStorageReport, error) {
+	report := TableStorageReport{
+		Table:     table.Name,
+		Retention: table.Retention.String(),
+		LastPrune: table.LastPrune,
+	}
+
+	err := queryTableSize.WithContext(ctx).QueryRow(table.Name).Scan(&report.SizeBytes)
+	if err != nil {
+		return report, err
+	}
+
+	var oldest sql.NullTime
+	query := fmt.Sprintf("SELECT COUNT(*), MIN(%s) FROM %s", table.TimestampColumn, table.Name)
+	if err := queryTableAge.WithContext(ctx).Build(query).QueryRow().Scan(&report.RowCount, &oldest); err != nil {
+		return report, err
+	}
+	if oldest.Valid {
+		report.OldestRowAgeSeconds = int64(dbClock.Now().Sub(oldest.Time).Seconds())
+	}
+
+	return report, nil
+}
+
+// GET /api/admin/storage - Storage report for append-only tables
+// @Summary Storage report
+// @Description Row counts, disk size, oldest row age and retention for each managed table
+// @Tags admin
+// @Produce json,application/xml
+// @Success 200 {array} TableStorageReport
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/storage [get]
+func getStorageReport(w http.ResponseWriter, r *http.Request) {
+	reports := []TableStorageReport{}
+	for _, table := range sortedManagedTables() {
+		report, err := describeManagedTable(r.Context(), table)
+		if err != nil {
+			respondWithInternalError(w, r, err, "Failed to read storage statistics")
+			return
+		}
+		reports = append(reports, report)
+	}
+
+	respondWith(w, r, http.StatusOK, reports)
+}
+
+// POST /api/admin/storage/prune - Run all pruners now
+// @Summary Prune managed tables
+// @Description Immediately delete rows past retention in every managed table
+// @Tags admin
+// @Produce json,application/xml
+// @Success 200 {array} PruneResult
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/admin/storage/prune [post]
+func pruneStorage(w http.ResponseWriter, r *http.Request) {
+	results := pruneManagedTables()
+	if results == nil {
+		results = []PruneResult{}
+	}
+
+	session, _ := sessionFromRequest(r)
+	requestLogger(r).Info("AUDIT storage prune run", "by", session.Username)
+
+	respondWith(w, r, http.StatusOK, results)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock stopped at now
+type fakeClock struct {
+	now time.Time
+}
+
+func (c fakeClock) Now() time.Time { return c.now }
+
+// useFakeClock makes dbClock read base for the rest of the test, with the
+// given database skew applied when preferDB is set
+func useFakeClock(t *testing.T, base Clock, preferDB bool, skew time.Duration) {
+	t.Helper()
+	previous := dbClock
+	t.Cleanup(func() { dbClock = previous })
+
+	dbClock = &dbAlignedClock{base: base, preferDB: preferDB}
+	dbClock.skew.Store(int64(skew))
+}
+
+func TestPruneTableCutoff(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	skew := -90 * time.Second
+
+	tests := []struct {
+		name     string
+		preferDB bool
+		wantNow  time.Time
+	}{
+		{"host clock", false, now},
+		{"database clock", true, now.Add(skew)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			database := useFakeDB(t)
+			useFakeClock(t, fakeClock{now}, tt.preferDB, skew)
+
+			result := pruneTable(managedTable{Name: "idempotency_keys", TimestampColumn: "created_at", Retention: idempotencyKeyTTL})
+			if result.Error != "" || result.Deleted != 1 {
+				t.Fatalf("pruneTable() = %+v", result)
+			}
+			if !result.RanAt.Equal(tt.wantNow) {
+				t.Errorf("RanAt = %v, want %v", result.RanAt, tt.wantNow)
+			}
+
+			execs := database.executed()
+			if len(execs) != 1 {
+				t.Fatalf("%d statements, want 1", len(execs))
+			}
+			if execs[0].query != "DELETE FROM idempotency_keys WHERE created_at < $1" {
+				t.Errorf("statement = %q", execs[0].query)
+			}
+			want := tt.wantNow.Add(-idempotencyKeyTTL)
+			if cutoff, ok := execs[0].args[0].(time.Time); !ok || !cutoff.Equal(want) {
+				t.Errorf("cutoff = %v, want %v", execs[0].args[0], want)
+			}
+		})
+	}
+}
+
+// The cutoff moves with the clock from one sweep to the next
+func TestPruneTableFollowsTheClock(t *testing.T) {
+	database := useFakeDB(t)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	table := managedTable{Name: "hero_views", TimestampColumn: "bucket", Retention: heroViewRetention}
+
+	for _, elapsed := range []time.Duration{0, time.Second, 24 * time.Hour} {
+		useFakeClock(t, fakeClock{start.Add(elapsed)}, false, 0)
+		pruneTable(table)
+	}
+
+	execs := database.executed()
+	if len(execs) != 3 {
+		t.Fatalf("%d statements, want 3", len(execs))
+	}
+	for i, elapsed := range []time.Duration{0, time.Second, 24 * time.Hour} {
+		want := start.Add(elapsed).Add(-heroViewRetention)
+		if cutoff := execs[i].args[0].(time.Time); !cutoff.Equal(want) {
+			t.Errorf("prune %d cutoff = %v, want %v", i, cutoff, want)
+		}
+	}
+}
+
+func TestPruneTableSkipsTablesWithoutRetention(t *testing.T) {
+	database := useFakeDB(t)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	useFakeClock(t, fakeClock{now}, false, 0)
+
+	result := pruneTable(managedTable{Name: "hero_views", TimestampColumn: "bucket"})
+	if !result.Skipped || !result.RanAt.Equal(now) {
+		t.Errorf("pruneTable() = %+v, want skipped at %v", result, now)
+	}
+	if execs := database.executed(); len(execs) != 0 {
+		t.Errorf("ran %v for a table without retention", execs)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Tags are lowercase words joined by hyphens, e.g. "beginner-friendly"
+var tagPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Longest tag name, matching tags.name
+const maxTagLength = 50
+
+// Queries on tags and hero_tags; tags are created the first time they are used
+var (
+	queryTagHero = registerQuery("hero_tags.add", `WITH tag AS (
+			INSERT INTO tags (name) VALUES ($2)
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id
+		)
+		INSERT INTO hero_tags (hero_id, tag_id) SELECT $1, id FROM tag ON CONFLICT DO NOTHING`, paramHeroID, paramText)
+	queryUntagHero = registerQuery("hero_tags.remove", `DELETE FROM hero_tags
+		WHERE hero_id = $1 AND tag_id = (SELECT id FROM tags WHERE name = $2)`, paramHeroID, paramText)
+)
+
+// normalizeTag lowercases and trims a tag and checks its format
+func normalizeTag(raw string) (string, error) {
+	tag := strings.ToLower(strings.TrimSpace(raw))
+	if len(tag) > maxTagLength || !tagPattern.MatchString(tag) {
+		return "", fmt.Errorf("tag %q must be 1-%d lowercase letters, digits and single hyphens", raw, maxTagLength)
+	}
+	return tag, nil
+}
+
+// normalizeTags normalizes tags, dropping duplicates and sorting them by name
+func normalizeTags(raw []string) ([]string, error) {
+	seen := make(map[string]bool, len(raw))
+	tags := []string{}
+	for _, value := range raw {
+		tag, err := normalizeTag(value)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// tagHero attaches tags to the hero with id inside tx
+func tagHero(tx *sql.Tx, id HeroID, tags []string) error {
+	for _, tag := range tags {
+		if _, err := queryTagHero.In(tx).Exec(id, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// heroForTagging resolves the hero and tag named in the path, responding with
+// an error and returning false when either is invalid
+func heroForTagging(w http.ResponseWriter, r *http.Request) (Hero, string, bool) {
+	vars := mux.Vars(r)
+	id, err := heroIDs.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
+		return Hero{}, "", false
+	}
+	tag, err := normalizeTag(vars["tag"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, err.Error())
+		return Hero{}, "", false
+	}
+
+	filter := &heroFilter{}
+	filter.add("id = $%d", id)
+	filter.restrictVisibility(r)
+
+	var hero Hero
+	err = queryGetHero.WithContext(r.Context()).Build("SELECT " + heroColumns + " FROM heroes" + filter.where()).QueryRow(filter.args...).
+		Scan(heroScanDest(&hero)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, r, http.StatusNotFound, ErrCodeHeroNotFound, "Hero not found")
+		} else {
+			respondWithInternalError(w, r, err, "Failed to fetch hero")
+		}
+		return Hero{}, "", false
+	}
+	return hero, tag, true
+}
+
+// PUT /api/heroes/{id}/tags/{tag} - Tag a hero
+// @Summary Add hero tag
+// @Description Attach a tag to a hero, creating the tag if it is new. Tags are lowercased; adding a tag the hero already has is a no-op.
+// @Tags heroes
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Param tag path string true "Tag, e.g. meta or beginner-friendly"
+// @Success 200 {object} Hero
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/{id}/tags/{tag} [put]
+func addHeroTag(w http.ResponseWriter, r *http.Request) {
+	hero, tag, ok := heroForTagging(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := queryTagHero.WithContext(r.Context()).Exec(hero.ID, tag); err != nil {
+		respondWithInternalError(w, r, err, "Failed to tag hero")
+		return
+	}
+
+	if i := sort.SearchStrings(hero.Tags, tag); i == len(hero.Tags) || hero.Tags[i] != tag {
+		hero.Tags = append(hero.Tags[:i], append([]string{tag}, hero.Tags[i:]...)...)
+		invalidateHeroCache()
+		publishHeroEvent(r, eventUpdated, hero)
+
+		session, _ := sessionFromRequest(r)
+		requestLogger(r).Info("AUDIT hero tagged", "name", hero.Name, "tag", tag, "by", session.Username)
+	}
+
+	respondWith(w, r, http.StatusOK, hero)
+}
+
+// DELETE /api/heroes/{id}/tags/{tag} - Untag a hero
+// @Summary Remove hero tag
+// @Description Detach a tag from a hero. Removing a tag the hero doesn't have is a no-op.
+// @Tags heroes
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Param tag path string true "Tag"
+// @Success 200 {object} Hero
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/{id}/tags/{tag} [delete]
+func removeHeroTag(w http.ResponseWriter, r *http.Request) {
+	hero, tag, ok := heroForTagging(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := queryUntagHero.WithContext(r.Context()).Exec(hero.ID, tag)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to untag hero")
+		return
+	}
+
+	if removed, _ := result.RowsAffected(); removed > 0 {
+		tags := []string{}
+		for _, existing := range hero.Tags {
+			if existing != tag {
+				tags = append(tags, existing)
+			}
+		}
+		hero.Tags = tags
+		invalidateHeroCache()
+		publishHeroEvent(r, eventUpdated, hero)
+
+		session, _ := sessionFromRequest(r)
+		requestLogger(r).Info("AUDIT hero untagged", "name", hero.Name, "tag", tag, "by", session.Username)
+	}
+
+	respondWith(w, r, http.StatusOK, hero)
+}
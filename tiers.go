@@ -0,0 +1,210 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Queries on hero_tiers; the tier list itself is filtered by visibility per request
+var (
+	queryLockTierList   = registerQuery("hero_tiers.lock", "SELECT pg_advisory_xact_lock(hashtext($1))", paramText)
+	queryTierPlacements = registerQuery("hero_tiers.placements", `SELECT t.hero_id, t.tier, h.id IS NOT NULL FROM hero_tiers t
+		LEFT JOIN heroes h ON h.id::text = t.hero_id WHERE t.patch = $1`, paramText)
+	queryAssignTier = registerQuery("hero_tiers.assign", `INSERT INTO hero_tiers (patch, hero_id, tier) VALUES ($1, $2, $3)
+		ON CONFLICT (patch, hero_id) DO UPDATE SET tier = EXCLUDED.tier, updated_at = CURRENT_TIMESTAMP`, paramText, paramHeroID, paramText)
+	queryLatestPatch = registerQuery("hero_tiers.latest_patch", "SELECT patch FROM hero_tiers ORDER BY updated_at DESC LIMIT 1")
+	queryTierList    = registerBuiltQuery("hero_tiers.list")
+)
+
+// PUT /api/heroes/{id}/tier - Place a hero in a tier
+// @Summary Set hero tier
+// @Description Place a hero in a tier for a balance patch, moving it out of the tier it had in that patch.
+// @Description Tier names and tier sizes follow the validation.tier_lists rules in config.yaml.
+// @Tags tiers
+// @Accept json
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Param tier body TierAssignmentRequest true "Tier and patch"
+// @Success 200 {object} TierAssignment
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ValidationErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/{id}/tier [put]
+func assignHeroTier(w http.ResponseWriter, r *http.Request) {
+	id, err := heroIDs.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
+		return
+	}
+
+	var req TierAssignmentRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.Tier == "" || req.Patch == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, "Tier and patch are required")
+		return
+	}
+
+	filter := &heroFilter{}
+	filter.add("id = $%d", id)
+	filter.restrictVisibility(r)
+
+	var hero Hero
+	err = queryGetHero.WithContext(r.Context()).Build("SELECT " + heroColumns + " FROM heroes" + filter.where()).QueryRow(filter.args...).
+		Scan(heroScanDest(&hero)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, r, http.StatusNotFound, ErrCodeHeroNotFound, "Hero not found")
+		} else {
+			respondWithInternalError(w, r, err, "Failed to fetch hero")
+		}
+		return
+	}
+
+	tx, err := beginTx(r.Context(), nil)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to update tier list")
+		return
+	}
+	defer tx.Rollback()
+
+	// Serialize placements per patch so concurrent requests can't overfill a tier
+	if _, err := queryLockTierList.WithContext(r.Context()).In(tx).Exec(req.Patch); err != nil {
+		respondWithInternalError(w, r, err, "Failed to update tier list")
+		return
+	}
+
+	tiers, previous, known, err := tierPlacements(tx, req.Patch, hero.ID)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to fetch tier list")
+		return
+	}
+	tiers[req.Tier] = append(tiers[req.Tier], hero.ID)
+	known[hero.ID] = true
+
+	// Only the target tier is checked, so tiers that break rules changed
+	// since they were filled don't block other placements. Placements left
+	// behind by deleted heroes don't take up room.
+	var violations []Violation
+	for _, violation := range validateTierList(tiers, config.Validation.TierLists, known) {
+		if violation.Path == jsonPointer("tiers", req.Tier) {
+			violation.Path = jsonPointer("tier")
+			violations = append(violations, violation)
+		}
+	}
+	if len(violations) > 0 {
+		respondWithViolations(w, r, violations)
+		return
+	}
+
+	if _, err := queryAssignTier.WithContext(r.Context()).In(tx).Exec(req.Patch, hero.ID, req.Tier); err != nil {
+		respondWithInternalError(w, r, err, "Failed to update tier list")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithInternalError(w, r, err, "Failed to update tier list")
+		return
+	}
+
+	session, _ := sessionFromRequest(r)
+	if previous == "" {
+		previous = "none"
+	}
+	requestLogger(r).Info("AUDIT hero tier changed", "name", hero.Name, "from", previous, "to", req.Tier, "patch", req.Patch, "by", session.Username)
+
+	respondWith(w, r, http.StatusOK, TierAssignment{Patch: req.Patch, Tier: req.Tier, Hero: hero})
+}
+
+// tierPlacements returns the hero IDs per tier in patch without id, the
+// tier id currently has ("" if it has none) and which placed heroes still exist
+func tierPlacements(tx *sql.Tx, patch string, id HeroID) (map[string][]HeroID, string, map[HeroID]bool, error) {
+	rows, err := queryTierPlacements.In(tx).Query(patch)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	defer rows.Close()
+
+	tiers := make(map[string][]HeroID)
+	known := make(map[HeroID]bool)
+	var previous string
+	for rows.Next() {
+		var heroID HeroID
+		var tier string
+		var exists bool
+		if err := rows.Scan(&heroID, &tier, &exists); err != nil {
+			return nil, "", nil, err
+		}
+		if heroID == id {
+			previous = tier
+			continue
+		}
+		tiers[tier] = append(tiers[tier], heroID)
+		if exists {
+			known[heroID] = true
+		}
+	}
+	return tiers, previous, known, rows.Err()
+}
+
+// GET /api/tierlist - Heroes grouped by tier
+// @Summary Tier list
+// @Description Heroes grouped by tier for a balance patch. Without ?patch the most recently changed patch
+// @Description is returned. Every configured tier is present, empty tiers as an empty list. tier_order lists
+// @Description the tiers in configured order, tiers no longer configured last; tiers follows the same order.
+// @Tags tiers
+// @Produce json,application/xml
+// @Param patch query string false "Balance patch, e.g. 1.8.42"
+// @Success 200 {object} TierList
+// @Router /api/tierlist [get]
+func getTierList(w http.ResponseWriter, r *http.Request) {
+	patch := r.URL.Query().Get("patch")
+	if patch == "" {
+		err := queryLatestPatch.WithContext(r.Context()).QueryRow().Scan(&patch)
+		if err != nil && err != sql.ErrNoRows {
+			respondWithInternalError(w, r, err, "Failed to fetch tier list")
+			return
+		}
+	}
+
+	list := TierList{Patch: patch, Order: config.Validation.TierLists.Tiers, Tiers: make(map[string][]Hero)}
+	for _, tier := range config.Validation.TierLists.Tiers {
+		list.Tiers[tier] = []Hero{}
+	}
+
+	filter := &heroFilter{}
+	filter.restrictVisibility(r)
+
+	query := "SELECT " + heroColumns + ", t.tier FROM heroes" +
+		fmt.Sprintf(" JOIN (SELECT hero_id, tier FROM hero_tiers WHERE patch = $%d) t ON t.hero_id = heroes.id::text", len(filter.args)+1) +
+		filter.where() + " ORDER BY heroes.name"
+
+	rows, err := queryTierList.WithContext(r.Context()).Build(query).Query(append(filter.args, patch)...)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to fetch tier list")
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hero Hero
+		var tier string
+		if err := rows.Scan(heroScanDest(&hero, &tier)...); err != nil {
+			respondWithInternalError(w, r, err, "Failed to scan hero data")
+			return
+		}
+		list.Tiers[tier] = append(list.Tiers[tier], hero)
+	}
+
+	if err := rows.Err(); err != nil {
+		respondWithInternalError(w, r, err, "Error iterating heroes")
+		return
+	}
+	list.Order = list.names()
+
+	respondWith(w, r, http.StatusOK, list)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// HandlerTimeouts bound how long API handlers may run, per group of routes;
+// 0 disables the bound for that group
+type HandlerTimeouts struct {
+	// Read covers GET, HEAD and OPTIONS
+	Read time.Duration `yaml:"read"`
+	// Write covers every other method
+	Write time.Duration `yaml:"write"`
+	// Bulk covers export, import and NDJSON streams
+	Bulk time.Duration `yaml:"bulk"`
+}
+
+// API paths, relative to the API root, that move the whole dataset
+var bulkRoutePaths = map[string]bool{
+	"/admin/export": true,
+	"/admin/import": true,
+}
+
+// API paths, relative to the API root, that stay open until the client leaves
+var streamRoutePaths = map[string]bool{
+	"/heroes/events": true,
+}
+
+// forRequest returns the timeout of the group r belongs to
+func (t HandlerTimeouts) forRequest(r *http.Request) time.Duration {
+	path := apiRoutePath(r)
+	switch {
+	case streamRoutePaths[path]:
+		return 0
+	case bulkRoutePaths[path] || negotiateFormat(r) == formatNDJSON:
+		return t.Bulk
+	case r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions:
+		return t.Read
+	default:
+		return t.Write
+	}
+}
+
+// timeoutMiddleware cancels the request context once the group's timeout has
+// passed, which aborts the handler's queries. Unlike http.TimeoutHandler the
+// response isn't buffered, so streams keep flushing; if the handler hasn't
+// answered by the deadline, a 504 is sent in its place and its later writes
+// are dropped. A client that disconnects cancels the context the same way.
+func timeoutMiddleware(timeouts HandlerTimeouts) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := timeouts.forRequest(r)
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{ResponseWriter: w, header: w.Header().Clone()}
+			started := time.Now()
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if recovered := recover(); recovered != nil {
+						panicked <- recovered
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case recovered := <-panicked:
+				panic(recovered)
+			case <-done:
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					logTimeout(r, timeout, started)
+				}
+				return
+			case <-ctx.Done():
+			}
+
+			// The handler may have returned just as the context ended
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			tw.mu.Lock()
+			answered := tw.wroteHeader
+			if !answered {
+				tw.abandoned = true
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					respondWithError(w, r, http.StatusGatewayTimeout, ErrCodeRequestTimeout,
+						"Request did not complete within "+timeout.String())
+				}
+			}
+			tw.mu.Unlock()
+
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				logTimeout(r, timeout, started)
+			} else {
+				requestLogger(r).Info("Client disconnected", "method", r.Method, "path", r.URL.Path,
+					"duration_ms", durationMs(time.Since(started)))
+			}
+
+			// A response already under way is the handler's to finish; its
+			// context is cancelled, so it stops at the next query
+			if answered {
+				select {
+				case <-done:
+				case recovered := <-panicked:
+					panic(recovered)
+				}
+			}
+		})
+	}
+}
+
+// logTimeout records a request that ran past its handler timeout
+func logTimeout(r *http.Request, timeout time.Duration, started time.Time) {
+	requestLogger(r).Warn("Request timed out", "method", r.Method, "path", r.URL.Path,
+		"timeout", timeout.String(), "duration_ms", durationMs(time.Since(started)))
+}
+
+// timeoutWriter passes the handler's response through until timeoutMiddleware
+// abandons it. The handler gets its own header map, copied on the first write,
+// so the middleware can answer in its place without racing on headers.
+type timeoutWriter struct {
+	http.ResponseWriter
+	header http.Header
+
+	mu          sync.Mutex
+	wroteHeader bool
+	abandoned   bool
+}
+
+// Header returns the handler's header map
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// WriteHeader sends the status unless the middleware already answered
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.abandoned || tw.wroteHeader {
+		return
+	}
+	tw.writeHeader(status)
+}
+
+// writeHeader copies the handler's headers and sends status; tw.mu must be held
+func (tw *timeoutWriter) writeHeader(status int) {
+	dst := tw.ResponseWriter.Header()
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+	tw.wroteHeader = true
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+// Write sends body bytes, failing with http.ErrHandlerTimeout once abandoned
+func (tw *timeoutWriter) Write(data []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.abandoned {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeader(http.StatusOK)
+	}
+	return tw.ResponseWriter.Write(data)
+}
+
+// Flush forwards to the underlying writer so streamed responses still flush
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.abandoned {
+		return
+	}
+	if flusher, ok := tw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. for deadlines
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+// isCancellation reports whether err is the request context ending, either
+// because the client left or because the handler timeout passed
+func isCancellation(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,531 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Minimum length for passwords set by admins and by users themselves
+const (
+	minPasswordLength    = 8
+	minOwnPasswordLength = 10
+)
+
+// Compared against when the username is unknown so failed logins take the same time
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// Columns selected for a UserAccount, in scanUserAccount order
+const userAccountColumns = "id, username, role, created_at, last_login_at"
+
+// Queries on the users table
+var (
+	queryUserCount       = registerQuery("users.count", "SELECT COUNT(*) FROM users")
+	querySeedUser        = registerQuery("users.seed", "INSERT INTO users (username, password_hash, role) VALUES ($1, $2, $3) ON CONFLICT (username) DO NOTHING", paramText, paramText, paramText)
+	queryRecordLogin     = registerQuery("users.record_login", "UPDATE users SET last_login_at = CURRENT_TIMESTAMP WHERE id = $1", paramInt)
+	queryUserCredentials = registerQuery("users.credentials", "SELECT id, username, role, password_hash FROM users WHERE username = $1", paramText)
+	queryListUsers       = registerQuery("users.list", "SELECT "+userAccountColumns+" FROM users ORDER BY id")
+	queryCreateUser      = registerQuery("users.create", "INSERT INTO users (username, password_hash, role) VALUES ($1, $2, $3) RETURNING "+userAccountColumns, paramText, paramText, paramText)
+	queryUsername        = registerQuery("users.username", "SELECT username FROM users WHERE id = $1", paramInt)
+	queryUpdateUser      = registerQuery("users.update", "UPDATE users SET username = $1, role = $2 WHERE id = $3 RETURNING "+userAccountColumns, paramText, paramText, paramInt)
+	queryDeleteUser      = registerQuery("users.delete", "DELETE FROM users WHERE id = $1 RETURNING username", paramInt)
+	queryResetPassword   = registerQuery("users.reset_password", "UPDATE users SET password_hash = $1 WHERE id = $2 RETURNING username", paramText, paramInt)
+	queryChangePassword  = registerQuery("users.change_password", "UPDATE users SET password_hash = $1 WHERE id = $2", paramText, paramInt)
+	queryUserExists      = registerQuery("users.exists", "SELECT COUNT(*) FROM users WHERE id = $1", paramInt)
+	queryUpsertAdmin     = registerQuery("users.upsert_admin", "INSERT INTO users (username, password_hash, role) VALUES ($1, $2, 'admin') ON CONFLICT (username) DO UPDATE SET password_hash = EXCLUDED.password_hash, role = EXCLUDED.role", paramText, paramText)
+)
+
+// scanUserAccount scans a row selected with userAccountColumns
+func scanUserAccount(row interface{ Scan(...interface{}) error }) (UserAccount, error) {
+	var account UserAccount
+	var lastLogin sql.NullTime
+	err := row.Scan(&account.ID, &account.Username, &account.Role, &account.CreatedAt, &lastLogin)
+	if lastLogin.Valid {
+		account.LastLoginAt = &lastLogin.Time
+	}
+	return account, err
+}
+
+// SeedUsers imports the config.yaml users when the users table is empty
+func SeedUsers(users []User) error {
+	var count int
+	if err := queryUserCount.QueryRow().Scan(&count); err != nil {
+		return fmt.Errorf("failed to check existing users: %v", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	// Without any user nobody could ever log in to create one
+	if len(users) == 0 {
+		return errors.New("users table is empty and no users are configured; list them under users in config.yaml or set ADMIN_USER/ADMIN_PASSWORD")
+	}
+
+	for _, user := range users {
+		if _, err := importUser(context.Background(), user); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Imported users from config.yaml", "count", len(users))
+	return nil
+}
+
+// importUser stores a config.yaml user with a bcrypt hash of its password.
+// An existing user of the same name is left unchanged and reports false.
+func importUser(ctx context.Context, user User) (bool, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash password for %s: %v", user.Username, err)
+	}
+
+	role := user.Role
+	if role == "" {
+		role = roleUser
+	}
+
+	result, err := querySeedUser.WithContext(ctx).Exec(user.Username, string(hash), role)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert user %s: %v", user.Username, err)
+	}
+	inserted, err := result.RowsAffected()
+	return inserted > 0, err
+}
+
+// ensureAdminUser makes the ADMIN_USER/ADMIN_PASSWORD account log in as an
+// admin with that password, creating it or overwriting its stored hash and
+// role. An account that already matches is not rewritten.
+func ensureAdminUser(admin User) error {
+	account, ok, err := verifyPassword(context.Background(), admin.Username, admin.Password)
+	if err != nil {
+		return fmt.Errorf("failed to check admin user %s: %v", admin.Username, err)
+	}
+	if ok && account.Role == roleAdmin {
+		return nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(admin.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password for %s: %v", admin.Username, err)
+	}
+	if _, err := queryUpsertAdmin.Exec(admin.Username, string(hash)); err != nil {
+		return fmt.Errorf("failed to store admin user %s: %v", admin.Username, err)
+	}
+
+	slog.Info("AUDIT admin user set from ADMIN_USER", "user", admin.Username)
+	return nil
+}
+
+// authenticateUser checks the credentials and records the login time
+func authenticateUser(ctx context.Context, username, password string) (UserAccount, bool, error) {
+	account, ok, err := verifyPassword(ctx, username, password)
+	if !ok || err != nil {
+		return account, ok, err
+	}
+
+	if _, err := queryRecordLogin.WithContext(ctx).Exec(account.ID); err != nil {
+		slog.Error("Failed to record login", "user", account.Username, "error", err)
+	}
+	return account, true, nil
+}
+
+// verifyPassword checks password against the stored hash of username
+func verifyPassword(ctx context.Context, username, password string) (UserAccount, bool, error) {
+	var account UserAccount
+	var hash string
+	err := queryUserCredentials.WithContext(ctx).QueryRow(username).
+		Scan(&account.ID, &account.Username, &account.Role, &hash)
+	if err == sql.ErrNoRows {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return UserAccount{}, false, nil
+	}
+	if err != nil {
+		return UserAccount{}, false, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return UserAccount{}, false, nil
+	}
+	return account, true, nil
+}
+
+// revokeUserTokens drops every session of username except the token keep
+// (pass "" to drop all) and returns how many were removed
+func revokeUserTokens(username, keep string) int {
+	var revoked []Session
+
+	tokenMutex.Lock()
+	for token, session := range validTokens {
+		if session.Username == username && token != keep {
+			delete(validTokens, token)
+			revoked = append(revoked, session)
+		}
+	}
+	tokenMutex.Unlock()
+
+	persistRevocations(revoked...)
+	return len(revoked)
+}
+
+// validRole reports whether role can be assigned to a user
+func validRole(role string) bool {
+	return role == roleUser || role == roleAdmin
+}
+
+// parseUserID reads the {id} path variable
+func parseUserID(r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	return id, err == nil && id > 0
+}
+
+// GET /api/users - List users
+// @Summary List users
+// @Description List all API users; password hashes are never returned
+// @Tags users
+// @Produce json,application/xml
+// @Success 200 {array} UserAccount
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/users [get]
+func getUsers(w http.ResponseWriter, r *http.Request) {
+	rows, err := queryListUsers.WithContext(r.Context()).Query()
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to fetch users")
+		return
+	}
+	defer rows.Close()
+
+	users := []UserAccount{}
+	for rows.Next() {
+		account, err := scanUserAccount(rows)
+		if err != nil {
+			respondWithInternalError(w, r, err, "Failed to scan user data")
+			return
+		}
+		users = append(users, account)
+	}
+
+	if err = rows.Err(); err != nil {
+		respondWithInternalError(w, r, err, "Error iterating users")
+		return
+	}
+
+	respondWith(w, r, http.StatusOK, users)
+}
+
+// POST /api/users - Create a user
+// @Summary Create user
+// @Description Create an API user with a bcrypt-hashed password
+// @Tags users
+// @Accept json
+// @Produce json,application/xml
+// @Param user body UserCreateRequest true "User data"
+// @Success 201 {object} UserAccount
+// @Header 201 {string} Location "URL of the created user"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/users [post]
+func createUser(w http.ResponseWriter, r *http.Request) {
+	var req UserCreateRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Role == "" {
+		req.Role = roleUser
+	}
+	if req.Username == "" || req.Password == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, "Username and password are required")
+		return
+	}
+	if len(req.Password) < minPasswordLength {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, fmt.Sprintf("Password must be at least %d characters", minPasswordLength))
+		return
+	}
+	if !validRole(req.Role) {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidRole, "Role must be user or admin")
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to hash password")
+		return
+	}
+
+	account, err := scanUserAccount(queryCreateUser.WithContext(r.Context()).QueryRow(req.Username, string(hash), req.Role))
+	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, r, http.StatusConflict, ErrCodeUsernameTaken, "A user with this username already exists")
+		} else {
+			respondWithInternalError(w, r, err, "Failed to create user")
+		}
+		return
+	}
+
+	session, _ := sessionFromRequest(r)
+	requestLogger(r).Info("AUDIT user created", "username", account.Username, "role", account.Role, "by", session.Username)
+
+	w.Header().Set("Location", apiURL(r, fmt.Sprintf("/users/%d", account.ID)))
+	respondWith(w, r, http.StatusCreated, account)
+}
+
+// PUT /api/users/{id} - Update a user
+// @Summary Update user
+// @Description Change a user's username and role. Existing tokens of the user are revoked.
+// @Tags users
+// @Accept json
+// @Produce json,application/xml
+// @Param id path int true "User ID"
+// @Param user body UserUpdateRequest true "User data"
+// @Success 200 {object} UserAccount
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/users/{id} [put]
+func updateUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUserID(r)
+	if !ok {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidUserID, "Invalid user ID")
+		return
+	}
+
+	var req UserUpdateRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" || req.Role == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, "Username and role are required")
+		return
+	}
+	if !validRole(req.Role) {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidRole, "Role must be user or admin")
+		return
+	}
+
+	var previous string
+	if err := queryUsername.WithContext(r.Context()).QueryRow(id).Scan(&previous); err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, r, http.StatusNotFound, ErrCodeUserNotFound, "User not found")
+		} else {
+			respondWithInternalError(w, r, err, "Failed to fetch user")
+		}
+		return
+	}
+
+	account, err := scanUserAccount(queryUpdateUser.WithContext(r.Context()).QueryRow(req.Username, req.Role, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, r, http.StatusNotFound, ErrCodeUserNotFound, "User not found")
+		} else if isUniqueViolation(err) {
+			respondWithError(w, r, http.StatusConflict, ErrCodeUsernameTaken, "A user with this username already exists")
+		} else {
+			respondWithInternalError(w, r, err, "Failed to update user")
+		}
+		return
+	}
+
+	// Sessions carry the old name and role, so make the user log in again
+	revoked := revokeUserTokens(previous, "")
+	session, _ := sessionFromRequest(r)
+	requestLogger(r).Info("AUDIT user updated", "previous", previous, "username", account.Username, "role", account.Role, "revoked", revoked, "by", session.Username)
+
+	respondWith(w, r, http.StatusOK, account)
+}
+
+// DELETE /api/users/{id} - Delete a user
+// @Summary Delete user
+// @Description Delete a user and revoke their tokens. With DESTRUCTIVE_CONFIRMATION on, the first call returns 428
+// @Description with a confirmation token that must be echoed in X-Confirmation-Token.
+// @Tags users
+// @Produce json,application/xml
+// @Param id path int true "User ID"
+// @Param X-Confirmation-Token header string false "Confirmation token from the 428 response"
+// @Success 204 "No Content"
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 428 {object} ConfirmationRequiredResponse
+// @Security BearerAuth
+// @Router /api/users/{id} [delete]
+func deleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUserID(r)
+	if !ok {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidUserID, "Invalid user ID")
+		return
+	}
+
+	var username string
+	err := queryDeleteUser.WithContext(r.Context()).QueryRow(id).Scan(&username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, r, http.StatusNotFound, ErrCodeUserNotFound, "User not found")
+		} else {
+			respondWithInternalError(w, r, err, "Failed to delete user")
+		}
+		return
+	}
+
+	revoked := revokeUserTokens(username, "")
+	session, _ := sessionFromRequest(r)
+	requestLogger(r).Info("AUDIT user deleted", "username", username, "revoked", revoked, "by", session.Username)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/users/{id}/password - Reset a user's password
+// @Summary Reset user password
+// @Description Set a new password for a user. Existing tokens of the user are revoked.
+// @Tags users
+// @Accept json
+// @Produce json,application/xml
+// @Param id path int true "User ID"
+// @Param password body PasswordResetRequest true "New password"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/users/{id}/password [post]
+func resetUserPassword(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUserID(r)
+	if !ok {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidUserID, "Invalid user ID")
+		return
+	}
+
+	var req PasswordResetRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if len(req.Password) < minPasswordLength {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, fmt.Sprintf("Password must be at least %d characters", minPasswordLength))
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to hash password")
+		return
+	}
+
+	var username string
+	err = queryResetPassword.WithContext(r.Context()).QueryRow(string(hash), id).Scan(&username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, r, http.StatusNotFound, ErrCodeUserNotFound, "User not found")
+		} else {
+			respondWithInternalError(w, r, err, "Failed to reset password")
+		}
+		return
+	}
+
+	revoked := revokeUserTokens(username, "")
+	session, _ := sessionFromRequest(r)
+	requestLogger(r).Info("AUDIT password reset", "username", username, "revoked", revoked, "by", session.Username)
+
+	respondWith(w, r, http.StatusOK, SuccessResponse{Message: "Password updated"})
+}
+
+// POST /api/me/password - Change the caller's own password
+// @Summary Change own password
+// @Description Verify the current password and set a new one. Every other session of the
+// @Description caller is revoked; the token making the request stays valid.
+// @Tags users
+// @Accept json
+// @Produce json,application/xml
+// @Param password body PasswordChangeRequest true "Current and new password"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/me/password [post]
+func changeOwnPassword(w http.ResponseWriter, r *http.Request) {
+	session, _ := sessionFromRequest(r)
+
+	var req PasswordChangeRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, "Current and new password are required")
+		return
+	}
+
+	if locked, remaining := lockedOut(session.Username); locked {
+		respondLockedOut(w, r, remaining)
+		return
+	}
+
+	account, ok, err := verifyPassword(r.Context(), session.Username, req.CurrentPassword)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to check password")
+		return
+	}
+	if !ok {
+		recordPasswordFailure(session.Username)
+		requestLogger(r).Warn("AUDIT password change rejected, wrong current password", "username", session.Username)
+		respondWithError(w, r, http.StatusForbidden, ErrCodeInvalidCredentials, "Current password is incorrect")
+		return
+	}
+	resetPasswordFailures(session.Username)
+
+	if len(req.NewPassword) < minOwnPasswordLength {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, fmt.Sprintf("New password must be at least %d characters", minOwnPasswordLength))
+		return
+	}
+	if strings.EqualFold(req.NewPassword, session.Username) {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeValidationFailed, "New password must not equal the username")
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to hash password")
+		return
+	}
+
+	if _, err := queryChangePassword.WithContext(r.Context()).Exec(string(hash), account.ID); err != nil {
+		respondWithInternalError(w, r, err, "Failed to change password")
+		return
+	}
+
+	current := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	revoked := revokeUserTokens(session.Username, current)
+	requestLogger(r).Info("AUDIT password changed by the user", "username", session.Username, "revoked", revoked)
+
+	respondWith(w, r, http.StatusOK, SuccessResponse{Message: "Password changed"})
+}
+
+// Describe the rows removed by DELETE /api/users/{id}
+func describeUserDelete(r *http.Request) (AffectedRows, error) {
+	id, ok := parseUserID(r)
+	if !ok {
+		// Let the handler reject the malformed ID
+		return AffectedRows{}, nil
+	}
+
+	var count, favorites int
+	if err := queryUserExists.WithContext(r.Context()).QueryRow(id).Scan(&count); err != nil {
+		return nil, err
+	}
+	if err := queryUserFavoriteCount.WithContext(r.Context()).QueryRow(id).Scan(&favorites); err != nil {
+		return nil, err
+	}
+	return AffectedRows{"users": count, "favorites": favorites}, nil
+}
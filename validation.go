@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TierListRules constrains the contents of a tier list
+type TierListRules struct {
+	Tiers            []string `yaml:"tiers"`
+	MaxHeroesPerTier int      `yaml:"max_heroes_per_tier"`
+}
+
+// RoleDifficultyRules lists the difficulties typical for each role. Heroes
+// created with another difficulty are still accepted, with a warning.
+type RoleDifficultyRules struct {
+	Enabled bool                `yaml:"enabled"`
+	Typical map[string][]string `yaml:"typical"`
+}
+
+// ValidationRules holds the semantic validation rules for composite resources
+type ValidationRules struct {
+	TierLists        TierListRules       `yaml:"tier_lists"`
+	RoleDifficulties RoleDifficultyRules `yaml:"role_difficulties"`
+}
+
+// defaultValidationRules returns the rules used when config.yaml doesn't override them
+func defaultValidationRules() ValidationRules {
+	return ValidationRules{
+		TierLists: TierListRules{
+			Tiers:            []string{"S", "A", "B", "C", "D"},
+			MaxHeroesPerTier: 15,
+		},
+	}
+}
+
+// withDefaults fills every unset rule from defaultValidationRules
+func (v ValidationRules) withDefaults() ValidationRules {
+	defaults := defaultValidationRules()
+	if len(v.TierLists.Tiers) == 0 {
+		v.TierLists.Tiers = defaults.TierLists.Tiers
+	}
+	if v.TierLists.MaxHeroesPerTier <= 0 {
+		v.TierLists.MaxHeroesPerTier = defaults.TierLists.MaxHeroesPerTier
+	}
+	return v
+}
+
+// jsonPointer builds an RFC 6901 pointer from path segments
+func jsonPointer(segments ...string) string {
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+
+	var pointer strings.Builder
+	for _, segment := range segments {
+		pointer.WriteString("/")
+		pointer.WriteString(escaper.Replace(segment))
+	}
+	return pointer.String()
+}
+
+// validateTierList checks tier names, tier sizes and that no hero is placed twice.
+// tiers maps a tier name to the hero IDs placed in it. When known is given,
+// heroes missing from it are reported and don't count toward their tier's size.
+func validateTierList(tiers map[string][]HeroID, rules TierListRules, known map[HeroID]bool) []Violation {
+	var violations []Violation
+
+	allowed := make(map[string]bool, len(rules.Tiers))
+	for _, tier := range rules.Tiers {
+		allowed[tier] = true
+	}
+
+	// Walk tiers in a fixed order so violations are reported deterministically
+	names := make([]string, 0, len(tiers))
+	for name := range tiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	placedIn := make(map[HeroID]string)
+	for _, name := range names {
+		if !allowed[name] {
+			violations = append(violations, Violation{
+				Path:    jsonPointer("tiers", name),
+				Message: fmt.Sprintf("unknown tier %q, allowed tiers are %s", name, strings.Join(rules.Tiers, ", ")),
+			})
+		}
+
+		size := 0
+		for i, heroID := range tiers[name] {
+			path := jsonPointer("tiers", name, strconv.Itoa(i))
+			if known != nil && !known[heroID] {
+				violations = append(violations, Violation{Path: path, Message: fmt.Sprintf("hero %s does not exist", heroID)})
+				continue
+			}
+			size++
+
+			if previous, exists := placedIn[heroID]; exists {
+				violations = append(violations, Violation{
+					Path:    path,
+					Message: fmt.Sprintf("hero %s is already placed in tier %s", heroID, previous),
+				})
+				continue
+			}
+			placedIn[heroID] = name
+		}
+
+		if size > rules.MaxHeroesPerTier {
+			violations = append(violations, Violation{
+				Path:    jsonPointer("tiers", name),
+				Message: fmt.Sprintf("tier %s has %d heroes, at most %d allowed", name, size, rules.MaxHeroesPerTier),
+			})
+		}
+	}
+
+	return violations
+}
+
+// roleDifficultyWarnings warns when difficulty is atypical for role. Roles
+// without configured difficulties are never flagged.
+func roleDifficultyWarnings(role, difficulty string, rules RoleDifficultyRules) []string {
+	if !rules.Enabled {
+		return nil
+	}
+
+	typical, known := rules.Typical[role]
+	if !known {
+		return nil
+	}
+	for _, value := range typical {
+		if strings.EqualFold(value, difficulty) {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("difficulty %s is unusual for role %s, typical difficulties are %s",
+		difficulty, role, strings.Join(typical, ", "))}
+}
+
+// Respond with 422 listing every validation violation
+func respondWithViolations(w http.ResponseWriter, r *http.Request, violations []Violation) {
+	respondWith(w, r, http.StatusUnprocessableEntity, ValidationErrorResponse{
+		Code:       ErrCodeValidationFailed,
+		Error:      "Validation failed",
+		Violations: violations,
+	})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateTierList(t *testing.T) {
+	rules := TierListRules{Tiers: []string{"S", "A", "B"}, MaxHeroesPerTier: 2}
+
+	tests := []struct {
+		name  string
+		tiers map[string][]HeroID
+		known map[HeroID]bool
+		want  []Violation
+	}{
+		{
+			name:  "valid",
+			tiers: map[string][]HeroID{"S": {"1", "2"}, "A": {"3"}},
+		},
+		{
+			name:  "empty",
+			tiers: map[string][]HeroID{},
+		},
+		{
+			name:  "exactly at the limit",
+			tiers: map[string][]HeroID{"B": {"1", "2"}},
+			known: map[HeroID]bool{"1": true, "2": true},
+		},
+		{
+			name:  "over the limit",
+			tiers: map[string][]HeroID{"S": {"1", "2", "3"}},
+			want:  []Violation{{Path: "/tiers/S", Message: "tier S has 3 heroes, at most 2 allowed"}},
+		},
+		{
+			name:  "unknown tier",
+			tiers: map[string][]HeroID{"Z": {"1"}},
+			want:  []Violation{{Path: "/tiers/Z", Message: `unknown tier "Z", allowed tiers are S, A, B`}},
+		},
+		{
+			name:  "tier names are case sensitive",
+			tiers: map[string][]HeroID{"s": {"1"}},
+			want:  []Violation{{Path: "/tiers/s", Message: `unknown tier "s", allowed tiers are S, A, B`}},
+		},
+		{
+			name:  "duplicate hero across tiers",
+			tiers: map[string][]HeroID{"A": {"1"}, "S": {"2", "1"}},
+			want:  []Violation{{Path: "/tiers/S/1", Message: "hero 1 is already placed in tier A"}},
+		},
+		{
+			name:  "duplicate hero within a tier",
+			tiers: map[string][]HeroID{"B": {"4", "4"}},
+			want:  []Violation{{Path: "/tiers/B/1", Message: "hero 4 is already placed in tier B"}},
+		},
+		{
+			name:  "unknown hero",
+			tiers: map[string][]HeroID{"S": {"1", "9"}},
+			known: map[HeroID]bool{"1": true},
+			want:  []Violation{{Path: "/tiers/S/1", Message: "hero 9 does not exist"}},
+		},
+		{
+			name:  "unknown heroes don't count toward the limit",
+			tiers: map[string][]HeroID{"S": {"1", "8", "9", "2"}},
+			known: map[HeroID]bool{"1": true, "2": true},
+			want: []Violation{
+				{Path: "/tiers/S/1", Message: "hero 8 does not exist"},
+				{Path: "/tiers/S/2", Message: "hero 9 does not exist"},
+			},
+		},
+		{
+			name:  "tier names with slashes are escaped",
+			tiers: map[string][]HeroID{"S/A": {"1"}},
+			want:  []Violation{{Path: "/tiers/S~1A", Message: `unknown tier "S/A", allowed tiers are S, A, B`}},
+		},
+		{
+			name:  "every violation in fixed order",
+			tiers: map[string][]HeroID{"Z": {"1"}, "A": {"1", "2", "3"}, "S": {"7"}},
+			known: map[HeroID]bool{"1": true, "2": true, "3": true},
+			want: []Violation{
+				{Path: "/tiers/A", Message: "tier A has 3 heroes, at most 2 allowed"},
+				{Path: "/tiers/S/0", Message: "hero 7 does not exist"},
+				{Path: "/tiers/Z", Message: `unknown tier "Z", allowed tiers are S, A, B`},
+				{Path: "/tiers/Z/0", Message: "hero 1 is already placed in tier A"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateTierList(tt.tiers, rules, tt.known)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateTierList() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationRulesWithDefaults(t *testing.T) {
+	got := ValidationRules{TierLists: TierListRules{Tiers: []string{"X"}}}.withDefaults()
+	if !reflect.DeepEqual(got.TierLists.Tiers, []string{"X"}) {
+		t.Errorf("configured tiers replaced: %v", got.TierLists.Tiers)
+	}
+	if got.TierLists.MaxHeroesPerTier != defaultValidationRules().TierLists.MaxHeroesPerTier {
+		t.Errorf("MaxHeroesPerTier = %d, want the default", got.TierLists.MaxHeroesPerTier)
+	}
+}
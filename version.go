@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+)
+
+// Build information, set at build time with
+// -ldflags "-X main.version=1.2.0 -X main.commit=$(git rev-parse --short HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildTime = "unknown"
+)
+
+// GET /version - Build information
+// @Summary Build information
+// @Description Version, git commit and build time of the running binary
+// @Tags health
+// @Produce json,application/xml
+// @Success 200 {object} VersionInfo
+// @Router /version [get]
+func getVersion(w http.ResponseWriter, r *http.Request) {
+	respondWith(w, r, http.StatusOK, VersionInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+	})
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Hero view tracking settings
+const (
+	heroViewFlushInterval = 30 * time.Second
+	heroViewRetention     = 90 * 24 * time.Hour
+	defaultTrendingWindow = 7 * 24 * time.Hour
+	defaultTrendingLimit  = 10
+)
+
+// Queries on hero_views; trending is filtered by visibility per request
+var (
+	queryFlushHeroViews = registerQuery("hero_views.flush", `INSERT INTO hero_views (hero_id, bucket, views)
+		VALUES ($1, date_trunc('hour', CURRENT_TIMESTAMP), $2)
+		ON CONFLICT (hero_id, bucket) DO UPDATE SET views = hero_views.views + EXCLUDED.views`, paramHeroID, paramInt)
+	queryTrendingHeroes = registerBuiltQuery("hero_views.trending")
+)
+
+// heroViewCounter buffers hero detail views in memory so reads don't write to
+// the database. A crash loses at most the views since the last flush.
+type heroViewCounter struct {
+	mu      sync.Mutex
+	pending map[HeroID]int64
+}
+
+// Views counted since the last flush
+var heroViews = &heroViewCounter{pending: make(map[HeroID]int64)}
+
+// record counts one view of id
+func (c *heroViewCounter) record(id HeroID) {
+	c.mu.Lock()
+	c.pending[id]++
+	c.mu.Unlock()
+}
+
+// flush writes the buffered views into the current hour bucket. Views that
+// fail to write are put back and retried on the next flush.
+func (c *heroViewCounter) flush() error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[HeroID]int64)
+	c.mu.Unlock()
+
+	var failed error
+	for id, views := range pending {
+		if _, err := queryFlushHeroViews.Exec(id, views); err != nil {
+			failed = err
+			c.mu.Lock()
+			c.pending[id] += views
+			c.mu.Unlock()
+		}
+	}
+	return failed
+}
+
+// initHeroViews registers hero_views for retention pruning and starts the
+// flusher. The returned channel is closed after the final flush that follows
+// ctx being cancelled.
+func initHeroViews(ctx context.Context) <-chan struct{} {
+	registerManagedTable("hero_views", "bucket", heroViewRetention)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(heroViewFlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := heroViews.flush(); err != nil {
+					slog.Error("Failed to flush hero views", "error", err)
+				}
+			case <-ctx.Done():
+				if err := heroViews.flush(); err != nil {
+					slog.Error("Failed to flush hero views on shutdown", "error", err)
+				}
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// heroViewMiddleware counts successful GETs of a hero as views, including
+// those served from the cache
+func heroViewMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// HEAD is an existence check, not a view
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status == http.StatusOK {
+			if id, err := heroIDs.Parse(mux.Vars(r)["id"]); err == nil {
+				heroViews.record(id)
+			}
+		}
+	})
+}
+
+// parseWindow parses a trending window such as "7d", "12h" or "90m"
+func parseWindow(value string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 {
+			return 0, errors.New("window must be a positive duration such as 7d or 24h")
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	window, err := time.ParseDuration(value)
+	if err != nil || window <= 0 {
+		return 0, errors.New("window must be a positive duration such as 7d or 24h")
+	}
+	return window, nil
+}
+
+// GET /api/heroes/trending - Most viewed heroes
+// @Summary Trending heroes
+// @Description Heroes with the most detail views over a recent window. Views are flushed
+// @Description every 30 seconds, so the newest ones may not be counted yet.
+// @Tags heroes
+// @Produce json,application/xml
+// @Param window query string false "Period to count views over, e.g. 7d or 24h (default 7d)"
+// @Param limit query int false "Number of heroes (default 10, at most the configured max page size, 100 by default)"
+// @Success 200 {array} TrendingHero
+// @Failure 400 {object} ErrorResponse
+// @Router /api/heroes/trending [get]
+func getTrendingHeroes(w http.ResponseWriter, r *http.Request) {
+	window := defaultTrendingWindow
+	if value := r.URL.Query().Get("window"); value != "" {
+		var err error
+		if window, err = parseWindow(value); err != nil {
+			respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
+			return
+		}
+	}
+
+	limit := defaultTrendingLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidQuery, "limit must be a positive integer")
+			return
+		}
+		if limit > maxPageLimit() {
+			limit = maxPageLimit()
+		}
+	}
+
+	filter := &heroFilter{}
+	filter.add("v.bucket >= $%d", dbClock.Now().Add(-window))
+	filter.restrictVisibility(r)
+
+	query := "SELECT " + heroColumns + ", SUM(v.views) FROM heroes JOIN hero_views v ON v.hero_id = heroes.id::text" +
+		filter.where() + fmt.Sprintf(" GROUP BY heroes.id ORDER BY SUM(v.views) DESC, heroes.id LIMIT $%d", len(filter.args)+1)
+
+	rows, err := queryTrendingHeroes.WithContext(r.Context()).Build(query).Query(append(filter.args, limit)...)
+	if err != nil {
+		respondWithInternalError(w, r, err, "Failed to fetch trending heroes")
+		return
+	}
+	defer rows.Close()
+
+	trending := []TrendingHero{}
+	for rows.Next() {
+		var hero TrendingHero
+		if err := rows.Scan(heroScanDest(&hero.Hero, &hero.Views)...); err != nil {
+			respondWithInternalError(w, r, err, "Failed to scan hero data")
+			return
+		}
+		trending = append(trending, hero)
+	}
+
+	if err := rows.Err(); err != nil {
+		respondWithInternalError(w, r, err, "Error iterating heroes")
+		return
+	}
+
+	respondWith(w, r, http.StatusOK, trending)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// heroVisibility is the set of hero lifecycle states a caller may read
+type heroVisibility string
+
+// Visibility levels, from most to least restricted
+const (
+	visibleActive   heroVisibility = "active"
+	visibleArchived heroVisibility = "archived"
+	visibleAll      heroVisibility = "all"
+)
+
+// visibilityFor applies the hero visibility policy to a request:
+// anonymous callers see active heroes, authenticated users also see archived
+// ones, and admins see soft-deleted heroes too when they ask for ?include=all.
+func visibilityFor(r *http.Request) heroVisibility {
+	session, ok := sessionFromRequest(r)
+	if !ok {
+		session, ok = lookupSession(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	}
+	if !ok {
+		return visibleActive
+	}
+
+	if session.Role == roleAdmin && r.URL.Query().Get("include") == "all" {
+		return visibleAll
+	}
+	return visibleArchived
+}
+
+// condition returns the SQL fragment restricting heroes to this visibility, or "" for all
+func (v heroVisibility) condition() string {
+	switch v {
+	case visibleAll:
+		return ""
+	case visibleArchived:
+		return "deleted_at IS NULL"
+	default:
+		return "deleted_at IS NULL AND archived_at IS NULL"
+	}
+}
+
+// allows reports whether a hero in its current lifecycle state is visible, matching condition
+func (v heroVisibility) allows(hero Hero) bool {
+	switch v {
+	case visibleAll:
+		return true
+	case visibleArchived:
+		return hero.DeletedAt == nil
+	default:
+		return hero.DeletedAt == nil && hero.ArchivedAt == nil
+	}
+}
+
+// restrictVisibility limits the filter to heroes the caller may read.
+// Every read path over heroes must call this.
+func (f *heroFilter) restrictVisibility(r *http.Request) {
+	if condition := visibilityFor(r).condition(); condition != "" {
+		f.conditions = append(f.conditions, condition)
+	}
+}
+
+// Lifecycle writes; archiving leaves soft-deleted heroes alone so they stay hidden from users
+var (
+	queryArchiveHero    = registerQuery("heroes.archive", "UPDATE heroes SET archived_at = COALESCE(archived_at, NOW()) WHERE id = $1 AND deleted_at IS NULL RETURNING "+heroColumns, paramHeroID)
+	queryUnarchiveHero  = registerQuery("heroes.unarchive", "UPDATE heroes SET archived_at = NULL WHERE id = $1 AND deleted_at IS NULL RETURNING "+heroColumns, paramHeroID)
+	querySoftDeleteHero = registerQuery("heroes.soft_delete", "UPDATE heroes SET deleted_at = COALESCE(deleted_at, NOW()) WHERE id = $1 RETURNING "+heroColumns, paramHeroID)
+	queryRestoreHero    = registerQuery("heroes.restore", "UPDATE heroes SET deleted_at = NULL WHERE id = $1 RETURNING "+heroColumns, paramHeroID)
+)
+
+// POST /api/heroes/{id}/archive - Archive a hero
+// @Summary Archive hero
+// @Description Hide a hero from anonymous callers; authenticated users still see it. Archiving an archived hero keeps its archived_at.
+// @Tags heroes
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Success 200 {object} Hero
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/{id}/archive [post]
+func archiveHero(w http.ResponseWriter, r *http.Request) {
+	setHeroLifecycle(w, r, queryArchiveHero, "Failed to archive hero")
+}
+
+// POST /api/heroes/{id}/unarchive - Make an archived hero active again
+// @Summary Unarchive hero
+// @Description Clear archived_at so the hero is visible to everyone again.
+// @Tags heroes
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Success 200 {object} Hero
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/{id}/unarchive [post]
+func unarchiveHero(w http.ResponseWriter, r *http.Request) {
+	setHeroLifecycle(w, r, queryUnarchiveHero, "Failed to unarchive hero")
+}
+
+// POST /api/heroes/{id}/soft-delete - Soft-delete a hero
+// @Summary Soft-delete hero
+// @Description Hide a hero from everyone except admins reading with ?include=all. Unlike DELETE /api/heroes/{id}
+// @Description the row, its tags, counters and tier placements are kept and can be restored.
+// @Tags heroes
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Success 200 {object} Hero
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/{id}/soft-delete [post]
+func softDeleteHero(w http.ResponseWriter, r *http.Request) {
+	setHeroLifecycle(w, r, querySoftDeleteHero, "Failed to delete hero")
+}
+
+// POST /api/heroes/{id}/restore - Restore a soft-deleted hero
+// @Summary Restore hero
+// @Description Clear deleted_at; a hero archived before it was deleted stays archived.
+// @Tags heroes
+// @Produce json,application/xml
+// @Param id path string true "Hero ID"
+// @Success 200 {object} Hero
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Router /api/heroes/{id}/restore [post]
+func restoreHero(w http.ResponseWriter, r *http.Request) {
+	setHeroLifecycle(w, r, queryRestoreHero, "Failed to restore hero")
+}
+
+// setHeroLifecycle runs a lifecycle write for the hero in the path and responds with the hero
+func setHeroLifecycle(w http.ResponseWriter, r *http.Request, query *namedQuery, failure string) {
+	id, err := heroIDs.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, ErrCodeInvalidHeroID, "Invalid hero ID")
+		return
+	}
+
+	var hero Hero
+	if err := query.WithContext(r.Context()).QueryRow(id).Scan(heroScanDest(&hero)...); err != nil {
+		respondWithHeroWriteError(w, r, err, failure)
+		return
+	}
+
+	invalidateHeroCache()
+	publishHeroEvent(r, eventUpdated, hero)
+
+	respondWith(w, r, http.StatusOK, hero)
+}
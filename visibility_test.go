@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addTestSession registers a session for role and returns its bearer token
+func addTestSession(t *testing.T, role string) string {
+	t.Helper()
+	token := "test-token-" + role
+	tokenMutex.Lock()
+	validTokens[token] = Session{ID: token, Username: role + "-user", Role: role, ExpiresAt: time.Now().Add(time.Hour)}
+	tokenMutex.Unlock()
+	t.Cleanup(func() {
+		tokenMutex.Lock()
+		delete(validTokens, token)
+		tokenMutex.Unlock()
+	})
+	return token
+}
+
+// respondNotRevoked answers the revocation check authMiddleware runs, and
+// every other query with no rows
+func respondNotRevoked(query string, _ []interface{}) *fakeResult {
+	if strings.Contains(query, "revoked_tokens") {
+		return &fakeResult{columns: []string{"exists"}, rows: [][]driver.Value{{false}}}
+	}
+	return nil
+}
+
+func TestHeroVisibilityMatrix(t *testing.T) {
+	archivedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	deletedAt := archivedAt.Add(time.Hour)
+	states := []struct {
+		name string
+		hero Hero
+	}{
+		{"active", Hero{ID: "1"}},
+		{"archived", Hero{ID: "2", ArchivedAt: &archivedAt}},
+		{"deleted", Hero{ID: "3", DeletedAt: &deletedAt}},
+		{"archived then deleted", Hero{ID: "4", ArchivedAt: &archivedAt, DeletedAt: &deletedAt}},
+	}
+
+	userToken, adminToken := addTestSession(t, roleUser), addTestSession(t, roleAdmin)
+	callers := []struct {
+		name    string
+		token   string
+		include string
+		want    heroVisibility
+		// visible per state, in the order of states
+		sees []bool
+	}{
+		{"anonymous", "", "", visibleActive, []bool{true, false, false, false}},
+		{"anonymous asking for all", "", "all", visibleActive, []bool{true, false, false, false}},
+		{"unknown token", "not-a-session", "all", visibleActive, []bool{true, false, false, false}},
+		{"user", userToken, "", visibleArchived, []bool{true, true, false, false}},
+		{"user asking for all", userToken, "all", visibleArchived, []bool{true, true, false, false}},
+		{"admin", adminToken, "", visibleArchived, []bool{true, true, false, false}},
+		{"admin asking for all", adminToken, "all", visibleAll, []bool{true, true, true, true}},
+	}
+
+	for _, caller := range callers {
+		t.Run(caller.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/heroes?include="+caller.include, nil)
+			if caller.token != "" {
+				r.Header.Set("Authorization", "Bearer "+caller.token)
+			}
+			visibility := visibilityFor(r)
+			if visibility != caller.want {
+				t.Fatalf("visibilityFor() = %s, want %s", visibility, caller.want)
+			}
+			for i, state := range states {
+				if got := visibility.allows(state.hero); got != caller.sees[i] {
+					t.Errorf("%s hero: allows() = %v, want %v", state.name, got, caller.sees[i])
+				}
+			}
+		})
+	}
+}
+
+// Every read path over heroes runs through the router against the fake
+// database, and each statement reading heroes must carry the caller's
+// visibility condition. The event stream is covered by TestStreamHidesInvisibleHeroes.
+func TestRouterReadsRestrictVisibility(t *testing.T) {
+	cfg := useTestConfig(t)
+	captureLogs(t)
+	connector := useFakeDB(t)
+	connector.respond = respondNotRevoked
+
+	userToken, adminToken := addTestSession(t, roleUser), addTestSession(t, roleAdmin)
+	callers := []struct {
+		name    string
+		token   string
+		include string
+		want    heroVisibility
+	}{
+		{"anonymous", "", "", visibleActive},
+		{"anonymous asking for all", "", "all", visibleActive},
+		{"user asking for all", userToken, "all", visibleArchived},
+		{"admin", adminToken, "", visibleArchived},
+		{"admin asking for all", adminToken, "all", visibleAll},
+	}
+
+	endpoints := []struct {
+		path string
+		// requires a session; anonymous callers get 401 before any hero is read
+		auth bool
+	}{
+		{"/api/heroes", false},
+		{"/api/heroes/1", false},
+		{"/api/heroes/1/exists", false},
+		{"/api/heroes/search?q=a", false},
+		{"/api/heroes/trending", false},
+		{"/api/heroes/draft", false},
+		{"/api/heroes/1/counters", false},
+		{"/api/tierlist", false},
+		{"/api/roles", false},
+		{"/api/difficulties", false},
+		{"/api/me/favorites", true},
+	}
+
+	forEachIDStrategy(t, func(t *testing.T, _ idStrategy) {
+		for _, endpoint := range endpoints {
+			for _, caller := range callers {
+				t.Run(endpoint.path+" as "+caller.name, func(t *testing.T) {
+					separator := "?"
+					if strings.Contains(endpoint.path, "?") {
+						separator = "&"
+					}
+					r := httptest.NewRequest(http.MethodGet, endpoint.path+separator+"include="+caller.include, nil)
+					if caller.token != "" {
+						r.Header.Set("Authorization", "Bearer "+caller.token)
+					}
+
+					before := len(connector.executed())
+					rec := serve(cfg, r)
+					if endpoint.auth && caller.token == "" {
+						if rec.Code != http.StatusUnauthorized {
+							t.Errorf("status = %d, want 401", rec.Code)
+						}
+						return
+					}
+
+					var reads int
+					for _, exec := range connector.executed()[before:] {
+						if !strings.Contains(exec.query, "FROM heroes") {
+							continue
+						}
+						reads++
+						hidesDeleted := strings.Contains(exec.query, "deleted_at IS NULL")
+						hidesArchived := strings.Contains(exec.query, "archived_at IS NULL")
+						if hidesDeleted != (caller.want != visibleAll) || hidesArchived != (caller.want == visibleActive) {
+							t.Errorf("statement for %s visibility has the wrong condition:\n%s", caller.want, exec.query)
+						}
+					}
+					if reads == 0 {
+						t.Errorf("status %d without reading heroes; body %s", rec.Code, rec.Body.String())
+					}
+				})
+			}
+		}
+	})
+}
+
+// authMiddleware puts the session into the context; visibility must read it from there too
+func TestVisibilityForUsesContextSession(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/heroes?include=all", nil)
+	ctx := context.WithValue(r.Context(), sessionContextKey, Session{Username: "root", Role: roleAdmin})
+	if got := visibilityFor(r.WithContext(ctx)); got != visibleAll {
+		t.Errorf("visibilityFor() = %s, want %s", got, visibleAll)
+	}
+}
+
+// condition is applied in SQL while allows filters events; they must agree
+func TestHeroVisibilityConditionMatchesAllows(t *testing.T) {
+	archivedAt, deletedAt := time.Now(), time.Now()
+	for _, visibility := range []heroVisibility{visibleActive, visibleArchived, visibleAll} {
+		condition := visibility.condition()
+		hidesDeleted := strings.Contains(condition, "deleted_at IS NULL")
+		hidesArchived := strings.Contains(condition, "archived_at IS NULL")
+
+		if got := visibility.allows(Hero{DeletedAt: &deletedAt}); got == hidesDeleted {
+			t.Errorf("%s: condition %q but allows(deleted) = %v", visibility, condition, got)
+		}
+		if got := visibility.allows(Hero{ArchivedAt: &archivedAt}); got == hidesArchived {
+			t.Errorf("%s: condition %q but allows(archived) = %v", visibility, condition, got)
+		}
+		if !visibility.allows(Hero{}) {
+			t.Errorf("%s hides active heroes", visibility)
+		}
+	}
+}
+
+// Authenticated cases need the revocation table; these only check the routes are protected
+func TestRouterLifecycleWritesRequireAuthentication(t *testing.T) {
+	cfg := useTestConfig(t)
+
+	for _, path := range []string{"/api/heroes/1/archive", "/api/heroes/1/unarchive", "/api/heroes/1/soft-delete", "/api/heroes/1/restore"} {
+		t.Run(path, func(t *testing.T) {
+			rec := serve(cfg, httptest.NewRequest(http.MethodPost, path, nil))
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want 401; body %s", rec.Code, rec.Body.String())
+			}
+			if body := decodeError(t, rec); body.Code != ErrCodeUnauthorized {
+				t.Errorf("code = %s, want %s", body.Code, ErrCodeUnauthorized)
+			}
+		})
+	}
+}
+
+func TestStreamHidesInvisibleHeroes(t *testing.T) {
+	useTestConfig(t)
+	lore := "secret lore"
+	archivedAt := time.Now()
+	events := []HeroEvent{
+		{Type: eventUpdated, Hero: Hero{ID: "1", Name: "Alucard"}},
+		{Type: eventUpdated, Hero: Hero{ID: "2", Name: "Layla", ArchivedAt: &archivedAt}},
+		{Type: eventUpdated, Hero: Hero{ID: "2", Name: "Layla", HeroDetails: HeroDetails{Lore: &lore}, ArchivedAt: &archivedAt}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		streamHeroEvents(rec, httptest.NewRequest(http.MethodGet, "/api/heroes/events", nil).WithContext(ctx))
+	}()
+
+	subscribed := func() bool {
+		heroEvents.mu.RLock()
+		defer heroEvents.mu.RUnlock()
+		return len(heroEvents.subscribers) > 0
+	}
+	if !waitFor(t, time.Second, subscribed) {
+		t.Fatal("stream never subscribed")
+	}
+	for _, event := range events {
+		heroEvents.publish(event)
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	want := "event: updated\ndata: " + `{"id":1,"name":"Alucard"`
+	body := rec.Body.String()
+	if !strings.Contains(body, want) {
+		t.Errorf("stream %q, want the visible hero in full", body)
+	}
+	if got := strings.Count(body, "event: deleted\ndata: {\"id\":2}\n\n"); got != 2 {
+		t.Errorf("stream %q has %d tombstones for the archived hero, want 2", body, got)
+	}
+	for _, leak := range []string{"Layla", "secret lore", "archived_at"} {
+		if strings.Contains(body, leak) {
+			t.Errorf("stream %q leaks %q to an anonymous caller", body, leak)
+		}
+	}
+}